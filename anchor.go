@@ -0,0 +1,130 @@
+package rst
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Anchors generates unique anchor ids for titles within a single document,
+// such as for the id attributes of headings in rendered output.
+//
+// The zero value generates ids in the same way as docutils. An Anchors value
+// remembers the ids it has generated so far, so a separate value must be
+// used for each document.
+type Anchors struct {
+	// Slug converts a title into a candidate id. If nil, DocutilsSlug is
+	// used. GitHubSlug is an alternative that matches the anchors GitHub
+	// generates for headings in Markdown files.
+	//
+	// Slug may return an empty string if the title contains nothing
+	// usable, in which case an id is generated as for a duplicate.
+	Slug func(Text) string
+
+	// Duplicates selects how ids are made unique when a candidate id has
+	// already been used.
+	Duplicates DuplicateStyle
+
+	used  map[string]bool
+	count int
+	bases map[string]int
+}
+
+// DuplicateStyle selects a strategy for making anchor ids unique.
+type DuplicateStyle int
+
+const (
+	// DocutilsDuplicates replaces a duplicate or empty id with "id"
+	// followed by a number that counts generated ids across the whole
+	// document, so that the first is "id1", the second "id2", and so on.
+	DocutilsDuplicates DuplicateStyle = iota
+
+	// GitHubDuplicates adds a suffix to a duplicate id counting the
+	// previous uses of the same id, so that the second "intro" becomes
+	// "intro-1", the third "intro-2", and so on. An empty id is treated
+	// as if it were "section".
+	GitHubDuplicates
+)
+
+// ID returns a unique anchor id for the given title.
+func (a *Anchors) ID(title Text) string {
+	if a.used == nil {
+		a.used = make(map[string]bool)
+		a.bases = make(map[string]int)
+	}
+
+	slug := a.Slug
+	if slug == nil {
+		slug = DocutilsSlug
+	}
+	id := slug(title)
+
+	switch a.Duplicates {
+	case GitHubDuplicates:
+		if id == "" {
+			id = "section"
+		}
+		base := id
+		for a.used[id] {
+			a.bases[base]++
+			id = base + "-" + strconv.Itoa(a.bases[base])
+		}
+	default:
+		for id == "" || a.used[id] {
+			a.count++
+			id = "id" + strconv.Itoa(a.count)
+		}
+	}
+
+	a.used[id] = true
+	return id
+}
+
+// DocutilsSlug converts the given title into an id in the same way as the
+// docutils make_id function: it is lowercased, runs of ASCII characters
+// other than letters and digits become single hyphens, and any leading
+// hyphens and digits and any trailing hyphens are removed.
+//
+// Docutils first decomposes accented characters so that their ASCII base
+// letters are kept. This function does not, and simply drops all non-ASCII
+// characters.
+func DocutilsSlug(title Text) string {
+	var buf strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(strings.Join(strings.Fields(title.String()), " ")) {
+		switch {
+		case r > unicode.MaxASCII:
+			// dropped entirely
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen {
+				buf.WriteByte('-')
+			}
+			hyphen = false
+			buf.WriteRune(r)
+		default:
+			hyphen = true
+		}
+	}
+	if hyphen {
+		buf.WriteByte('-')
+	}
+	id := strings.TrimLeft(buf.String(), "-0123456789")
+	return strings.TrimRight(id, "-")
+}
+
+// GitHubSlug converts the given title into an id in the same way as GitHub
+// does for headings in Markdown files: it is lowercased, spaces become
+// hyphens, and all other characters except letters, digits, hyphens and
+// underscores are removed.
+func GitHubSlug(title Text) string {
+	var buf strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title.String())) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			buf.WriteRune(r)
+		case r == ' ':
+			buf.WriteByte('-')
+		}
+	}
+	return buf.String()
+}
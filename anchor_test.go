@@ -0,0 +1,102 @@
+package rst
+
+import (
+	"testing"
+)
+
+func TestDocutilsSlug(t *testing.T) {
+	tests := []struct {
+		Input, Want string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Spaces   and\ttabs ", "spaces-and-tabs"},
+		{"2. Numbered heading", "numbered-heading"},
+		{"C++ & Go!", "c-go"},
+		{"naïve café", "nave-caf"},
+		{"123", ""},
+	}
+	for _, test := range tests {
+		if got := DocutilsSlug(Text{CharData(test.Input)}); got != test.Want {
+			t.Errorf("DocutilsSlug(%q) = %q; want %q", test.Input, got, test.Want)
+		}
+	}
+}
+
+func TestGitHubSlug(t *testing.T) {
+	tests := []struct {
+		Input, Want string
+	}{
+		{"Hello World", "hello-world"},
+		{"2. Numbered heading", "2-numbered-heading"},
+		{"C++ & Go!", "c--go"},
+		{"naïve café", "naïve-café"},
+		{"snake_case and-hyphens", "snake_case-and-hyphens"},
+		{"???", ""},
+	}
+	for _, test := range tests {
+		if got := GitHubSlug(Text{CharData(test.Input)}); got != test.Want {
+			t.Errorf("GitHubSlug(%q) = %q; want %q", test.Input, got, test.Want)
+		}
+	}
+}
+
+func TestAnchors(t *testing.T) {
+	titles := []string{"Intro", "Intro", "???", "Intro", "Usage"}
+
+	tests := []struct {
+		Name    string
+		Anchors Anchors
+		Want    []string
+	}{
+		{
+			"docutils",
+			Anchors{},
+			[]string{"intro", "id1", "id2", "id3", "usage"},
+		},
+		{
+			"github",
+			Anchors{Slug: GitHubSlug, Duplicates: GitHubDuplicates},
+			[]string{"intro", "intro-1", "section", "intro-2", "usage"},
+		},
+		{
+			"github slugs with docutils duplicates",
+			Anchors{Slug: GitHubSlug},
+			[]string{"intro", "id1", "id2", "id3", "usage"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			anchors := test.Anchors
+			for i, title := range titles {
+				if got := anchors.ID(Text{CharData(title)}); got != test.Want[i] {
+					t.Errorf("ID(%q) #%d = %q; want %q", title, i, got, test.Want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAnchorsCollision(t *testing.T) {
+	// A generated suffix must not collide with an id that was derived
+	// directly from an earlier title.
+	anchors := Anchors{Slug: GitHubSlug, Duplicates: GitHubDuplicates}
+	var got []string
+	for _, title := range []string{"a-1", "a", "a"} {
+		got = append(got, anchors.ID(Text{CharData(title)}))
+	}
+	want := []string{"a-1", "a", "a-2"}
+	if diff := Diff(want, got); diff != "" {
+		t.Errorf("wrong ids\n%s", diff)
+	}
+
+	anchors = Anchors{}
+	got = nil
+	for _, title := range []string{"id1", "", ""} {
+		got = append(got, anchors.ID(Text{CharData(title)}))
+	}
+	want = []string{"id1", "id2", "id3"}
+	if diff := Diff(want, got); diff != "" {
+		t.Errorf("wrong ids\n%s", diff)
+	}
+}
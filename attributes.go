@@ -0,0 +1,109 @@
+package rst
+
+import (
+	"strings"
+)
+
+// Attributes are the generic attributes that docutils allows on every
+// element. They are embedded in each of the element types, and can be
+// accessed generically using NodeAttributes.
+//
+// The zero value has no attributes. As with the sequence types, the parser
+// leaves every field nil when it is empty, so that embedding Attributes
+// does not affect comparisons of trees that don't use them.
+//
+// The methods that only read attributes may be called on a nil *Attributes,
+// which behaves as if it had no attributes. The methods that modify
+// attributes must be called on a non-nil value.
+type Attributes struct {
+	// IDs are the unique identifiers of the element, for use as anchors.
+	IDs []string
+
+	// Names are the reference names of the element, such as those given
+	// by targets. Names are whitespace-normalized and lowercase; see
+	// SetName.
+	Names []string
+
+	// Classes are class names that select how the element is presented,
+	// such as by the class directive.
+	Classes []string
+
+	// Extra holds any other attributes, keyed by name.
+	Extra map[string]string
+}
+
+// Attrs returns a. Since Attributes is embedded in each element type, this
+// method allows the attributes of any element to be reached through an
+// interface, as NodeAttributes does.
+func (a *Attributes) Attrs() *Attributes {
+	return a
+}
+
+// HasClass returns true if the given class is among the element's classes.
+func (a *Attributes) HasClass(class string) bool {
+	if a == nil {
+		return false
+	}
+	for _, existing := range a.Classes {
+		if existing == class {
+			return true
+		}
+	}
+	return false
+}
+
+// AddClass adds the given class to the element's classes, unless it is
+// already present.
+func (a *Attributes) AddClass(class string) {
+	if !a.HasClass(class) {
+		a.Classes = append(a.Classes, class)
+	}
+}
+
+// SetName replaces the element's names with the given name, normalized in
+// the same way as docutils normalizes reference names: runs of whitespace
+// become single spaces, leading and trailing whitespace is removed, and
+// the result is lowercased.
+func (a *Attributes) SetName(name string) {
+	a.Names = []string{normalizeName(name)}
+}
+
+// ExtraAttr returns the value of the given extra attribute, and whether it
+// is set at all.
+func (a *Attributes) ExtraAttr(name string) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	value, ok := a.Extra[name]
+	return value, ok
+}
+
+// SetExtraAttr sets the value of the given extra attribute, allocating the
+// Extra map if necessary.
+func (a *Attributes) SetExtraAttr(name, value string) {
+	if a.Extra == nil {
+		a.Extra = make(map[string]string)
+	}
+	a.Extra[name] = value
+}
+
+// IsEmpty returns true if no attributes are set.
+func (a *Attributes) IsEmpty() bool {
+	return a == nil || (len(a.IDs) == 0 && len(a.Names) == 0 && len(a.Classes) == 0 && len(a.Extra) == 0)
+}
+
+// NodeAttributes returns the generic attributes of the given node, or nil
+// if it is not an element that has them, such as a sequence or CharData.
+//
+// The result points into the node itself, so modifying it modifies the
+// node.
+func NodeAttributes(node interface{}) *Attributes {
+	if n, ok := node.(interface{ Attrs() *Attributes }); ok {
+		return n.Attrs()
+	}
+	return nil
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,114 @@
+package rst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	var a Attributes
+	if !a.IsEmpty() {
+		t.Errorf("zero value is not empty")
+	}
+
+	a.AddClass("note")
+	a.AddClass("wide")
+	a.AddClass("note")
+	if got, want := strings.Join(a.Classes, ","), "note,wide"; got != want {
+		t.Errorf("wrong classes %q; want %q", got, want)
+	}
+	if !a.HasClass("wide") || a.HasClass("narrow") {
+		t.Errorf("HasClass gives wrong results for %#v", a.Classes)
+	}
+
+	a.SetName("  Hello\n   World ")
+	if got, want := strings.Join(a.Names, ","), "hello world"; got != want {
+		t.Errorf("wrong names %q; want %q", got, want)
+	}
+
+	if _, ok := a.ExtraAttr("lang"); ok {
+		t.Errorf("unset extra attribute is present")
+	}
+	a.SetExtraAttr("lang", "en")
+	if got, ok := a.ExtraAttr("lang"); !ok || got != "en" {
+		t.Errorf("wrong extra attribute %q, %v", got, ok)
+	}
+	if a.IsEmpty() {
+		t.Errorf("attributes are empty after setting them")
+	}
+}
+
+func TestAttributesNil(t *testing.T) {
+	var a *Attributes
+	if !a.IsEmpty() {
+		t.Errorf("nil attributes are not empty")
+	}
+	if a.HasClass("note") {
+		t.Errorf("nil attributes have a class")
+	}
+	if _, ok := a.ExtraAttr("lang"); ok {
+		t.Errorf("nil attributes have an extra attribute")
+	}
+}
+
+func TestNodeAttributes(t *testing.T) {
+	para := &Paragraph{Text: Text{CharData("hello")}}
+	NodeAttributes(para).AddClass("lead")
+	if !para.HasClass("lead") {
+		t.Errorf("change through NodeAttributes did not affect the node")
+	}
+
+	for _, node := range []interface{}{
+		CharData("hello"),
+		Text{CharData("hello")},
+		Body{para},
+		&Fragment{},
+	} {
+		if got := NodeAttributes(node); got != nil {
+			t.Errorf("NodeAttributes(%T) = %#v; want nil", node, got)
+		}
+	}
+}
+
+func TestAttributesWriters(t *testing.T) {
+	para := &Paragraph{
+		Text: Text{CharData("hello")},
+	}
+	para.IDs = []string{"intro"}
+	para.SetName("Intro Text")
+	para.AddClass("lead")
+	para.SetExtraAttr("lang", "en")
+	fragment := &Fragment{Body: Body{para}}
+
+	t.Run("xml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteXML(&buf, fragment); err != nil {
+			t.Fatal(err)
+		}
+		want := `<paragraph ids="intro" names="intro\ text" classes="lead" lang="en">hello</paragraph>`
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output does not contain %s\n%s", want, buf.String())
+		}
+	})
+	t.Run("pseudoxml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WritePseudoXML(&buf, fragment); err != nil {
+			t.Fatal(err)
+		}
+		want := `<paragraph classes="lead" ids="intro" lang="en" names="intro\ text">`
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output does not contain %s\n%s", want, buf.String())
+		}
+	})
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := DumpTree(&buf, fragment); err != nil {
+			t.Fatal(err)
+		}
+		want := `#intro.lead[name="intro text"][lang="en"]`
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output does not contain %s\n%s", want, buf.String())
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package rst
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// benchmarkSource builds a document of roughly the given size by repeating
+// the golden test inputs, which together contain a realistic mixture of
+// constructs.
+func benchmarkSource(b *testing.B, size int) []byte {
+	var sample []byte
+	for _, name := range []string{"pep.rst", "sphinx-index.rst", "bullet-list.rst", "block-quote.rst"} {
+		src, err := ioutil.ReadFile(filepath.Join("testdata", "golden", name))
+		if err != nil {
+			b.Fatal(err)
+		}
+		sample = append(sample, src...)
+		sample = append(sample, '\n')
+	}
+
+	var buf bytes.Buffer
+	for buf.Len() < size {
+		buf.Write(sample)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParseFragment(b *testing.B) {
+	src := benchmarkSource(b, 1<<20)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ParseFragmentBytes(src, "bench.rst")
+	}
+}
+
+// BenchmarkParseFragmentReader parses the same source as
+// BenchmarkParseFragment, but from a reader, for comparison.
+func BenchmarkParseFragmentReader(b *testing.B) {
+	src := benchmarkSource(b, 1<<20)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ParseFragment(bytes.NewReader(src), "bench.rst")
+	}
+}
+
+func BenchmarkScanner(b *testing.B) {
+	src := benchmarkSource(b, 1<<20)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		scanner := NewScanner(bytes.NewReader(src), "bench.rst")
+		for {
+			token := scanner.Read()
+			if token.Type == EOF || token.Type == ERROR {
+				break
+			}
+		}
+	}
+}
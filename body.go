@@ -2,6 +2,10 @@ package rst
 
 // Body represents body markup, which is the set of elements that make up
 // the body of a section.
+//
+// Like the other sequence types in this package, an empty Body is always
+// represented as nil by the parser. Use Equal to compare trees that may
+// have been constructed without following this convention.
 type Body []BodyElement
 
 // BodyElement is an interface that represents the set of types that are
@@ -25,11 +29,23 @@ func (i *bodyElementImpl) BodyElement() BodyElement {
 
 type Paragraph struct {
 	bodyElementImpl
+	Attributes
 	Text
+	Pos Position
+}
+
+func (p *Paragraph) Position() Position {
+	return p.Pos
 }
 
 type BlockQuote struct {
 	bodyElementImpl
+	Attributes
 	Quote       Body
 	Attribution Text
+	Pos         Position
+}
+
+func (q *BlockQuote) Position() Position {
+	return q.Pos
 }
@@ -0,0 +1,194 @@
+package rst
+
+// Clone returns a deep copy of the given node, which may be any element
+// defined in this package or one of the sequence types Structure, Body,
+// Text or []*ListItem. The result has the same type as the given node, and
+// shares no elements, sequences or attributes with it, so either can be
+// modified without affecting the other. The exception is the Source of a
+// Fragment, which is immutable and so is shared.
+//
+// Values of any other type, such as inline elements defined outside of this
+// package, are returned unchanged, so they are shared between the two
+// trees.
+//
+// As with the sequence types produced by the parser, empty sequences are
+// cloned as nil.
+func Clone(node interface{}) interface{} {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *Fragment:
+		ret := *n
+		ret.SourceNames = append([]string(nil), n.SourceNames...)
+		ret.Body = cloneBody(n.Body)
+		ret.ChildElements = cloneStructure(n.ChildElements)
+		return &ret
+	case *Document:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Title = cloneText(n.Title)
+		ret.Subtitle = cloneText(n.Subtitle)
+		if n.Decoration != nil {
+			ret.Decoration = Clone(n.Decoration).(*Decoration)
+		}
+		if n.DocInfo != nil {
+			ret.DocInfo = Clone(n.DocInfo).(*DocInfo)
+		}
+		ret.Body = cloneBody(n.Body)
+		ret.ChildElements = cloneStructure(n.ChildElements)
+		return &ret
+	case *Decoration:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Header = cloneBody(n.Header)
+		ret.Footer = cloneBody(n.Footer)
+		return &ret
+	case *DocInfo:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Author = cloneText(n.Author)
+		ret.Authors = nil
+		for _, author := range n.Authors {
+			ret.Authors = append(ret.Authors, cloneText(author))
+		}
+		ret.Organization = cloneText(n.Organization)
+		ret.Address = cloneText(n.Address)
+		ret.Contact = cloneText(n.Contact)
+		ret.Version = cloneText(n.Version)
+		ret.Revision = cloneText(n.Revision)
+		ret.Status = cloneText(n.Status)
+		ret.Date = cloneText(n.Date)
+		ret.Copyright = cloneText(n.Copyright)
+		ret.Fields = nil
+		for _, field := range n.Fields {
+			ret.Fields = append(ret.Fields, Clone(field).(*DocInfoField))
+		}
+		return &ret
+	case *DocInfoField:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Name = cloneText(n.Name)
+		ret.Body = cloneBody(n.Body)
+		return &ret
+	case *Section:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Title = cloneText(n.Title)
+		ret.Body = cloneBody(n.Body)
+		ret.ChildElements = cloneStructure(n.ChildElements)
+		return &ret
+	case *Transition:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		return &ret
+	case *Paragraph:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Text = cloneText(n.Text)
+		return &ret
+	case *BlockQuote:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Quote = cloneBody(n.Quote)
+		ret.Attribution = cloneText(n.Attribution)
+		return &ret
+	case *BulletList:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Items = cloneItems(n.Items)
+		return &ret
+	case *EnumeratedList:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Items = cloneItems(n.Items)
+		return &ret
+	case *ListItem:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		ret.Body = cloneBody(n.Body)
+		return &ret
+	case *Comment:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		return &ret
+	case *LiteralBlock:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		return &ret
+	case *Raw:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		return &ret
+	case *Error:
+		ret := *n
+		ret.Attributes = n.Attributes.clone()
+		return &ret
+	case CharData:
+		return n
+	case Structure:
+		return cloneStructure(n)
+	case Body:
+		return cloneBody(n)
+	case Text:
+		return cloneText(n)
+	case []*ListItem:
+		return cloneItems(n)
+	default:
+		return node
+	}
+}
+
+func cloneStructure(structure Structure) Structure {
+	var ret Structure
+	for _, elem := range structure {
+		ret = append(ret, Clone(elem).(StructureElement))
+	}
+	return ret
+}
+
+func cloneBody(body Body) Body {
+	var ret Body
+	for _, elem := range body {
+		ret = append(ret, Clone(elem).(BodyElement))
+	}
+	return ret
+}
+
+func cloneText(text Text) Text {
+	var ret Text
+	for _, elem := range text {
+		ret = append(ret, Clone(elem).(InlineElement))
+	}
+	return ret
+}
+
+func cloneItems(items []*ListItem) []*ListItem {
+	var ret []*ListItem
+	for _, item := range items {
+		ret = append(ret, Clone(item).(*ListItem))
+	}
+	return ret
+}
+
+// clone returns a deep copy of a.
+func (a Attributes) clone() Attributes {
+	ret := Attributes{
+		IDs:     cloneStrings(a.IDs),
+		Names:   cloneStrings(a.Names),
+		Classes: cloneStrings(a.Classes),
+	}
+	if len(a.Extra) != 0 {
+		ret.Extra = make(map[string]string, len(a.Extra))
+		for name, value := range a.Extra {
+			ret.Extra[name] = value
+		}
+	}
+	return ret
+}
+
+func cloneStrings(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
@@ -0,0 +1,303 @@
+package rst
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// cloneTestTree returns a tree that contains every element type, with
+// every field that refers to other values populated at least once, so that
+// TestClone covers all of the copying that Clone must do.
+func cloneTestTree() *Document {
+	pos := func(line int) Position {
+		return Position{Line: line, Column: 1, Filename: "test.rst"}
+	}
+	text := func(s string) Text {
+		return Text{CharData(s)}
+	}
+	para := func(s string, line int) *Paragraph {
+		return &Paragraph{Text: text(s), Pos: pos(line)}
+	}
+	item := func(s string, line int) *ListItem {
+		return &ListItem{Body: Body{para(s, line)}, Pos: pos(line)}
+	}
+	inlineErr := &Error{Message: "inline problem", Pos: pos(2)}
+
+	doc := &Document{
+		Title:    text("Title"),
+		Subtitle: text("Subtitle"),
+		Decoration: &Decoration{
+			Header: Body{para("header", 1)},
+			Footer: Body{para("footer", 1)},
+			Pos:    pos(1),
+		},
+		DocInfo: &DocInfo{
+			Author:       text("Ann"),
+			Authors:      []Text{text("Bob"), text("Cat")},
+			Organization: text("Org"),
+			Address:      text("Addr"),
+			Contact:      text("Contact"),
+			Version:      text("1"),
+			Revision:     text("2"),
+			Status:       text("draft"),
+			Date:         text("today"),
+			Copyright:    text("nobody"),
+			Fields: []*DocInfoField{
+				{Name: text("Extra"), Body: Body{para("value", 1)}, Pos: pos(1)},
+			},
+			Pos: pos(1),
+		},
+		Body: Body{
+			&Paragraph{Text: Text{CharData("with "), inlineErr}, Pos: pos(2)},
+			&BlockQuote{
+				Quote:       Body{para("quoted", 3)},
+				Attribution: text("someone"),
+				Pos:         pos(3),
+			},
+			&BulletList{Bullet: "*", Items: []*ListItem{item("bullet", 4)}, Pos: pos(4)},
+			&EnumeratedList{
+				EnumType:   EnumArabic,
+				EnumSuffix: ".",
+				FirstIndex: 1,
+				Items:      []*ListItem{item("enumerated", 5)},
+				Pos:        pos(5),
+			},
+			&Comment{Text: "comment", Pos: pos(6)},
+			&LiteralBlock{Text: "literal", Pos: pos(6)},
+			&Raw{Format: "html", Text: "<br>", Pos: pos(7)},
+			&Transition{Pos: pos(8)},
+			&Error{Message: "body problem", Skipped: "skipped", Pos: pos(9)},
+		},
+		ChildElements: Structure{
+			&Section{
+				Title:         text("Section"),
+				Body:          Body{para("section body", 11)},
+				ChildElements: Structure{&Section{Title: text("Subsection"), Pos: pos(12)}},
+				Pos:           pos(10),
+			},
+			&Error{Message: "structure problem", Pos: pos(13)},
+		},
+		Pos:        pos(1),
+		SourceName: "test.rst",
+	}
+
+	// Every element gets all of the generic attributes, too.
+	Walk(doc, func(node interface{}) bool {
+		if attrs := NodeAttributes(node); attrs != nil {
+			attrs.IDs = []string{"id"}
+			attrs.SetName("name")
+			attrs.AddClass("class")
+			attrs.SetExtraAttr("extra", "value")
+		}
+		return true
+	})
+	return doc
+}
+
+func TestClone(t *testing.T) {
+	orig := cloneTestTree()
+	clone := Clone(orig).(*Document)
+
+	if diff := Diff(orig, clone); diff != "" {
+		t.Fatalf("clone differs from original\n%s", diff)
+	}
+	checkNoAliasing(t, reflect.ValueOf(orig), reflect.ValueOf(clone), "Document")
+
+	// Modifying the clone must leave the original as it was.
+	Rewrite(clone, func(node interface{}) (interface{}, bool) {
+		if attrs := NodeAttributes(node); attrs != nil {
+			attrs.IDs[0] = "changed"
+			attrs.Extra["extra"] = "changed"
+		}
+		if c, ok := node.(CharData); ok {
+			return CharData(strings.ToUpper(string(c))), false
+		}
+		return node, true
+	})
+	clone.DocInfo.Authors[0] = nil
+	clone.Body[2].(*BulletList).Items[0].Pos.Line = 99
+	if diff := Diff(cloneTestTree(), orig); diff != "" {
+		t.Errorf("modifying the clone changed the original\n%s", diff)
+	}
+
+	fragment := &Fragment{
+		Body:          orig.Body,
+		ChildElements: orig.ChildElements,
+		Pos:           orig.Pos,
+		SourceName:    "test.rst",
+		SourceNames:   []string{"test.rst", "other.rst"},
+		Source:        newSource("", ""),
+	}
+	fragmentClone := Clone(fragment)
+	if diff := Diff(fragment, fragmentClone); diff != "" {
+		t.Fatalf("fragment clone differs from original\n%s", diff)
+	}
+	checkNoAliasing(t, reflect.ValueOf(fragment), reflect.ValueOf(fragmentClone), "Fragment")
+
+	if got := Clone(Body(nil)); got.(Body) != nil {
+		t.Errorf("clone of nil Body is %#v", got)
+	}
+	if got := Clone(CharData("x")); got != CharData("x") {
+		t.Errorf("clone of CharData is %#v", got)
+	}
+}
+
+// checkNoAliasing fails the test if a and b, which must be equal, share any
+// pointers, non-empty slices or maps. A *Source is immutable, and so may be
+// shared.
+func checkNoAliasing(t *testing.T, a, b reflect.Value, path string) {
+	t.Helper()
+
+	if a.Type() == reflect.TypeOf((*Source)(nil)) {
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return
+		}
+		if a.Pointer() == b.Pointer() && a.Elem().Type().Size() != 0 {
+			t.Errorf("%s: pointer is shared", path)
+			return
+		}
+		checkNoAliasing(t, a.Elem(), b.Elem(), path)
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return
+		}
+		checkNoAliasing(t, a.Elem(), b.Elem(), path)
+	case reflect.Slice:
+		if a.Len() != 0 && a.Pointer() == b.Pointer() {
+			t.Errorf("%s: slice is shared", path)
+			return
+		}
+		for i := 0; i < a.Len() && i < b.Len(); i++ {
+			checkNoAliasing(t, a.Index(i), b.Index(i), path+"["+strconv.Itoa(i)+"]")
+		}
+	case reflect.Map:
+		if a.Len() != 0 && a.Pointer() == b.Pointer() {
+			t.Errorf("%s: map is shared", path)
+		}
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			checkNoAliasing(t, a.Field(i), b.Field(i), path+"."+a.Type().Field(i).Name)
+		}
+	}
+}
+
+// TestCloneCompleteness checks that cloneTestTree covers every element type
+// defined in the package, and every field of those types that Clone must
+// copy, so that TestClone fails if Clone is not updated along with them.
+func TestCloneCompleteness(t *testing.T) {
+	// The element types are those that have a Position or
+	// InlineChildNodes method.
+	fset := token.NewFileSet()
+	pkgs, err := goparser.ParseDir(fset, ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil {
+					continue
+				}
+				if name := fn.Name.Name; name != "Position" && name != "InlineChildNodes" {
+					continue
+				}
+				recv := fn.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if ident, ok := recv.(*ast.Ident); ok && ident.Name != "Text" {
+					want[ident.Name] = true
+				}
+			}
+		}
+	}
+
+	found := make(map[string]bool)
+	populated := make(map[string]bool)
+	var visit func(v reflect.Value)
+	visit = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if !v.IsNil() {
+				visit(v.Elem())
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				visit(v.Index(i))
+			}
+		case reflect.String:
+			if v.Type().Name() == "CharData" {
+				found["CharData"] = true
+			}
+		case reflect.Struct:
+			typeName := v.Type().Name()
+			if want[typeName] {
+				found[typeName] = true
+			}
+			recordFields(v, typeName, populated)
+			for i := 0; i < v.NumField(); i++ {
+				visit(v.Field(i))
+			}
+		}
+	}
+	doc := cloneTestTree()
+	visit(reflect.ValueOf(doc))
+	visit(reflect.ValueOf(&Fragment{
+		Body:          doc.Body,
+		ChildElements: doc.ChildElements,
+		Pos:           doc.Pos,
+		SourceName:    "test.rst",
+		SourceNames:   []string{"test.rst", "other.rst"},
+		Source:        newSource("", ""),
+	}))
+
+	for name := range want {
+		if !found[name] {
+			t.Errorf("cloneTestTree does not contain a %s", name)
+		}
+	}
+	for field, ok := range populated {
+		if !ok {
+			t.Errorf("cloneTestTree never populates %s", field)
+		}
+	}
+}
+
+// recordFields records in populated whether each of the exported fields of
+// the given struct that refer to other values is set, including the fields
+// of embedded structs. Fields already recorded as set stay set.
+func recordFields(v reflect.Value, typeName string, populated map[string]bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		fv := v.Field(i)
+		key := typeName + "." + field.Name
+		switch fv.Kind() {
+		case reflect.Struct:
+			if field.Anonymous {
+				recordFields(fv, typeName, populated)
+			}
+		case reflect.Slice, reflect.Map:
+			populated[key] = populated[key] || fv.Len() != 0
+		case reflect.Ptr, reflect.Interface:
+			populated[key] = populated[key] || !fv.IsNil()
+		}
+	}
+}
@@ -1,22 +1,17 @@
+// Command go-rst-spew writes a description of the tree parsed from a
+// reStructuredText document.
+//
+// Deprecated: use the go-rst command instead, which this command is now an
+// alias for. "go-rst-spew" alone is equivalent to "go-rst dump", and its
+// -format, -lint and -tokens flags select the equivalent go-rst commands.
 package main
 
 import (
 	"os"
 
-	"github.com/davecgh/go-spew/spew"
-
-	"github.com/apparentlymart/go-rst"
+	"github.com/apparentlymart/go-rst/cmd/internal/cli"
 )
 
 func main() {
-	fragment := rst.ParseFragment(os.Stdin, "-")
-
-	spewer := &spew.ConfigState{
-		Indent:                  "    ",
-		SortKeys:                true,
-		DisablePointerAddresses: true,
-		DisableCapacities:       true,
-	}
-
-	spewer.Dump(fragment)
+	os.Exit(cli.SpewMain(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
@@ -0,0 +1,14 @@
+// Command go-rst renders, checks and inspects reStructuredText documents.
+//
+// Run "go-rst help" for a list of its subcommands.
+package main
+
+import (
+	"os"
+
+	"github.com/apparentlymart/go-rst/cmd/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,377 @@
+// Package cli implements the go-rst command and its subcommands, so that
+// they can be shared with the deprecated go-rst-spew command and tested
+// without running a separate process.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apparentlymart/go-rst"
+)
+
+// command is a subcommand of go-rst.
+type command struct {
+	Name    string
+	Summary string
+
+	// Run runs the command with the given arguments, which do not include
+	// the command name, and returns the exit status.
+	Run func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var commands []command
+
+func init() {
+	// This is populated here rather than in the declaration because the
+	// help command refers back to the list.
+	commands = []command{
+		{"render", "render documents in an output format such as HTML", runRender},
+		{"lint", "report problems in documents", runLint},
+		{"dump", "show the parsed tree of documents, for debugging", runDump},
+		{"tokens", "show the scanner tokens of documents, for debugging", runTokens},
+		{"diff", "compare the structure of two documents", runDiff},
+		{"serve", "serve a live HTML preview of documents", runServe},
+		{"help", "show help for a command", runHelp},
+	}
+}
+
+// Main runs the go-rst command with the given arguments, not including the
+// program name, and returns the exit status.
+//
+// The exit status is 0 on success, 1 if any input could not be processed,
+// and 2 if the command line itself was invalid. Problems in the markup of a
+// document are reported but do not prevent it from being processed, except
+// with -strict, in which case any error-level problem does. The lint
+// command instead fails according to its -fail-level flag. Informational
+// messages about how the parser interpreted a document are reported only
+// with -v.
+func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	switch args[0] {
+	case "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+
+	cmd := lookupCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "go-rst: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	return cmd.Run(args[1:], stdin, stdout, stderr)
+}
+
+func lookupCommand(name string) *command {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: go-rst <command> [flags] [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintf(w, "\nRun \"go-rst help <command>\" for more information about a command.\n")
+}
+
+func runHelp(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stdout)
+		return 0
+	}
+	cmd := lookupCommand(args[0])
+	if cmd == nil || cmd.Name == "help" {
+		fmt.Fprintf(stderr, "go-rst help: unknown command %q\n", args[0])
+		return 2
+	}
+	return cmd.Run([]string{"-h"}, stdin, stdout, stdout)
+}
+
+// newFlagSet returns a flag set for the named command, with a usage
+// function that describes the command's arguments and purpose before
+// listing its flags.
+func newFlagSet(name, argsUsage, description string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet("go-rst "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: go-rst %s [flags] %s\n\n", name, argsUsage)
+		fmt.Fprintf(stderr, "%s\n\nflags:\n", description)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses the given arguments, returning false along with the
+// exit status to use if the command should not continue.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	switch err := flags.Parse(args); err {
+	case nil:
+		return 0, true
+	case flag.ErrHelp:
+		return 0, false
+	default:
+		return 2, false
+	}
+}
+
+// inputFlags are the flags shared by all commands that read documents.
+type inputFlags struct {
+	Ext string
+}
+
+func (f *inputFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.Ext, "ext", ".rst", "filename `extension` of the files to read from directories")
+}
+
+// outputFlags are the flags shared by all commands that produce output
+// for each document.
+type outputFlags struct {
+	Output string
+	Outdir string
+}
+
+func (f *outputFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.Output, "o", "", "write the output to `file` instead of to stdout")
+	flags.StringVar(&f.Outdir, "outdir", "", "write the output for each file to a file under `dir` instead of to stdout")
+}
+
+// convertFunc reads reStructuredText from r and writes something derived
+// from it to w, reporting any problems to stderr. It returns the exit
+// status that the program should use as a result.
+type convertFunc func(w io.Writer, r io.Reader, filename string, stderr io.Writer) int
+
+// convertAll runs convert for each of the inputs given in args, or for
+// stdin if there are none, and writes the results as selected by out.
+// outExt is the extension for the files written with -outdir.
+func convertAll(convert convertFunc, args []string, in inputFlags, out outputFlags, outExt string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 && out.Outdir != "" {
+		fmt.Fprintln(stderr, "-outdir requires at least one file or directory argument")
+		return 2
+	}
+	if out.Output != "" && out.Outdir != "" {
+		fmt.Fprintln(stderr, "-o and -outdir cannot be used together")
+		return 2
+	}
+
+	w := stdout
+	var outFile *outputFile
+	if out.Output != "" {
+		var err error
+		outFile, err = createOutput(out.Output)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		w = outFile
+	}
+
+	var status int
+	if len(args) == 0 {
+		status = convert(w, stdin, "-", stderr)
+	} else {
+		status = convertFiles(convert, args, in.Ext, out.Outdir, outExt, w, stderr)
+	}
+
+	if outFile != nil {
+		if err := outFile.Commit(); err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// convertFiles converts each of the files given in args, or found within the
+// directories given in args, writing the results either to out or, if
+// outdir is set, to a separate file for each under outdir.
+func convertFiles(convert convertFunc, args []string, ext, outdir, outExt string, out, stderr io.Writer) int {
+	status := 0
+	fail := func(err error) {
+		fmt.Fprintln(stderr, err)
+		status = 1
+	}
+
+	var inputs []inputFile
+	for _, arg := range args {
+		found, err := findInputs(arg, ext)
+		if err != nil {
+			fail(err)
+		}
+		inputs = append(inputs, found...)
+	}
+
+	// Inputs in different directories can have the same relative path,
+	// so with -outdir their results would overwrite one another. This is
+	// checked before writing anything, so that nothing is left half done.
+	outPath := func(input inputFile) string {
+		return filepath.Join(outdir, strings.TrimSuffix(input.Rel, filepath.Ext(input.Rel))+outExt)
+	}
+	if outdir != "" {
+		written := make(map[string]string)
+		for _, input := range inputs {
+			path := outPath(input)
+			if prev, exists := written[path]; exists && prev != input.Path {
+				fmt.Fprintf(stderr, "%s and %s would both be written to %s\n", prev, input.Path, path)
+				return 2
+			}
+			written[path] = input.Path
+		}
+	}
+
+	for _, input := range inputs {
+		f, err := os.Open(input.Path)
+		if err != nil {
+			fail(err)
+			continue
+		}
+
+		if outdir == "" {
+			if s := convert(out, f, input.Path, stderr); s > status {
+				status = s
+			}
+			f.Close()
+			continue
+		}
+
+		path := outPath(input)
+		var outFile *outputFile
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			outFile, err = createOutput(path)
+		}
+		if err != nil {
+			f.Close()
+			fail(err)
+			continue
+		}
+		if s := convert(outFile, f, input.Path, stderr); s > status {
+			status = s
+		}
+		f.Close()
+		if err := outFile.Commit(); err != nil {
+			fail(err)
+		}
+	}
+	return status
+}
+
+// reportLevel returns the minimum severity of problem to report given the
+// -q and -v flags: errors only with -q, everything with -v, and otherwise
+// errors and warnings but not informational messages.
+func reportLevel(quiet, verbose bool) rst.Severity {
+	switch {
+	case quiet:
+		return rst.SeverityError
+	case verbose:
+		return rst.SeverityInfo
+	default:
+		return rst.SeverityWarning
+	}
+}
+
+// reportErrors writes the problems within the given node whose severity is
+// at least level to w.
+func reportErrors(w io.Writer, node interface{}, level rst.Severity) {
+	for _, err := range rst.AllErrors(node) {
+		if err.Severity < level {
+			continue
+		}
+		writeProblem(w, err)
+	}
+}
+
+// reportParseError writes the error returned by rst.ParseFragmentErr for
+// the given file to w, and returns the exit status that the program should
+// use as a result. If parsing failed because of Options.StrictErrors then
+// each of the errors in the document is written as a problem.
+func reportParseError(w io.Writer, filename string, err error) int {
+	var problems []*rst.Error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			var problem *rst.Error
+			if errors.As(err, &problem) {
+				problems = append(problems, problem)
+			}
+		}
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%s: error: %s\n", filename, err)
+		return 1
+	}
+	for _, problem := range problems {
+		writeProblem(w, problem)
+	}
+	return 1
+}
+
+// writeProblem writes the given error to w in the conventional
+// "file:line:col: level: message" format, with the position extended to a
+// "file:line:col-line:col" range if the extent of the problem is known.
+func writeProblem(w io.Writer, err *rst.Error) {
+	level := "error"
+	switch err.Severity {
+	case rst.SeverityWarning:
+		level = "warning"
+	case rst.SeverityInfo:
+		level = "info"
+	}
+	fmt.Fprintf(w, "%s: %s: %s\n", err.Range(), level, err.Message)
+}
+
+// parserFlags are the flags shared by all commands that parse documents.
+// Each corresponds directly to a field of rst.Options, so that documents
+// are parsed in the same way as they would be by the library.
+type parserFlags struct {
+	Options rst.Options
+}
+
+func (f *parserFlags) register(flags *flag.FlagSet) {
+	flags.IntVar(&f.Options.MaxNestingDepth, "max-nesting-depth", 0, "maximum nesting `depth` of block-level constructs; 0 for the default, or negative for no limit")
+	flags.BoolVar(&f.Options.DropComments, "drop-comments", false, "omit comments from the parsed documents")
+	flags.Var((*runesFlag)(&f.Options.BulletRunes), "bullets", "the `characters` that may mark bullet list items, instead of the standard ones")
+	flags.IntVar(&f.Options.Limits.MaxElements, "max-elements", 0, "maximum `number` of block-level elements, or 0 for no limit")
+	flags.IntVar(&f.Options.Limits.MaxErrors, "max-parse-errors", 0, "maximum `number` of errors the parser reports before stopping, or 0 for no limit")
+	flags.BoolVar(&f.Options.PreferEnumeratedLists, "prefer-enumerated-lists", false, "treat a line beginning with a single letter enumerator, such as \"A.\", as a list item even if the next line is not indented")
+	flags.StringVar(&f.Options.Encoding, "encoding", "", "character `encoding` of the input: utf-8, latin-1, windows-1252, or auto to detect a byte order mark")
+	flags.BoolVar(&f.Options.StrictErrors, "strict", false, "fail to process any document containing an error, rather than reporting it and continuing")
+	f.registerLimits(flags)
+}
+
+// runesFlag is a flag.Value that sets a slice of runes from the characters
+// of a string.
+type runesFlag []rune
+
+func (f *runesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(*f)
+}
+
+func (f *runesFlag) Set(s string) error {
+	*f = []rune(s)
+	return nil
+}
+
+// registerLimits registers only the flags for the fields of Options.Limits
+// that the scanner enforces, for commands that use the scanner alone.
+func (f *parserFlags) registerLimits(flags *flag.FlagSet) {
+	flags.IntVar(&f.Options.Limits.MaxLineLength, "max-line-length", 0, "maximum length of a line in `bytes`, or 0 for no limit")
+	flags.IntVar(&f.Options.Limits.MaxIndentDepth, "max-indent-depth", 0, "maximum number of nested indentation `levels`, or 0 for no limit")
+	flags.IntVar(&f.Options.Limits.MaxTokens, "max-tokens", 0, "maximum `number` of scanner tokens, or 0 for no limit")
+}
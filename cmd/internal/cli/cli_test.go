@@ -0,0 +1,793 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/apparentlymart/go-rst"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// TestFormats runs the program with each output format over the fixture in
+// testdata/fixture.rst and compares the output with testdata/fixture.FORMAT.
+//
+// Run the tests with -update to regenerate the expected outputs after an
+// intentional change.
+func TestFormats(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("testdata", "fixture.rst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]string{
+		"tree":      {"dump"},
+		"dot":       {"dump", "-format", "dot"},
+		"tokens":    {"tokens"},
+		"pseudoxml": {"render", "-format", "pseudoxml"},
+		"xml":       {"render", "-format", "xml"},
+		"html":      {"render"},
+		"markdown":  {"render", "-format", "markdown"},
+		"text":      {"render", "-format", "text"},
+	}
+	for format, args := range tests {
+		t.Run(format, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := Main(args, bytes.NewReader(src), &stdout, &stderr)
+			if status != 0 {
+				t.Fatalf("exit status %d\n%s", status, stderr.String())
+			}
+
+			goldenPath := filepath.Join("testdata", "fixture."+format)
+			if *updateGolden {
+				if err := ioutil.WriteFile(goldenPath, stdout.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("%s (run with -update to create it)", err)
+			}
+			if got := stdout.String(); got != string(want) {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Args   []string
+		Input  io.Reader
+		Status int
+		Stderr string
+	}{
+		{
+			"unknown format",
+			[]string{"render", "-format", "nope"},
+			strings.NewReader(""),
+			2,
+			`unsupported format "nope"`,
+		},
+		{
+			"unknown flag",
+			[]string{"dump", "-nope"},
+			strings.NewReader(""),
+			2,
+			"flag provided but not defined",
+		},
+		{
+			"unknown command",
+			[]string{"nope"},
+			strings.NewReader(""),
+			2,
+			`unknown command "nope"`,
+		},
+		{
+			"no command",
+			nil,
+			strings.NewReader(""),
+			2,
+			"usage: go-rst <command>",
+		},
+		{
+			"read error",
+			[]string{"dump"},
+			iotest.ErrReader(errors.New("disk on fire")),
+			1,
+			"error: disk on fire",
+		},
+		{
+			"read error dumping tokens",
+			[]string{"tokens"},
+			iotest.ErrReader(errors.New("disk on fire")),
+			1,
+			"disk on fire",
+		},
+		{
+			"warning",
+			[]string{"render"},
+			strings.NewReader("\tx\n  y\n"),
+			0,
+			"-:2:1: warning: inconsistent use of tabs and spaces",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := Main(test.Args, test.Input, &stdout, &stderr)
+			if status != test.Status {
+				t.Errorf("wrong exit status %d; want %d", status, test.Status)
+			}
+			if !strings.Contains(stderr.String(), test.Stderr) {
+				t.Errorf("stderr does not contain %q:\n%s", test.Stderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestFileArguments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-rst-spew")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("docs/a.rst", "alpha")
+	write("docs/sub/b.rst", "beta")
+	write("docs/sub/c.txt", "gamma")
+	write("single.txt", "delta")
+
+	// A symbolic link back to an ancestor must not cause an endless walk.
+	if err := os.Symlink(filepath.Join(dir, "docs"), filepath.Join(dir, "docs", "sub", "loop")); err != nil {
+		t.Skipf("cannot create symbolic link: %s", err)
+	}
+
+	docs := filepath.Join(dir, "docs")
+	single := filepath.Join(dir, "single.txt")
+
+	t.Run("stdout", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		status := Main([]string{"render", "-format", "pseudoxml", docs, single}, nil, &stdout, &stderr)
+		if status != 0 {
+			t.Fatalf("exit status %d\n%s", status, stderr.String())
+		}
+		want := fmt.Sprintf(
+			"<document source=%q>\n    <paragraph>\n        alpha\n"+
+				"<document source=%q>\n    <paragraph>\n        beta\n"+
+				"<document source=%q>\n    <paragraph>\n        delta\n",
+			filepath.Join(docs, "a.rst"), filepath.Join(docs, "sub", "b.rst"), single,
+		)
+		if got := stdout.String(); got != want {
+			t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("ext", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		status := Main([]string{"render", "-format", "text", "-ext", ".txt", docs}, nil, &stdout, &stderr)
+		if status != 0 {
+			t.Fatalf("exit status %d\n%s", status, stderr.String())
+		}
+		if got, want := stdout.String(), "gamma\n"; got != want {
+			t.Errorf("wrong output %q; want %q", got, want)
+		}
+	})
+
+	t.Run("outdir", func(t *testing.T) {
+		outdir := filepath.Join(dir, "out")
+		var stdout, stderr bytes.Buffer
+		status := Main([]string{"render", "-format", "text", "-outdir", outdir, docs, single}, nil, &stdout, &stderr)
+		if status != 0 {
+			t.Fatalf("exit status %d\n%s", status, stderr.String())
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("unexpected output on stdout:\n%s", stdout.String())
+		}
+		for name, want := range map[string]string{
+			"a.txt":      "alpha\n",
+			"sub/b.txt":  "beta\n",
+			"single.txt": "delta\n",
+		} {
+			got, err := ioutil.ReadFile(filepath.Join(outdir, filepath.FromSlash(name)))
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if string(got) != want {
+				t.Errorf("wrong content for %s %q; want %q", name, got, want)
+			}
+		}
+	})
+
+	t.Run("outdir conflict", func(t *testing.T) {
+		write("other/a.rst", "another alpha")
+		write("other/sub/b.rst", "another beta")
+		tests := [][]string{
+			{filepath.Join(docs, "a.rst"), filepath.Join(dir, "other", "a.rst")},
+			{docs, filepath.Join(dir, "other")},
+		}
+		for _, args := range tests {
+			outdir, err := ioutil.TempDir(dir, "out")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			status := Main(append([]string{"render", "-format", "text", "-outdir", outdir}, args...), nil, &stdout, &stderr)
+			if status != 2 {
+				t.Errorf("wrong exit status %d for %q; want 2", status, args)
+			}
+			if !strings.Contains(stderr.String(), "would both be written to "+filepath.Join(outdir, "a.txt")) {
+				t.Errorf("conflict not reported for %q:\n%s", args, stderr.String())
+			}
+			if entries, _ := ioutil.ReadDir(outdir); len(entries) != 0 {
+				t.Errorf("files written despite conflict for %q", args)
+			}
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		status := Main([]string{"render", "-format", "text", filepath.Join(dir, "nope.rst"), single}, nil, &stdout, &stderr)
+		if status != 1 {
+			t.Errorf("wrong exit status %d; want 1", status)
+		}
+		if !strings.Contains(stderr.String(), "nope.rst") {
+			t.Errorf("missing file not reported:\n%s", stderr.String())
+		}
+		if got, want := stdout.String(), "delta\n"; got != want {
+			t.Errorf("other files not processed: %q; want %q", got, want)
+		}
+	})
+}
+
+func TestLint(t *testing.T) {
+	// The first line produces a warning about mixing tabs and spaces, and
+	// the rest produce an error.
+	const input = "\tx\n  y\n\n  q\n\n  -- me\n\nz\n"
+
+	tests := []struct {
+		Name   string
+		Args   []string
+		Status int
+		Stdout string
+	}{
+		{
+			"default",
+			[]string{"lint"},
+			1,
+			"-:2:1: warning: inconsistent use of tabs and spaces in indentation; compare with -:1:1\n" +
+				"-:6:3: error: missing dedent after attribution\n" +
+				"-:8:1-8:2: error: unexpected token: DEDENT\n",
+		},
+		{
+			"max errors",
+			[]string{"lint", "-max-errors", "1"},
+			1,
+			"-:2:1: warning: inconsistent use of tabs and spaces in indentation; compare with -:1:1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := Main(test.Args, strings.NewReader(input), &stdout, &stderr)
+			if status != test.Status {
+				t.Errorf("wrong exit status %d; want %d\n%s", status, test.Status, stderr.String())
+			}
+			if got := stdout.String(); got != test.Stdout {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, test.Stdout)
+			}
+		})
+	}
+
+	// Directories may be given in the style of Go package patterns.
+	dir, err := ioutil.TempDir("", "go-rst-lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.rst"), []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if status := Main([]string{"lint", filepath.Join(dir, "...")}, nil, &stdout, &stderr); status != 1 {
+		t.Errorf("wrong exit status %d for directory pattern; want 1\n%s", status, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), filepath.Join(dir, "a.rst")+":6:3: error:") {
+		t.Errorf("wrong output for directory pattern:\n%s", stdout.String())
+	}
+
+	// Warnings alone cause failure only at the default fail level.
+	for _, test := range []struct {
+		FailLevel string
+		Status    int
+	}{
+		{"warning", 1},
+		{"error", 0},
+	} {
+		var stdout, stderr bytes.Buffer
+		status := Main([]string{"lint", "-fail-level", test.FailLevel}, strings.NewReader("\tx\n  y\n"), &stdout, &stderr)
+		if status != test.Status {
+			t.Errorf("wrong exit status %d for -fail-level=%s; want %d", status, test.FailLevel, test.Status)
+		}
+		if !strings.Contains(stdout.String(), "warning: inconsistent") {
+			t.Errorf("warning not reported for -fail-level=%s:\n%s", test.FailLevel, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if status := Main([]string{"lint", "-fail-level", "bad"}, strings.NewReader(""), &stdout, &stderr); status != 2 {
+		t.Errorf("wrong exit status %d for invalid fail level; want 2", status)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	// A late indent is the case where the raw token stream is most useful
+	// for understanding the tree.
+	const input = "  a\n\n    b\n\n   c\n"
+	var stdout, stderr bytes.Buffer
+	if status := Main([]string{"tokens"}, strings.NewReader(input), &stdout, &stderr); status != 0 {
+		t.Fatalf("exit status %d\n%s", status, stderr.String())
+	}
+	want := "1:1\tINDENT\t\"  \"\n" +
+		"1:3\tLINE\t\"a\"\n" +
+		"2:1\tBLANK\t\"\"\n" +
+		"3:1\tINDENT\t\"    \"\n" +
+		"3:5\tLINE\t\"b\"\n" +
+		"4:1\tBLANK\t\"\"\n" +
+		"5:1\tLATE_INDENT\t\"   \"\n" +
+		"5:4\tLINE\t\"c\"\n" +
+		"6:1\tDEDENT\t\"\"\n" +
+		"6:1\tDEDENT\t\"\"\n" +
+		"6:1\tEOF\t\"\"\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-rst-spew")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outPath := filepath.Join(dir, "out.txt")
+	var stdout, stderr bytes.Buffer
+	status := Main([]string{"render", "-format", "text", "-o", outPath}, strings.NewReader("hello"), &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("exit status %d\n%s", status, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("unexpected output on stdout:\n%s", stdout.String())
+	}
+	got, err := ioutil.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "hello\n"; string(got) != want {
+		t.Errorf("wrong content %q; want %q", got, want)
+	}
+
+	// A failure partway through writing must not leave anything behind.
+	failPath := filepath.Join(dir, "fail.txt")
+	out, err := createOutput(failPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Write([]byte("partial"))
+	out.f.Close() // make the next write fail
+	if _, err := out.Write([]byte("more")); err == nil {
+		t.Fatal("write to closed file succeeded")
+	}
+	if err := out.Commit(); err == nil {
+		t.Errorf("no error from Commit after a failed write")
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "out.txt" {
+			t.Errorf("unexpected file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	const input = "\tx\n  y\n"
+
+	var stdout, stderr bytes.Buffer
+	if status := Main([]string{"dump"}, strings.NewReader(input), &stdout, &stderr); status != 0 {
+		t.Errorf("wrong exit status %d; want 0", status)
+	}
+	if !strings.Contains(stderr.String(), "warning:") {
+		t.Errorf("warning not reported without -q:\n%s", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if status := Main([]string{"dump", "-q"}, strings.NewReader(input), &stdout, &stderr); status != 0 {
+		t.Errorf("wrong exit status %d; want 0", status)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("unexpected diagnostics with -q:\n%s", stderr.String())
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	// The parser notes that this is not an enumerated list, with an info
+	// message.
+	const input = "A. Einstein was a\nphysicist.\n"
+
+	for _, cmd := range []string{"dump", "render", "lint"} {
+		t.Run(cmd, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if status := Main([]string{cmd}, strings.NewReader(input), &stdout, &stderr); status != 0 {
+				t.Errorf("wrong exit status %d; want 0", status)
+			}
+			if got := stdout.String() + stderr.String(); strings.Contains(got, "info:") {
+				t.Errorf("info message reported without -v:\n%s", got)
+			}
+
+			stdout.Reset()
+			stderr.Reset()
+			if status := Main([]string{cmd, "-v"}, strings.NewReader(input), &stdout, &stderr); status != 0 {
+				t.Errorf("wrong exit status %d; want 0", status)
+			}
+			if got := stdout.String() + stderr.String(); !strings.Contains(got, "-:1:1: info: ") {
+				t.Errorf("info message not reported with -v:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestHelp(t *testing.T) {
+	for _, cmd := range commands {
+		if cmd.Name == "help" {
+			continue
+		}
+		var stdout, stderr bytes.Buffer
+		if status := Main([]string{"help", cmd.Name}, nil, &stdout, &stderr); status != 0 {
+			t.Errorf("wrong exit status %d for help %s; want 0", status, cmd.Name)
+		}
+		if want := "usage: go-rst " + cmd.Name + " "; !strings.HasPrefix(stdout.String(), want) {
+			t.Errorf("wrong help for %s:\n%s", cmd.Name, stdout.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if status := Main([]string{"help"}, nil, &stdout, &stderr); status != 0 {
+		t.Errorf("wrong exit status %d for help; want 0", status)
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(stdout.String(), "  "+cmd.Name+" ") {
+			t.Errorf("command %s missing from usage:\n%s", cmd.Name, stdout.String())
+		}
+	}
+}
+
+func TestSpewMain(t *testing.T) {
+	// Each of the go-rst-spew command lines must produce the same output as
+	// the equivalent go-rst command line.
+	tests := []struct {
+		Spew, Equivalent []string
+	}{
+		{nil, []string{"dump"}},
+		{[]string{"-q"}, []string{"dump", "-q"}},
+		{[]string{"-format", "tree"}, []string{"dump"}},
+		{[]string{"-format=html"}, []string{"render", "-format", "html"}},
+		{[]string{"--format", "text"}, []string{"render", "-format", "text"}},
+		{[]string{"-tokens"}, []string{"tokens"}},
+		{[]string{"-format", "tokens"}, []string{"tokens"}},
+		{[]string{"-lint", "-max-errors", "1"}, []string{"lint", "-max-errors", "1"}},
+		{[]string{"-ext", ".rst", "-format", "html"}, []string{"render", "-format", "html", "-ext", ".rst"}},
+		{[]string{"-q", "-ext=.txt", "--format", "text", "-"}, []string{"render", "-format", "text", "-q", "-ext", ".txt", "-"}},
+		{[]string{"-max-errors", "1", "-lint", "-fail-level", "error"}, []string{"lint", "-max-errors", "1", "-fail-level", "error"}},
+		{[]string{"-fail-level", "error", "-format", "tree"}, []string{"dump"}},
+		{[]string{"-tokens", "-q"}, []string{"tokens"}},
+		{[]string{"-nope"}, nil},
+	}
+
+	const input = "\tx\n  y\n\n- a\n- b\n"
+	for _, test := range tests {
+		var spewOut, spewErr, wantOut, wantErr bytes.Buffer
+		if test.Equivalent == nil {
+			// go-rst-spew rejected this, with its own usage message.
+			if status := SpewMain(test.Spew, strings.NewReader(input), &spewOut, &spewErr); status != 2 {
+				t.Errorf("go-rst-spew %q exited with status %d; want 2", test.Spew, status)
+			}
+			continue
+		}
+		spewStatus := SpewMain(test.Spew, strings.NewReader(input), &spewOut, &spewErr)
+		wantStatus := Main(test.Equivalent, strings.NewReader(input), &wantOut, &wantErr)
+		if spewStatus != wantStatus || spewOut.String() != wantOut.String() || spewErr.String() != wantErr.String() {
+			t.Errorf(
+				"go-rst-spew %q differs from go-rst %q\nstatus %d, %d\nstdout:\n%s\n%s\nstderr:\n%s\n%s",
+				test.Spew, test.Equivalent, spewStatus, wantStatus,
+				spewOut.String(), wantOut.String(), spewErr.String(), wantErr.String(),
+			)
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	// This fixture has an error, which is reported either way but causes
+	// failure only with -strict.
+	const input = "before::\n    literal\n\nafter\n"
+	const problem = "-:2:1-2:12: error: unexpected token: LITERAL"
+
+	tests := []struct {
+		Args   []string
+		Status int
+		Stdout bool
+	}{
+		{[]string{"render"}, 0, true},
+		{[]string{"render", "-strict"}, 1, false},
+		{[]string{"dump"}, 0, true},
+		{[]string{"dump", "-strict"}, 1, false},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := Main(test.Args, strings.NewReader(input), &stdout, &stderr)
+		if status != test.Status {
+			t.Errorf("wrong exit status %d for %q; want %d\n%s", status, test.Args, test.Status, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), problem) {
+			t.Errorf("problem not reported for %q:\n%s", test.Args, stderr.String())
+		}
+		if got := stdout.Len() != 0; got != test.Stdout {
+			t.Errorf("wrong output for %q:\n%s", test.Args, stdout.String())
+		}
+	}
+
+	// lint fails because of the error either way, but reports it in the
+	// same format.
+	for _, args := range [][]string{{"lint"}, {"lint", "-strict"}} {
+		var stdout, stderr bytes.Buffer
+		if status := Main(args, strings.NewReader(input), &stdout, &stderr); status != 1 {
+			t.Errorf("wrong exit status %d for %q; want 1", status, args)
+		}
+		if got, want := stdout.String(), problem+"\n"; got != want {
+			t.Errorf("wrong output for %q\ngot:  %q\nwant: %q", args, got, want)
+		}
+	}
+}
+
+func TestParserFlags(t *testing.T) {
+	// The same input is accepted by default but rejected when the parser
+	// options restrict it, for each command that parses. Exceeding a limit
+	// is an error in the document, so render and dump fail only with
+	// -strict.
+	const input = "a\n\n  b\n\n    c\n"
+
+	tests := []struct {
+		Args   []string
+		Status int
+	}{
+		{[]string{"dump"}, 0},
+		{[]string{"dump", "-strict", "-max-indent-depth", "1"}, 1},
+		{[]string{"dump", "-strict", "-max-nesting-depth", "1"}, 1},
+		{[]string{"dump", "-drop-comments"}, 0},
+		{[]string{"dump", "-bullets", "-+"}, 0},
+		{[]string{"dump", "-max-elements", "5"}, 0},
+		{[]string{"dump", "-strict", "-max-elements", "4"}, 1},
+		{[]string{"dump", "-max-parse-errors", "1"}, 0},
+		{[]string{"dump", "-encoding", "latin-1"}, 0},
+		{[]string{"dump", "-prefer-enumerated-lists"}, 0},
+		{[]string{"dump", "-encoding", "ebcdic"}, 1},
+		{[]string{"render", "-strict", "-max-tokens", "3"}, 1},
+		{[]string{"lint"}, 0},
+		{[]string{"lint", "-max-line-length", "5"}, 0},
+		{[]string{"lint", "-max-line-length", "4"}, 1},
+		{[]string{"lint", "-max-indent-depth", "1"}, 1},
+		{[]string{"tokens"}, 0},
+		{[]string{"tokens", "-max-indent-depth", "1"}, 1},
+	}
+
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := Main(test.Args, strings.NewReader(input), &stdout, &stderr)
+		if status != test.Status {
+			t.Errorf("wrong exit status %d for %q; want %d\n%s", status, test.Args, test.Status, stderr.String())
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-rst-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"old.rst":       "The quick brown fox jumps over\nthe lazy dog.\n\n- one\n- two\n",
+		"rewrapped.rst": "\n\nThe quick brown\nfox   jumps over the lazy dog.\n\n\n* one\n* two\n",
+		"changed.rst":   "The quick brown fox jumps over\nthe lazy cat.\n\n- one\n- two\n",
+		"warning.rst":   "The quick brown fox jumps over\nthe lazy dog.\n\n- one\n- two\n\n\tx\n  y\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	tests := []struct {
+		Name   string
+		Args   []string
+		Status int
+		Stdout string
+	}{
+		{
+			"rewrapped",
+			[]string{"diff", path("old.rst"), path("rewrapped.rst")},
+			1,
+			// Rewrapping is ignored, but the change of bullet is not.
+			"Body[1].(*BulletList).Bullet: \"-\" != \"*\"\n",
+		},
+		{
+			"changed",
+			[]string{"diff", path("old.rst"), path("changed.rst")},
+			1,
+			"Body[0].(*Paragraph).Text[0]: CharData(\"The quick brown fox jumps over the lazy dog.\") != CharData(\"The quick brown fox jumps over the lazy cat.\")\n",
+		},
+		{
+			"same",
+			[]string{"diff", path("old.rst"), path("old.rst")},
+			0,
+			"",
+		},
+		{
+			"ignored",
+			[]string{"diff", "-ignore", "Error, Paragraph,blockquote", path("old.rst"), path("warning.rst")},
+			0,
+			"",
+		},
+		{
+			"missing file",
+			[]string{"diff", path("old.rst"), path("nope.rst")},
+			2,
+			"",
+		},
+		{
+			"wrong number of arguments",
+			[]string{"diff", path("old.rst")},
+			2,
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := Main(test.Args, nil, &stdout, &stderr)
+			if status != test.Status {
+				t.Errorf("wrong exit status %d; want %d\n%s", status, test.Status, stderr.String())
+			}
+			if got := stdout.String(); got != test.Stdout {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, test.Stdout)
+			}
+		})
+	}
+}
+
+func TestPreviewServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-rst-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("root/index.rst", "Hello <script>alert(1)</script>\n")
+	write("root/sub/broken.rst", "\tx\n  y\n")
+	write("root/notes.txt", "not a document")
+	write("secret.rst", "secret")
+	symlinked := os.Symlink(filepath.Join(dir, "secret.rst"), filepath.Join(root, "leak.rst")) == nil
+
+	srv, err := newPreviewServer(root, ".rst", rst.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	status, body := get("/")
+	if status != 200 {
+		t.Fatalf("wrong status %d for index", status)
+	}
+	for _, want := range []string{`href="/index.rst"`, `href="/sub/broken.rst"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index does not contain %s:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "notes.txt") {
+		t.Errorf("index lists a file with the wrong extension:\n%s", body)
+	}
+	if strings.Contains(body, "leak.rst") {
+		t.Errorf("index lists a file outside of the root:\n%s", body)
+	}
+
+	status, body = get("/index.rst")
+	if status != 200 {
+		t.Fatalf("wrong status %d for document", status)
+	}
+	if !strings.Contains(body, "<p>Hello &lt;script&gt;alert(1)&lt;/script&gt;</p>") || strings.Contains(body, "<script>alert") {
+		t.Errorf("document not rendered with escaping:\n%s", body)
+	}
+
+	status, body = get("/sub/broken.rst")
+	if status != 200 || !strings.Contains(body, `<div class="system-message">`) {
+		t.Errorf("errors not shown inline (status %d):\n%s", status, body)
+	}
+
+	// The page must be rendered again once the file changes.
+	status, before := get("/index.rst?mtime")
+	if status != 200 || before == "" {
+		t.Fatalf("wrong mtime response (status %d): %q", status, before)
+	}
+	write("root/index.rst", "Changed\n")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "index.rst"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, after := get("/index.rst?mtime"); after == before {
+		t.Errorf("mtime did not change")
+	}
+	if _, body := get("/index.rst"); !strings.Contains(body, "<p>Changed</p>") {
+		t.Errorf("document not rendered again after change:\n%s", body)
+	}
+
+	// Nothing outside of the root, or without the document extension,
+	// may be served.
+	notFound := []string{"/notes.txt", "/nope.rst", "/../secret.rst", "/sub/../../secret.rst", "/sub"}
+	if symlinked {
+		notFound = append(notFound, "/leak.rst")
+	}
+	for _, path := range notFound {
+		if status, body := get(path); status != 404 {
+			t.Errorf("wrong status %d for %s; want 404\n%s", status, path, body)
+		}
+	}
+}
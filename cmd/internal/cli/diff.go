@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/apparentlymart/go-rst"
+)
+
+func runDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("diff", "old.rst new.rst", "Compares the trees parsed from two documents, ignoring positions and the way\nparagraphs are wrapped. The exit status is 0 if they are the same, 1 if they\ndiffer, and 2 if either cannot be read.", stderr)
+	ignore := flags.String("ignore", "", "comma-separated `types` of element to leave out of the comparison, such as Error")
+	var parse parserFlags
+	parse.register(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	ignored := make(map[string]bool)
+	for _, name := range strings.Split(*ignore, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ignored[strings.ToLower(name)] = true
+		}
+	}
+
+	var trees [2]interface{}
+	for i, filename := range flags.Args() {
+		f, err := os.Open(filename)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		fragment, err := rst.ParseFragmentErr(f, filename, parse.Options)
+		f.Close()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		trees[i] = normalizeTree(fragment, ignored)
+	}
+
+	diff := rst.Diff(trees[0], trees[1])
+	if diff == "" {
+		return 0
+	}
+	io.WriteString(stdout, diff)
+	return 1
+}
+
+var (
+	positionType = reflect.TypeOf(rst.Position{})
+	textType     = reflect.TypeOf(rst.Text(nil))
+)
+
+// normalizeTree modifies the given tree so that it can be compared with
+// another without regard to the details of its source: elements of the
+// ignored types are removed, positions and source names are zeroed, and
+// each Text is replaced by a single CharData with its whitespace
+// normalized. It returns the resulting tree.
+//
+// Ignored types are given as lowercase Go type names without the package
+// name, such as "error".
+func normalizeTree(node interface{}, ignored map[string]bool) interface{} {
+	node = rst.Rewrite(node, func(node interface{}) (interface{}, bool) {
+		t := reflect.TypeOf(node)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if ignored[strings.ToLower(t.Name())] {
+			return nil, false
+		}
+		return node, true
+	})
+
+	if fragment, ok := node.(*rst.Fragment); ok {
+		fragment.SourceName = ""
+		fragment.SourceNames = nil
+	}
+
+	rst.Walk(node, func(node interface{}) bool {
+		v := reflect.ValueOf(node)
+		if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+			return true
+		}
+		v = v.Elem()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			switch field.Type() {
+			case positionType:
+				field.Set(reflect.Zero(positionType))
+			case textType:
+				text := field.Interface().(rst.Text)
+				var normalized rst.Text
+				if s := strings.Join(strings.Fields(text.String()), " "); s != "" {
+					normalized = rst.Text{rst.CharData(s)}
+				}
+				field.Set(reflect.ValueOf(normalized))
+			}
+		}
+		return true
+	})
+	return node
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// inputFile is a file to be processed.
+type inputFile struct {
+	// Path is the path of the file to read, which is also used as the
+	// filename in positions.
+	Path string
+
+	// Rel is the path of the file relative to the directory given on the
+	// command line, or just its base name if the file itself was given,
+	// for constructing the path of its output file.
+	Rel string
+}
+
+// findInputs returns the files to process for the given command line
+// argument. A file is returned as-is, regardless of its extension, while
+// a directory is searched recursively for files with the given extension,
+// following symbolic links. As a convenience for those used to the go
+// command, a directory may also be written with a "/..." suffix.
+//
+// Each directory is visited at most once, so that a symbolic link cycle
+// cannot cause an endless search. If some parts of a directory tree cannot
+// be read then the files found elsewhere are returned along with the first
+// error encountered.
+func findInputs(arg, ext string) ([]inputFile, error) {
+	if slashed := filepath.ToSlash(arg); strings.HasSuffix(slashed, "/...") {
+		arg = filepath.FromSlash(strings.TrimSuffix(slashed, "..."))
+	}
+
+	info, err := os.Stat(arg)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []inputFile{{Path: arg, Rel: filepath.Base(arg)}}, nil
+	}
+
+	var inputs []inputFile
+	var firstErr error
+	visited := make(map[string]bool)
+
+	var walk func(dir, rel string)
+	walk = func(dir, rel string) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			resolved, err = filepath.Abs(resolved)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return
+		}
+		if visited[resolved] {
+			return
+		}
+		visited[resolved] = true
+
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			entryRel := filepath.Join(rel, entry.Name())
+
+			// ReadDir describes symbolic links themselves, so we must
+			// follow them to find out what they refer to.
+			if entry.Mode()&os.ModeSymlink != 0 {
+				target, err := os.Stat(path)
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					continue
+				}
+				entry = target
+			}
+
+			switch {
+			case entry.IsDir():
+				walk(path, entryRel)
+			case entry.Mode().IsRegular() && filepath.Ext(path) == ext:
+				inputs = append(inputs, inputFile{Path: path, Rel: entryRel})
+			}
+		}
+	}
+	walk(arg, "")
+
+	return inputs, firstErr
+}
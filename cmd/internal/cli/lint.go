@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/apparentlymart/go-rst"
+)
+
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("lint", "[file or directory ...]", "Reports the problems in each document, sorted by position, and fails if any are\nat or above the fail level.", stderr)
+	failLevel := flags.String("fail-level", "warning", "the minimum `level` of problem that causes failure: warning or error")
+	maxErrors := flags.Int("max-errors", 0, "report at most `n` problems in total, or all of them if zero")
+	verbose := flags.Bool("v", false, "also report informational messages")
+	var in inputFlags
+	var parse parserFlags
+	in.register(flags)
+	parse.register(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	l := &linter{opts: parse.Options, maxErrors: *maxErrors, reportLevel: reportLevel(false, *verbose)}
+	switch *failLevel {
+	case "warning":
+		l.failLevel = rst.SeverityWarning
+	case "error":
+		l.failLevel = rst.SeverityError
+	default:
+		fmt.Fprintf(stderr, "unsupported fail level %q; must be warning or error\n", *failLevel)
+		return 2
+	}
+	return convertAll(l.lint, flags.Args(), in, outputFlags{}, "", stdin, stdout, stderr)
+}
+
+// linter implements the lint command, in which problems are reported on
+// stdout and no other output is produced.
+type linter struct {
+	opts      rst.Options
+	failLevel rst.Severity
+	maxErrors int
+
+	// reportLevel is the minimum severity of problem to report, which
+	// excludes informational messages unless -v is set.
+	reportLevel rst.Severity
+
+	// reported counts the problems reported so far across all files, for
+	// enforcing maxErrors.
+	reported int
+}
+
+// lint is a convertFunc that parses the input and writes its problems to w,
+// sorted by position, in the conventional "file:line:col: level: message"
+// format. The exit status is 1 if any problem is at or above the fail
+// level, even if it was not reported because of the limit.
+func (l *linter) lint(w io.Writer, r io.Reader, filename string, stderr io.Writer) int {
+	fragment, err := rst.ParseFragmentErr(r, filename, l.opts)
+	if err != nil {
+		return reportParseError(w, filename, err)
+	}
+	errs := rst.AllErrors(fragment)
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i].Pos, errs[j].Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	status := 0
+	for _, err := range errs {
+		if err.Severity >= l.failLevel {
+			status = 1
+		}
+		if err.Severity < l.reportLevel {
+			continue
+		}
+		if l.maxErrors > 0 && l.reported >= l.maxErrors {
+			continue
+		}
+		l.reported++
+		writeProblem(w, err)
+	}
+	return status
+}
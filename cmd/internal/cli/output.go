@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// outputFile is an output file that is written first to a temporary file
+// in the same directory and then renamed into place, so that a failure
+// partway through never leaves a truncated file at the final path for
+// later build steps to consume.
+type outputFile struct {
+	f    *os.File
+	path string
+
+	// err is the first error encountered while writing, which causes
+	// Commit to discard the file.
+	err error
+}
+
+func createOutput(path string) (*outputFile, error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &outputFile{f: f, path: path}, nil
+}
+
+func (o *outputFile) Write(p []byte) (int, error) {
+	if o.err != nil {
+		return 0, o.err
+	}
+	n, err := o.f.Write(p)
+	o.err = err
+	return n, err
+}
+
+// Commit moves the file into place at its final path, unless an error
+// occurred while writing it, in which case the temporary file is removed
+// and the error is returned.
+func (o *outputFile) Commit() error {
+	err := o.f.Close()
+	if o.err != nil {
+		err = o.err
+	}
+	if err == nil {
+		// TempFile creates files readable only by their owner, but an
+		// output file should have the usual permissions.
+		err = os.Chmod(o.f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(o.f.Name(), o.path)
+	}
+	if err != nil {
+		os.Remove(o.f.Name())
+	}
+	return err
+}
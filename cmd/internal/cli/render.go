@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apparentlymart/go-rst"
+)
+
+func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("render", "[file or directory ...]", "Renders each document in the selected output format.", stderr)
+	format := flags.String("format", "html", "output `format`: "+strings.Join(rst.RendererFormats(), ", "))
+	quiet := flags.Bool("q", false, "do not report warnings, only errors")
+	verbose := flags.Bool("v", false, "also report informational messages")
+	var in inputFlags
+	var out outputFlags
+	var parse parserFlags
+	in.register(flags)
+	out.register(flags)
+	parse.register(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	renderer, ok := rst.LookupRenderer(*format)
+	if !ok {
+		fmt.Fprintf(stderr, "unsupported format %q; must be one of %s\n", *format, strings.Join(rst.RendererFormats(), ", "))
+		return 2
+	}
+	convert := renderConverter(renderer, parse.Options, reportLevel(*quiet, *verbose))
+	return convertAll(convert, flags.Args(), in, out, outputExt(*format), stdin, stdout, stderr)
+}
+
+func runDump(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("dump", "[file or directory ...]", "Writes a description of the tree parsed from each document, for debugging.", stderr)
+	format := flags.String("format", "tree", "output `format`: tree, for an indented outline, or dot, for Graphviz")
+	quiet := flags.Bool("q", false, "do not report warnings, only errors")
+	verbose := flags.Bool("v", false, "also report informational messages")
+	var in inputFlags
+	var out outputFlags
+	var parse parserFlags
+	in.register(flags)
+	out.register(flags)
+	parse.register(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	var renderer rst.Renderer
+	switch *format {
+	case "tree":
+		renderer = rst.RendererFunc(func(w io.Writer, node interface{}) error {
+			_, err := io.WriteString(w, rst.DumpString(node))
+			return err
+		})
+	case "dot":
+		renderer = rst.RendererFunc(rst.DumpDOT)
+	default:
+		fmt.Fprintf(stderr, "unsupported format %q; must be tree or dot\n", *format)
+		return 2
+	}
+	convert := renderConverter(renderer, parse.Options, reportLevel(*quiet, *verbose))
+	return convertAll(convert, flags.Args(), in, out, "."+*format, stdin, stdout, stderr)
+}
+
+// renderConverter returns a convertFunc that parses its input with the given
+// options and writes it using the given renderer, reporting the problems
+// at or above the given severity.
+func renderConverter(renderer rst.Renderer, opts rst.Options, level rst.Severity) convertFunc {
+	return func(w io.Writer, r io.Reader, filename string, stderr io.Writer) int {
+		fragment, err := rst.ParseFragmentErr(r, filename, opts)
+		if err != nil {
+			return reportParseError(stderr, filename, err)
+		}
+		if err := renderer.Render(w, fragment); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		reportErrors(stderr, fragment, level)
+		return 0
+	}
+}
+
+// outputExt returns the filename extension used for files written in the
+// given format with -outdir.
+func outputExt(format string) string {
+	switch format {
+	case "markdown":
+		return ".md"
+	case "text":
+		return ".txt"
+	default:
+		return "." + format
+	}
+}
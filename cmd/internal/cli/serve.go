@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apparentlymart/go-rst"
+)
+
+func runServe(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("serve", "[directory]", "Serves a live HTML preview of the documents under the given directory, or the\ncurrent directory if none is given. Pages reload automatically when their\nsource files change.", stderr)
+	addr := flags.String("addr", "localhost:8000", "the `address` to listen on")
+	var in inputFlags
+	var parse parserFlags
+	in.register(flags)
+	parse.register(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	root := "."
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		root = flags.Arg(0)
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	srv, err := newPreviewServer(root, in.Ext, parse.Options)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "serving %s at http://%s/\n", root, *addr)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// previewServer is an http.Handler that renders the documents under a root
+// directory as HTML on demand.
+//
+// A request for the root path lists the documents. A request for the path
+// of a document renders it, reusing the previous rendering if the file has
+// not been modified since. Adding the query parameter "mtime" instead
+// returns just the modification time of the file, which the script in each
+// page polls so that it can reload itself when the file changes.
+type previewServer struct {
+	root string
+	ext  string
+	opts rst.Options
+
+	mu    sync.Mutex
+	cache map[string]previewPage
+}
+
+// previewPage is a rendering of a document, along with the modification
+// time of the file it was rendered from.
+type previewPage struct {
+	ModTime time.Time
+	HTML    []byte
+}
+
+func newPreviewServer(root, ext string, opts rst.Options) (*previewServer, error) {
+	abs, err := filepath.Abs(root)
+	if err == nil {
+		abs, err = filepath.EvalSymlinks(abs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &previewServer{
+		root:  abs,
+		ext:   ext,
+		opts:  opts,
+		cache: make(map[string]previewPage),
+	}, nil
+}
+
+func (s *previewServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	urlPath := path.Clean("/" + r.URL.Path)
+	if urlPath == "/" {
+		s.serveIndex(w)
+		return
+	}
+
+	filename, ok := s.resolve(urlPath)
+	if !ok || filepath.Ext(filename) != s.ext {
+		http.NotFound(w, r)
+		return
+	}
+	info, err := os.Stat(filename)
+	if err != nil || !info.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+
+	if _, ok := r.URL.Query()["mtime"]; ok {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		io.WriteString(w, strconv.FormatInt(info.ModTime().UnixNano(), 10))
+		return
+	}
+
+	page, err := s.render(filename, urlPath, info.ModTime())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(page)
+}
+
+// resolve returns the filename for the given cleaned URL path, or false if
+// it would refer to something outside of the root directory, including by
+// way of a symbolic link.
+func (s *previewServer) resolve(urlPath string) (string, bool) {
+	filename := filepath.Join(s.root, filepath.FromSlash(strings.TrimPrefix(urlPath, "/")))
+	resolved, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(s.root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filename, true
+}
+
+func (s *previewServer) render(filename, urlPath string, modTime time.Time) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if page, ok := s.cache[filename]; ok && page.ModTime.Equal(modTime) {
+		return page.HTML, nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fragment, err := rst.ParseFragmentErr(f, filename, s.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Errors are always rendered in place, so that they are visible
+	// while editing.
+	renderer, err := rst.NewTemplateRenderer("")
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	if err := renderer.Render(&body, fragment); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = previewTemplate.Execute(&buf, map[string]interface{}{
+		"Title":   urlPath,
+		"Path":    urlPath,
+		"ModTime": strconv.FormatInt(modTime.UnixNano(), 10),
+		"Body":    template.HTML(body.String()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache[filename] = previewPage{ModTime: modTime, HTML: buf.Bytes()}
+	return buf.Bytes(), nil
+}
+
+func (s *previewServer) serveIndex(w http.ResponseWriter) {
+	inputs, err := findInputs(s.root, s.ext)
+	var paths []string
+	for _, input := range inputs {
+		urlPath := "/" + filepath.ToSlash(input.Rel)
+		if _, ok := s.resolve(urlPath); ok {
+			paths = append(paths, urlPath)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+	previewIndexTemplate.Execute(w, map[string]interface{}{
+		"Paths": paths,
+		"Error": errMsg,
+	})
+}
+
+var previewTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 50em; margin: 2em auto; padding: 0 1em; font-family: sans-serif; line-height: 1.5; }
+.system-message { border: 2px solid #c00; background: #fee; padding: 0 1em; margin: 1em 0; }
+.system-message-title { font-weight: bold; color: #c00; }
+.problematic { color: #c00; }
+</style>
+</head>
+<body>
+<p><a href="/">&larr; all documents</a></p>
+{{.Body}}
+<script>
+(function() {
+  var path = {{.Path}};
+  var modTime = {{.ModTime}};
+  setInterval(function() {
+    fetch(path + "?mtime", {cache: "no-store"})
+      .then(function(resp) { return resp.ok ? resp.text() : modTime; })
+      .then(function(t) { if (t !== modTime) location.reload(); })
+      .catch(function() {});
+  }, 1000);
+})();
+</script>
+</body>
+</html>
+`))
+
+var previewIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Documents</title>
+</head>
+<body>
+<h1>Documents</h1>
+{{with .Error}}<p class="error">{{.}}</p>
+{{end -}}
+<ul>
+{{range .Paths}}<li><a href="{{.}}">{{.}}</a></li>
+{{end -}}
+</ul>
+</body>
+</html>
+`))
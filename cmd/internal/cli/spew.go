@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// SpewMain runs the deprecated go-rst-spew command, which predates the
+// go-rst subcommands, and returns its exit status.
+//
+// Its flags are parsed as go-rst-spew parsed them and then translated into
+// the equivalent go-rst command line: -lint selects the lint command,
+// -tokens or -format=tokens select the tokens command, -format with a
+// renderer format selects the render command, and anything else selects
+// the dump command. The remaining flags are passed on to the selected
+// command, except for those that go-rst-spew ignored in that mode, and
+// the file and directory arguments follow them.
+func SpewMain(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("go-rst-spew", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: go-rst-spew [flags] [file or directory ...]\n\n")
+		fmt.Fprintf(stderr, "Deprecated: use the go-rst command instead.\n\n")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "tree", "output `format`")
+	flags.String("ext", ".rst", "filename `extension` of the files to read from directories")
+	flags.String("outdir", "", "write the output for each file to a file under `dir` instead of to stdout")
+	flags.String("o", "", "write the output to `file` instead of to stdout")
+	flags.Bool("q", false, "do not report warnings, only errors")
+	tokens := flags.Bool("tokens", false, "dump the raw token stream from the scanner; the same as -format=tokens")
+	lint := flags.Bool("lint", false, "only report problems, instead of writing any output")
+	flags.String("fail-level", "warning", "with -lint, the minimum `level` of problem that causes failure: warning or error")
+	flags.Int("max-errors", 0, "with -lint, report at most `n` problems in total, or all of them if zero")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cmd := "dump"
+	switch {
+	case *lint:
+		cmd = "lint"
+	case *tokens || *format == "tokens":
+		cmd = "tokens"
+	case *format != "tree":
+		cmd = "render"
+	}
+
+	// Each flag is passed on only if the selected command has it.
+	passed := map[string][]string{
+		"ext":        {"dump", "render", "lint", "tokens"},
+		"outdir":     {"dump", "render", "lint", "tokens"},
+		"o":          {"dump", "render", "tokens"},
+		"q":          {"dump", "render"},
+		"fail-level": {"lint"},
+		"max-errors": {"lint"},
+	}
+	rest := []string{cmd}
+	if cmd == "render" {
+		rest = append(rest, "-format="+*format)
+	}
+	flags.Visit(func(f *flag.Flag) {
+		for _, name := range passed[f.Name] {
+			if name == cmd {
+				rest = append(rest, "-"+f.Name+"="+f.Value.String())
+			}
+		}
+	})
+	if flags.NArg() > 0 {
+		rest = append(rest, "--")
+		rest = append(rest, flags.Args()...)
+	}
+
+	return Main(rest, stdin, stdout, stderr)
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/apparentlymart/go-rst"
+)
+
+func runTokens(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("tokens", "[file or directory ...]", "Writes the raw token stream from the scanner for each document, for debugging.", stderr)
+	var in inputFlags
+	var out outputFlags
+	var parse parserFlags
+	in.register(flags)
+	out.register(flags)
+	parse.registerLimits(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	convert := func(w io.Writer, r io.Reader, filename string, stderr io.Writer) int {
+		if err := writeTokens(w, r, filename, parse.Options.Limits); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+	return convertAll(convert, flags.Args(), in, out, ".tokens", stdin, stdout, stderr)
+}
+
+// writeTokens writes one line for each token produced by the scanner. The
+// scanner is not given the feedback that the parser would give it about
+// the indentation of list items, so the tokens may differ slightly from
+// those that the parser sees.
+func writeTokens(w io.Writer, r io.Reader, filename string, limits rst.Limits) error {
+	bw := bufio.NewWriter(w)
+	scanner := rst.NewScanner(r, filename)
+	scanner.SetLimits(limits)
+	var tok *rst.Token
+	for {
+		tok = scanner.Read()
+		fmt.Fprintf(bw, "%d:%d\t%s\t%q\n", tok.Position.Line, tok.Position.Column, tok.Type, tok.Data)
+		if tok.Type == rst.EOF || tok.Type == rst.ERROR {
+			break
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if tok.Type == rst.ERROR {
+		return fmt.Errorf("%s: error: %s", tok.Position, tok.Data)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package rst
+
+// A Comment is an explicit markup block that is not any other kind of
+// explicit markup, such as:
+//
+//	.. This is a comment.
+//	   It continues for as long as the lines are indented.
+//
+// Comments are not rendered, but are kept in the tree by default so that
+// tools can read information from them, such as pragmas for linters. Use
+// Options.DropComments to have the parser omit them, or StripComments to
+// remove them afterwards.
+type Comment struct {
+	bodyElementImpl
+	Attributes
+
+	// Text is the content of the comment, with the explicit markup start
+	// and the common indentation of its lines removed. Lines are separated
+	// by newlines, and blank lines within the comment are preserved.
+	Text string
+
+	Pos Position
+}
+
+func (c *Comment) Position() Position {
+	return c.Pos
+}
+
+// StripComments removes all of the Comment elements from the tree rooted
+// at the given node, which may be any value accepted by Rewrite, and
+// returns the result.
+//
+// As with Rewrite, the tree is modified in place.
+func StripComments(node interface{}) interface{} {
+	return Rewrite(node, func(node interface{}) (interface{}, bool) {
+		if _, ok := node.(*Comment); ok {
+			return nil, false
+		}
+		return node, true
+	})
+}
+
+// StripCommentsTransform is a Transform that removes all of the comments
+// from a document, as StripComments does.
+var StripCommentsTransform = NewTransform("strip-comments", PriorityStripComments, func(doc *Document) []*Error {
+	StripComments(doc)
+	return nil
+})
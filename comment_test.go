@@ -0,0 +1,257 @@
+package rst
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseComment(t *testing.T) {
+	tests := []struct {
+		Name string
+		Src  string
+		Want Body
+	}{
+		{
+			"single line",
+			".. lint: disable=line-length\n",
+			Body{
+				&Comment{
+					Text: "lint: disable=line-length",
+					Pos:  Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"indented block",
+			".. first\n   second\n\n     indented\n\n   third\n\nafter\n",
+			Body{
+				&Comment{
+					Text: "first\nsecond\n\n  indented\n\nthird",
+					Pos:  Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+				&Paragraph{
+					Text: Text{CharData("after")},
+					Pos:  Position{Line: 8, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"block only",
+			"..\n   hidden\n   text\n",
+			Body{
+				&Comment{
+					Text: "hidden\ntext",
+					Pos:  Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"empty comment",
+			"..\n\n   quote\n",
+			Body{
+				&Comment{
+					Pos: Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+				&BlockQuote{
+					Quote: Body{
+						&Paragraph{
+							Text: Text{CharData("quote")},
+							Pos:  Position{Line: 3, Column: 4, Filename: "test.rst"},
+						},
+					},
+					Pos: Position{Line: 3, Column: 4, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"in list item",
+			"* item\n\n  .. note to self\n",
+			Body{
+				&BulletList{
+					Bullet: "*",
+					Items: []*ListItem{
+						{
+							Body: Body{
+								&Paragraph{
+									Text: Text{CharData("item")},
+									Pos:  Position{Line: 1, Column: 3, Filename: "test.rst"},
+								},
+								&Comment{
+									Text: "note to self",
+									Pos:  Position{Line: 3, Column: 3, Filename: "test.rst"},
+								},
+							},
+							Pos: Position{Line: 1, Column: 1, Filename: "test.rst"},
+						},
+					},
+					Pos: Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"directive is not a comment",
+			".. note:: hello\n",
+			Body{
+				&Paragraph{
+					Text: Text{CharData(".. note:: hello")},
+					Pos:  Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"target is not a comment",
+			".. _target: http://example.com/\n",
+			Body{
+				&Paragraph{
+					Text: Text{CharData(".. _target: http://example.com/")},
+					Pos:  Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"directive with body",
+			".. code::\n\n   x = 1\n",
+			Body{
+				&Paragraph{
+					Text: Text{CharData(".. code::")},
+					Pos:  Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+				&BlockQuote{
+					Quote: Body{
+						&Paragraph{
+							Text: Text{CharData("x = 1")},
+							Pos:  Position{Line: 3, Column: 4, Filename: "test.rst"},
+						},
+					},
+					Pos: Position{Line: 3, Column: 4, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"continuing a paragraph",
+			"text\n.. not a comment\n",
+			Body{
+				&Paragraph{
+					Text: Text{CharData("text"), CharData(".. not a comment")},
+					Pos:  Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"starting a list item",
+			"* .. first\n    second\n",
+			Body{
+				&BulletList{
+					Bullet: "*",
+					Items: []*ListItem{
+						{
+							Body: Body{
+								&Comment{
+									Text: "first\nsecond",
+									Pos:  Position{Line: 1, Column: 3, Filename: "test.rst"},
+								},
+							},
+							Pos: Position{Line: 1, Column: 1, Filename: "test.rst"},
+						},
+					},
+					Pos: Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+		{
+			"not explicit markup",
+			"..not a comment\n",
+			Body{
+				&Paragraph{
+					Text: Text{CharData("..not a comment")},
+					Pos:  Position{Line: 1, Column: 1, Filename: "test.rst"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ParseFragmentString(test.Src, "test.rst")
+			want := &Fragment{
+				Body:       test.Want,
+				Pos:        Position{Line: 1, Column: 1, Filename: "test.rst"},
+				SourceName: "test.rst",
+			}
+			if diff := Diff(want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDropComments(t *testing.T) {
+	src := ".. pragma\n\nhello\n"
+	got := ParseFragmentString(src, "test.rst", Options{DropComments: true})
+	want := &Fragment{
+		Body: Body{
+			&Paragraph{
+				Text: Text{CharData("hello")},
+				Pos:  Position{Line: 3, Column: 1, Filename: "test.rst"},
+			},
+		},
+		Pos:        Position{Line: 1, Column: 1, Filename: "test.rst"},
+		SourceName: "test.rst",
+	}
+	if diff := Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestStripComments(t *testing.T) {
+	src := ".. pragma: keep-me\n\nIntro.\n\n* item\n\n  .. hidden\n\n  more\n\n.. trailing\n"
+
+	kept := ParseFragmentString(src, "test.rst")
+	var comments []string
+	Walk(kept, func(node interface{}) bool {
+		if c, ok := node.(*Comment); ok {
+			comments = append(comments, c.Text)
+		}
+		return true
+	})
+	if len(comments) != 3 || comments[0] != "pragma: keep-me" {
+		t.Fatalf("wrong comments with default options: %q", comments)
+	}
+	if got, want := PlainText(kept), "Intro.\nitem\nmore"; got != want {
+		t.Errorf("wrong plain text %q; want %q", got, want)
+	}
+
+	renderers := []string{"html", "markdown", "text"}
+	render := func(format string, node interface{}) string {
+		r, ok := LookupRenderer(format)
+		if !ok {
+			t.Fatalf("no %s renderer", format)
+		}
+		var buf bytes.Buffer
+		if err := r.Render(&buf, node); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	before := make(map[string]string)
+	for _, format := range renderers {
+		before[format] = render(format, kept)
+	}
+
+	stripped := StripComments(kept)
+	Walk(stripped, func(node interface{}) bool {
+		if _, ok := node.(*Comment); ok {
+			t.Errorf("comment remains after StripComments")
+		}
+		return true
+	})
+	if diff := Diff(ParseFragmentString(src, "test.rst", Options{DropComments: true}), stripped); diff != "" {
+		t.Errorf("stripped tree differs from tree parsed without comments\n%s", diff)
+	}
+
+	for _, format := range renderers {
+		if got := render(format, stripped); got != before[format] {
+			t.Errorf("%s output differs after stripping comments\nbefore:\n%s\nafter:\n%s", format, before[format], got)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package rst
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestConformance compares the parser's output for each input in the
+// testdata/conformance corpus with the expected tree beside it, and reports
+// how closely they match for each construct. The expected trees are
+// currently written by hand rather than generated by docutils; see the
+// README in that directory.
+//
+// This is a measure of progress rather than a pass/fail test, so it only
+// runs when the RST_CONFORMANCE environment variable is set, and it fails
+// only if the corpus itself can't be read.
+func TestConformance(t *testing.T) {
+	if os.Getenv("RST_CONFORMANCE") == "" {
+		t.Skip("set RST_CONFORMANCE=1 to run the conformance comparison")
+	}
+
+	inputs, err := filepath.Glob(filepath.Join("testdata", "conformance", "*", "*.rst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no conformance inputs found")
+	}
+
+	scores := make(map[string][]float64)
+	for _, inputPath := range inputs {
+		construct := filepath.Base(filepath.Dir(inputPath))
+		refPath := strings.TrimSuffix(inputPath, ".rst") + ".pseudoxml"
+
+		src, err := ioutil.ReadFile(inputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, err := ioutil.ReadFile(refPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		fragment := ParseFragmentBytes(src, filepath.Base(inputPath))
+		if err := WritePseudoXML(&buf, fragment); err != nil {
+			t.Fatal(err)
+		}
+
+		want := normalizePseudoXML(string(ref))
+		got := normalizePseudoXML(buf.String())
+		score := conformanceScore(want, got)
+		scores[construct] = append(scores[construct], score)
+
+		if score < 1 {
+			t.Logf(
+				"%s: %.0f%%\n%s", inputPath, score*100,
+				diffLines(strings.Join(want, "\n"), strings.Join(got, "\n")),
+			)
+		}
+	}
+
+	constructs := make([]string, 0, len(scores))
+	for construct := range scores {
+		constructs = append(constructs, construct)
+	}
+	sort.Strings(constructs)
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "conformance by construct:\n")
+	for _, construct := range constructs {
+		total := 0.0
+		for _, score := range scores[construct] {
+			total += score
+		}
+		fmt.Fprintf(
+			&report, "  %-20s %5.1f%% (%d inputs)\n",
+			construct, total/float64(len(scores[construct]))*100, len(scores[construct]),
+		)
+	}
+	t.Log(report.String())
+}
+
+var pseudoXMLAttrPattern = regexp.MustCompile(`([\w:]+)="([^"]*)"`)
+
+// normalizePseudoXML returns the significant lines of the given pseudo-XML
+// output, with differences that don't affect the meaning removed: trailing
+// whitespace and blank lines are dropped, attributes are sorted, and source
+// attributes are reduced to just their filename.
+func normalizePseudoXML(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(trimmed, "<") && strings.HasSuffix(trimmed, ">") {
+			indent := line[:len(line)-len(trimmed)]
+			name := strings.Fields(strings.Trim(trimmed, "<>"))[0]
+			var attrs []string
+			for _, match := range pseudoXMLAttrPattern.FindAllStringSubmatch(trimmed, -1) {
+				value := match[2]
+				if match[1] == "source" {
+					value = filepath.Base(value)
+				}
+				attrs = append(attrs, fmt.Sprintf("%s=%q", match[1], value))
+			}
+			sort.Strings(attrs)
+			line = indent + "<" + strings.Join(append([]string{name}, attrs...), " ") + ">"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// conformanceScore returns the similarity of the two given sequences of
+// lines as a number between zero and one, based on the length of their
+// longest common subsequence.
+func conformanceScore(want, got []string) float64 {
+	if len(want)+len(got) == 0 {
+		return 1
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// want[i:] and got[j:].
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			switch {
+			case want[i] == got[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] > lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	return float64(2*lcs[0][0]) / float64(len(want)+len(got))
+}
+
+func TestConformanceScore(t *testing.T) {
+	tests := []struct {
+		Want, Got []string
+		Score     float64
+	}{
+		{nil, nil, 1},
+		{[]string{"a", "b"}, []string{"a", "b"}, 1},
+		{[]string{"a", "b"}, []string{"a", "c"}, 0.5},
+		{[]string{"a"}, nil, 0},
+	}
+	for _, test := range tests {
+		if got := conformanceScore(test.Want, test.Got); got != test.Score {
+			t.Errorf("conformanceScore(%q, %q) = %v; want %v", test.Want, test.Got, got, test.Score)
+		}
+	}
+
+	got := normalizePseudoXML("<document source=\"/tmp/x.rst\">  \n\n    <list suffix=\".\" prefix=\"\">\n")
+	want := []string{`<document source="x.rst">`, `    <list prefix="" suffix=".">`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wrong normalized lines %q; want %q", got, want)
+	}
+}
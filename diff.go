@@ -0,0 +1,137 @@
+package rst
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Diff compares two trees and returns a description of the differences
+// between them, or an empty string if they are equal.
+//
+// Each line of the result describes one difference, prefixed with the path
+// to the differing value, like this:
+//
+//	Body[0].(*BulletList).Items[1].Body[0].(*Paragraph).Text[0]: CharData("bar") != CharData("baz")
+//
+// The value from want appears first. As with Equal, nil and empty slices are
+// considered equal. Diff is primarily intended for producing readable test
+// failure messages.
+func Diff(want, got interface{}) string {
+	var diffs []string
+	diffValues(&diffs, "", reflect.ValueOf(want), reflect.ValueOf(got))
+	if len(diffs) == 0 {
+		return ""
+	}
+	return strings.Join(diffs, "\n") + "\n"
+}
+
+func diffValues(diffs *[]string, path string, a, b reflect.Value) {
+	report := func(aDesc, bDesc string) {
+		p := strings.TrimPrefix(path, ".")
+		if p == "" {
+			p = "(root)"
+		}
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s", p, aDesc, bDesc))
+	}
+
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			report(diffDescribe(a), diffDescribe(b))
+		}
+		return
+	}
+	if a.Type() != b.Type() {
+		report(diffTypeName(a.Type()), diffTypeName(b.Type()))
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				report(diffDescribe(a), diffDescribe(b))
+			}
+			return
+		}
+		ae, be := a.Elem(), b.Elem()
+		if ae.Type() != be.Type() {
+			report(diffTypeName(ae.Type()), diffTypeName(be.Type()))
+			return
+		}
+		if ae.Kind() == reflect.Ptr || ae.Kind() == reflect.Struct {
+			path = fmt.Sprintf("%s.(%s)", path, diffTypeName(ae.Type()))
+		}
+		diffValues(diffs, path, ae, be)
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				report(diffDescribe(a), diffDescribe(b))
+			}
+			return
+		}
+		diffValues(diffs, path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			name := a.Type().Field(i).Name
+			diffValues(diffs, path+"."+name, a.Field(i), b.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		n := a.Len()
+		if b.Len() > n {
+			n = b.Len()
+		}
+		for i := 0; i < n; i++ {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= a.Len():
+				diffValues(diffs, elemPath, reflect.Value{}, b.Index(i))
+			case i >= b.Len():
+				diffValues(diffs, elemPath, a.Index(i), reflect.Value{})
+			default:
+				diffValues(diffs, elemPath, a.Index(i), b.Index(i))
+			}
+		}
+	default:
+		if !equalValues(a, b) {
+			report(diffDescribe(a), diffDescribe(b))
+		}
+	}
+}
+
+// diffDescribe returns a short description of a value for use in the
+// output of Diff.
+func diffDescribe(v reflect.Value) string {
+	if !v.IsValid() {
+		return "(missing)"
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		return diffTypeName(v.Elem().Type())
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return diffTypeName(v.Type())
+	case reflect.String:
+		if v.Type().Name() == "string" {
+			return fmt.Sprintf("%q", v.String())
+		}
+		return fmt.Sprintf("%s(%q)", diffTypeName(v.Type()), v.String())
+	}
+
+	if v.CanInterface() {
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+		return fmt.Sprintf("%v", v.Interface())
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// diffTypeName returns the name of the given type with references to this
+// package's name removed, for brevity.
+func diffTypeName(t reflect.Type) string {
+	return strings.Replace(t.String(), "rst.", "", -1)
+}
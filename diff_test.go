@@ -0,0 +1,63 @@
+package rst
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		Want, Got interface{}
+		Diff      string
+	}{
+		{
+			&Fragment{},
+			&Fragment{Body: Body{}},
+			"",
+		},
+		{
+			&Fragment{
+				Body: Body{
+					&BulletList{
+						Items: []*ListItem{
+							{Body: Body{&Paragraph{Text: Text{CharData("foo")}}}},
+							{Body: Body{&Paragraph{Text: Text{CharData("bar")}}}},
+						},
+					},
+				},
+			},
+			&Fragment{
+				Body: Body{
+					&BulletList{
+						Items: []*ListItem{
+							{Body: Body{&Paragraph{Text: Text{CharData("foo")}}}},
+							{Body: Body{&Paragraph{Text: Text{CharData("baz")}}}},
+						},
+					},
+				},
+			},
+			`Body[0].(*BulletList).Items[1].Body[0].(*Paragraph).Text[0]: CharData("bar") != CharData("baz")` + "\n",
+		},
+		{
+			Body{&Paragraph{}},
+			Body{&BlockQuote{}, &Paragraph{Pos: Position{Line: 2}}},
+			"[0]: *Paragraph != *BlockQuote\n[1]: (missing) != *Paragraph\n",
+		},
+		{
+			&Token{Type: LINE, Data: "a"},
+			&Token{Type: BLANK, Data: "a"},
+			"Type: LINE != BLANK\n",
+		},
+		{
+			&Error{Message: "a", Pos: Position{Line: 1, Column: 2}},
+			&Error{Message: "a", Pos: Position{Line: 1, Column: 3}},
+			"Pos.Column: 2 != 3\n",
+		},
+	}
+
+	for _, test := range tests {
+		got := Diff(test.Want, test.Got)
+		if got != test.Diff {
+			t.Errorf("wrong diff\ngot:\n%s\nwant:\n%s", got, test.Diff)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package rst
+
+// DocInfo is the bibliographic information of a document, given by a field
+// list at the start of the document in source.
+//
+// The standard bibliographic fields that docutils recognizes each have a
+// field of their own, so that writers can use them without interpreting
+// the document's markup: for example, to produce meta tags in HTML or the
+// header of a manual page. Any other fields are kept in Fields, in their
+// original order.
+//
+// As with the other sequence types, each Text field is nil if the
+// corresponding entry is absent.
+type DocInfo struct {
+	Attributes
+
+	// Author is the single author given by an "Author" field.
+	Author Text
+
+	// Authors are the authors given by an "Authors" field, which lists
+	// several authors separated by semicolons or commas.
+	Authors []Text
+
+	Organization Text
+	Address      Text
+	Contact      Text
+	Version      Text
+	Revision     Text
+	Status       Text
+	Date         Text
+	Copyright    Text
+
+	// Fields are the fields that are not among the standard ones above,
+	// in the order they appeared.
+	Fields []*DocInfoField
+
+	Pos Position
+}
+
+func (d *DocInfo) Position() Position {
+	return d.Pos
+}
+
+// A DocInfoField is a field of a DocInfo that docutils does not recognize
+// as one of the standard bibliographic fields.
+type DocInfoField struct {
+	Attributes
+	Name Text
+	Body Body
+	Pos  Position
+}
+
+func (f *DocInfoField) Position() Position {
+	return f.Pos
+}
+
+// docInfoText returns the standard fields of d that follow the authors, in
+// the order that docutils places them, along with their normalized names.
+func (d *DocInfo) docInfoText() []struct {
+	Name string
+	Text Text
+} {
+	return []struct {
+		Name string
+		Text Text
+	}{
+		{"organization", d.Organization},
+		{"address", d.Address},
+		{"contact", d.Contact},
+		{"version", d.Version},
+		{"revision", d.Revision},
+		{"status", d.Status},
+		{"date", d.Date},
+		{"copyright", d.Copyright},
+	}
+}
+
+// Lookup returns the content of the field with the given name, or nil if
+// there is no such field.
+//
+// The name is matched after normalizing it in the same way as reference
+// names, so that for example "Author", "author" and " AUTHOR " are all the
+// same field. Standard fields are found by their usual names, and produce
+// a paragraph for each value: one for most fields, or one per author for
+// "authors". Other fields produce their body as given.
+func (d *DocInfo) Lookup(name string) Body {
+	if d == nil {
+		return nil
+	}
+
+	paragraph := func(text Text) Body {
+		if len(text) == 0 {
+			return nil
+		}
+		return Body{&Paragraph{Text: text}}
+	}
+
+	name = normalizeName(name)
+	switch name {
+	case "author":
+		return paragraph(d.Author)
+	case "authors":
+		var ret Body
+		for _, author := range d.Authors {
+			ret = append(ret, paragraph(author)...)
+		}
+		return ret
+	}
+	for _, field := range d.docInfoText() {
+		if field.Name == name {
+			return paragraph(field.Text)
+		}
+	}
+	for _, field := range d.Fields {
+		if normalizeName(field.Name.String()) == name {
+			return field.Body
+		}
+	}
+	return nil
+}
+
+// IsEmpty returns true if none of the fields are set. It may be called on a
+// nil *DocInfo.
+func (d *DocInfo) IsEmpty() bool {
+	if d == nil {
+		return true
+	}
+	for _, field := range d.docInfoText() {
+		if len(field.Text) != 0 {
+			return false
+		}
+	}
+	return len(d.Author) == 0 && len(d.Authors) == 0 && len(d.Fields) == 0
+}
@@ -0,0 +1,185 @@
+package rst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func docInfoTestDocument() *Document {
+	para := func(s string) *Paragraph {
+		return &Paragraph{Text: Text{CharData(s)}}
+	}
+	return &Document{
+		Title: Text{CharData("Title")},
+		DocInfo: &DocInfo{
+			Authors: []Text{
+				{CharData("Ann")},
+				{CharData("Bob")},
+			},
+			Version: Text{CharData("1.0")},
+			Date:    Text{CharData("2020-01-01")},
+			Fields: []*DocInfoField{
+				{
+					Name: Text{CharData("Reviewed By")},
+					Body: Body{para("Cat")},
+				},
+				{
+					Name: Text{CharData("Audience")},
+					Body: Body{para("everyone")},
+				},
+			},
+		},
+		Body: Body{para("body")},
+	}
+}
+
+func TestDocInfoLookup(t *testing.T) {
+	info := docInfoTestDocument().DocInfo
+
+	tests := []struct {
+		Name string
+		Want []string
+	}{
+		{"version", []string{"1.0"}},
+		{"  Version ", []string{"1.0"}},
+		{"authors", []string{"Ann", "Bob"}},
+		{"author", nil},
+		{"copyright", nil},
+		{"reviewed  by", []string{"Cat"}},
+		{"AUDIENCE", []string{"everyone"}},
+		{"nonexistent", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var got []string
+			for _, elem := range info.Lookup(test.Name) {
+				got = append(got, elem.(*Paragraph).Text.String())
+			}
+			if strings.Join(got, ",") != strings.Join(test.Want, ",") {
+				t.Errorf("wrong result %q; want %q", got, test.Want)
+			}
+		})
+	}
+
+	var nilInfo *DocInfo
+	if got := nilInfo.Lookup("author"); got != nil {
+		t.Errorf("nil DocInfo returned %#v", got)
+	}
+}
+
+func TestDocInfoIsEmpty(t *testing.T) {
+	var nilInfo *DocInfo
+	if !nilInfo.IsEmpty() {
+		t.Errorf("nil DocInfo is not empty")
+	}
+	if !(&DocInfo{}).IsEmpty() {
+		t.Errorf("zero DocInfo is not empty")
+	}
+	if (&DocInfo{Copyright: Text{CharData("me")}}).IsEmpty() {
+		t.Errorf("DocInfo with copyright is empty")
+	}
+	if (&DocInfo{Fields: []*DocInfoField{{}}}).IsEmpty() {
+		t.Errorf("DocInfo with a custom field is empty")
+	}
+}
+
+func TestDocInfoWriters(t *testing.T) {
+	doc := docInfoTestDocument()
+
+	t.Run("xml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteXML(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		want := `    <title>Title</title>
+    <docinfo>
+        <authors>
+            <author>Ann</author>
+            <author>Bob</author>
+        </authors>
+        <version>1.0</version>
+        <date>2020-01-01</date>
+        <field>
+            <field_name>Reviewed By</field_name>
+            <field_body>
+                <paragraph>Cat</paragraph>
+            </field_body>
+        </field>
+        <field>
+            <field_name>Audience</field_name>
+            <field_body>
+                <paragraph>everyone</paragraph>
+            </field_body>
+        </field>
+    </docinfo>
+    <paragraph>body</paragraph>
+`
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("wrong output\ngot:\n%s\nwant it to contain:\n%s", got, want)
+		}
+	})
+	t.Run("html", func(t *testing.T) {
+		r, err := NewTemplateRenderer("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := r.Render(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		want := `<dl class="docinfo simple">
+<dt class="authors">Authors</dt>
+<dd class="authors">Ann<br />
+Bob</dd>
+<dt class="version">Version</dt>
+<dd class="version">1.0</dd>
+<dt class="date">Date</dt>
+<dd class="date">2020-01-01</dd>
+<dt class="field">Reviewed By</dt>
+<dd class="field"><p>Cat</p>
+</dd>
+<dt class="field">Audience</dt>
+<dd class="field"><p>everyone</p>
+</dd>
+</dl>
+`
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("wrong output\ngot:\n%s\nwant it to contain:\n%s", got, want)
+		}
+	})
+}
+
+func TestDocInfoTraversal(t *testing.T) {
+	doc := docInfoTestDocument()
+
+	var got []string
+	Walk(doc, func(node interface{}) bool {
+		if c, ok := node.(CharData); ok {
+			got = append(got, string(c))
+		}
+		return true
+	})
+	want := "Title,Ann,Bob,1.0,2020-01-01,Reviewed By,Cat,Audience,everyone,body"
+	if got := strings.Join(got, ","); got != want {
+		t.Errorf("wrong walk order\ngot:  %s\nwant: %s", got, want)
+	}
+
+	Rewrite(doc, func(node interface{}) (interface{}, bool) {
+		switch n := node.(type) {
+		case *DocInfoField:
+			if n.Name.String() == "Audience" {
+				return nil, false
+			}
+		case CharData:
+			if n == "Bob" {
+				return nil, false
+			}
+		}
+		return node, true
+	})
+	info := doc.DocInfo
+	if len(info.Authors) != 1 || len(info.Fields) != 1 {
+		t.Errorf("rewrite did not remove author and field\n%#v", info)
+	}
+}
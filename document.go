@@ -1,12 +1,77 @@
 package rst
 
 type Document struct {
+	Attributes
 	Title    Text
 	Subtitle Text
 
-	// TODO: Decoration, Docinfo, Transition
+	// Decoration holds the page header and footer of the document, or is
+	// nil if it has neither. See GetOrCreateDecoration.
+	Decoration *Decoration
+
+	// DocInfo is the bibliographic information of the document, or nil if
+	// it has none.
+	DocInfo *DocInfo
+
+	// TODO: Transition
 
 	Body Body
 
 	ChildElements Structure
+
+	Pos Position
+
+	// SourceName is the filename that was given to the parser for the
+	// source of the document.
+	SourceName string
+}
+
+func (d *Document) Position() Position {
+	return d.Pos
+}
+
+// GetOrCreateDecoration returns the document's decoration, first creating
+// an empty one if the document has none.
+//
+// This is the way to add a header or footer, so that a document only has a
+// decoration when something has been put in it:
+//
+//	deco := doc.GetOrCreateDecoration()
+//	deco.Footer = append(deco.Footer, para)
+func (d *Document) GetOrCreateDecoration() *Decoration {
+	if d.Decoration == nil {
+		d.Decoration = &Decoration{Pos: d.Pos}
+	}
+	return d.Decoration
+}
+
+// A Decoration holds the content that is to appear at the top and bottom of
+// each page of a document, as given by the header and footer directives,
+// as opposed to the content of the document itself.
+//
+// The header and footer are separate from the body of the document so that
+// writers can place them appropriately for their output format. The writers
+// in this package that produce a whole page put the header before the title
+// and the footer after all of the other content.
+type Decoration struct {
+	Attributes
+
+	// Header is the content of the page header, or nil if there is none.
+	Header Body
+
+	// Footer is the content of the page footer, or nil if there is none.
+	Footer Body
+
+	Pos Position
+}
+
+func (d *Decoration) Position() Position {
+	return d.Pos
+}
+
+// IsEmpty returns true if the decoration has neither a header nor a footer,
+// in which case writers produce nothing for it. It may be called on a nil
+// *Decoration.
+func (d *Decoration) IsEmpty() bool {
+	return d == nil || (len(d.Header) == 0 && len(d.Footer) == 0)
 }
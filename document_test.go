@@ -0,0 +1,128 @@
+package rst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func decorationTestDocument() *Document {
+	doc := &Document{
+		Title: Text{CharData("Title")},
+		Body:  Body{&Paragraph{Text: Text{CharData("body")}}},
+	}
+	deco := doc.GetOrCreateDecoration()
+	deco.Header = Body{&Paragraph{Text: Text{CharData("top")}}}
+	deco.Footer = Body{&Paragraph{Text: Text{CharData("bottom")}}}
+	return doc
+}
+
+func TestGetOrCreateDecoration(t *testing.T) {
+	doc := &Document{}
+	if !doc.Decoration.IsEmpty() {
+		t.Errorf("new document has a decoration")
+	}
+
+	deco := doc.GetOrCreateDecoration()
+	if deco == nil || doc.Decoration != deco {
+		t.Fatalf("decoration was not created")
+	}
+	if !deco.IsEmpty() {
+		t.Errorf("new decoration is not empty")
+	}
+	if again := doc.GetOrCreateDecoration(); again != deco {
+		t.Errorf("second call created another decoration")
+	}
+
+	deco.Footer = Body{&Paragraph{Text: Text{CharData("footer")}}}
+	if deco.IsEmpty() {
+		t.Errorf("decoration with a footer is empty")
+	}
+}
+
+func TestDecorationWriters(t *testing.T) {
+	doc := decorationTestDocument()
+
+	t.Run("xml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteXML(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		want := `    <title>Title</title>
+    <decoration>
+        <header>
+            <paragraph>top</paragraph>
+        </header>
+        <footer>
+            <paragraph>bottom</paragraph>
+        </footer>
+    </decoration>
+    <paragraph>body</paragraph>
+`
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("wrong output\ngot:\n%s\nwant it to contain:\n%s", got, want)
+		}
+	})
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteMarkdown(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		want := "top\n\n# Title\n\nbody\n\nbottom\n"
+		if got := buf.String(); got != want {
+			t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
+	t.Run("html", func(t *testing.T) {
+		r, err := NewTemplateRenderer("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := r.Render(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.String()
+		header := strings.Index(got, `<div class="header">`)
+		document := strings.Index(got, `<div class="document">`)
+		footer := strings.Index(got, `<div class="footer">`)
+		if header < 0 || document < header || footer < document {
+			t.Errorf("header and footer are not around the document\n%s", got)
+		}
+	})
+}
+
+func TestDecorationTraversal(t *testing.T) {
+	doc := decorationTestDocument()
+
+	var got []string
+	Walk(doc, func(node interface{}) bool {
+		if c, ok := node.(CharData); ok {
+			got = append(got, string(c))
+		}
+		return true
+	})
+	if got, want := strings.Join(got, ","), "Title,top,bottom,body"; got != want {
+		t.Errorf("wrong walk order %q; want %q", got, want)
+	}
+
+	Rewrite(doc, func(node interface{}) (interface{}, bool) {
+		if c, ok := node.(CharData); ok {
+			return CharData(strings.ToUpper(string(c))), false
+		}
+		return node, true
+	})
+	if got := doc.Decoration.Footer[0].(*Paragraph).Text.String(); got != "BOTTOM" {
+		t.Errorf("rewrite did not reach footer; got %q", got)
+	}
+
+	Rewrite(doc, func(node interface{}) (interface{}, bool) {
+		if _, ok := node.(*Decoration); ok {
+			return nil, false
+		}
+		return node, true
+	})
+	if doc.Decoration != nil {
+		t.Errorf("rewrite did not remove decoration")
+	}
+}
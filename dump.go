@@ -0,0 +1,258 @@
+package rst
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// dumpTextLimit is the maximum number of characters of text shown for each
+// CharData node by DumpTree and DumpDOT.
+const dumpTextLimit = 40
+
+// DumpTree writes a compact, indented outline of the given node and its
+// descendents to w, for debugging. Each node is described on a line of its
+// own, giving its type, its position and a summary of its content, with
+// long text truncated.
+//
+// The node may be any value accepted by Walk. The output format is intended
+// for humans and may change in future versions. For output that is complete
+// and stable, use DumpString.
+func DumpTree(w io.Writer, node interface{}) error {
+	bw := bufio.NewWriter(w)
+	dumpOutline(bw, node, 0, dumpTextLimit)
+	return bw.Flush()
+}
+
+// DumpString returns an indented outline of the given node and its
+// descendents in the same format as DumpTree, except that text is never
+// truncated. The result is deterministic and is designed to be compared
+// line by line, as in golden files and test failure messages.
+//
+// The node may be any value accepted by Walk.
+//
+// Unlike that of DumpTree, this format is stable: existing lines will not
+// change in future versions, though new element types and fields may add
+// new ones. Any exception will be noted here.
+//
+// Exceptions: the lines for Fragment and Document now end with the quoted
+// source name, if there is one, or for a fragment merged by MergeFragments
+// each of the source names it was merged from.
+func DumpString(node interface{}) string {
+	var buf strings.Builder
+	bw := bufio.NewWriter(&buf)
+	dumpOutline(bw, node, 0, 0)
+	bw.Flush()
+	return buf.String()
+}
+
+// dumpOutline writes the outline of node for DumpTree and DumpString,
+// truncating text to the given number of characters unless limit is zero.
+//
+// Most nodes have their children listed beneath them, but the inline
+// content of elements that have several distinct parts, such as the title
+// and body of a section, appears beneath a label for each part so that
+// the parts can be told apart.
+func dumpOutline(w *bufio.Writer, node interface{}, depth int, limit int) {
+	switch node.(type) {
+	case Structure, Body, Text, nil:
+		// Sequences have no line of their own.
+		for _, child := range Children(node) {
+			dumpOutline(w, child, depth, limit)
+		}
+		return
+	}
+
+	dumpLine(w, depth, dumpDescribe(node, limit))
+
+	depth++
+	switch n := node.(type) {
+	case *Document:
+		dumpLabeled(w, "Title", n.Title, depth, limit)
+		dumpLabeled(w, "Subtitle", n.Subtitle, depth, limit)
+		if n.Decoration != nil {
+			dumpOutline(w, n.Decoration, depth, limit)
+		}
+		if n.DocInfo != nil {
+			dumpOutline(w, n.DocInfo, depth, limit)
+		}
+		dumpOutline(w, n.Body, depth, limit)
+		dumpOutline(w, n.ChildElements, depth, limit)
+	case *Section:
+		dumpLabeled(w, "Title", n.Title, depth, limit)
+		dumpOutline(w, n.Body, depth, limit)
+		dumpOutline(w, n.ChildElements, depth, limit)
+	case *BlockQuote:
+		dumpOutline(w, n.Quote, depth, limit)
+		dumpLabeled(w, "Attribution", n.Attribution, depth, limit)
+	case *Decoration:
+		dumpLabeled(w, "Header", n.Header, depth, limit)
+		dumpLabeled(w, "Footer", n.Footer, depth, limit)
+	case *DocInfo:
+		dumpLabeled(w, "Author", n.Author, depth, limit)
+		for _, author := range n.Authors {
+			dumpLabeled(w, "Authors", author, depth, limit)
+		}
+		for _, field := range n.docInfoText() {
+			label := strings.ToUpper(field.Name[:1]) + field.Name[1:]
+			dumpLabeled(w, label, field.Text, depth, limit)
+		}
+		for _, field := range n.Fields {
+			dumpOutline(w, field, depth, limit)
+		}
+	case *DocInfoField:
+		dumpLabeled(w, "Name", n.Name, depth, limit)
+		dumpOutline(w, n.Body, depth, limit)
+	case *Error:
+		if n.Skipped != "" {
+			dumpLine(w, depth, fmt.Sprintf("Skipped %q", dumpTruncate(n.Skipped, limit)))
+		}
+	default:
+		for _, child := range Children(node) {
+			dumpOutline(w, child, depth, limit)
+		}
+	}
+}
+
+// dumpLabeled writes the outline of the given sequence beneath a line
+// giving its label, or nothing at all if the sequence is empty.
+func dumpLabeled(w *bufio.Writer, label string, seq interface{}, depth int, limit int) {
+	if len(Children(seq)) == 0 {
+		return
+	}
+	dumpLine(w, depth, label)
+	dumpOutline(w, seq, depth+1, limit)
+}
+
+func dumpLine(w *bufio.Writer, depth int, line string) {
+	w.WriteString(strings.Repeat("  ", depth))
+	w.WriteString(line)
+	w.WriteByte('\n')
+}
+
+// DumpDOT writes a description of the given node and its descendents to w
+// in the Graphviz DOT language, for visualizing the structure of the tree.
+// Each node is labelled in the same way as for DumpTree.
+//
+// The node may be any value accepted by Walk.
+func DumpDOT(w io.Writer, node interface{}) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph rst {\n")
+	bw.WriteString("  node [shape=box, fontname=monospace];\n")
+
+	next := 0
+	var visit func(node interface{}, parent int)
+	visit = func(node interface{}, parent int) {
+		switch node.(type) {
+		case Structure, Body, Text, nil:
+			for _, child := range Children(node) {
+				visit(child, parent)
+			}
+			return
+		}
+
+		id := next
+		next++
+		fmt.Fprintf(bw, "  n%d [label=%q];\n", id, dumpDescribe(node, dumpTextLimit))
+		if parent >= 0 {
+			fmt.Fprintf(bw, "  n%d -> n%d;\n", parent, id)
+		}
+		for _, child := range Children(node) {
+			visit(child, id)
+		}
+	}
+	visit(node, -1)
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// dumpDescribe returns the single-line description of the given node used
+// by DumpTree, DumpString and DumpDOT, truncating text to the given number
+// of characters unless limit is zero.
+func dumpDescribe(node interface{}, limit int) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", node), "*")
+	name = strings.TrimPrefix(name, "rst.")
+	if n, ok := node.(Node); ok {
+		p := n.Position()
+		name = fmt.Sprintf("%s @%d:%d", name, p.Line, p.Column)
+	}
+
+	if attrs := NodeAttributes(node); !attrs.IsEmpty() {
+		name += " " + dumpAttributes(attrs)
+	}
+
+	switch n := node.(type) {
+	case *Fragment:
+		names := n.SourceNames
+		if names == nil && n.SourceName != "" {
+			names = []string{n.SourceName}
+		}
+		for _, sourceName := range names {
+			name += fmt.Sprintf(" %q", sourceName)
+		}
+		return name
+	case *Document:
+		if n.SourceName != "" {
+			return fmt.Sprintf("%s %q", name, n.SourceName)
+		}
+		return name
+	case *Decoration:
+		// The header and footer are flattened together in the children,
+		// so we say how many of them belong to each.
+		return fmt.Sprintf("%s header=%d footer=%d", name, len(n.Header), len(n.Footer))
+	case *BulletList:
+		return fmt.Sprintf("%s %q", name, n.Bullet)
+	case *EnumeratedList:
+		return fmt.Sprintf("%s %s %q %q %d", name, n.EnumType, n.EnumPrefix, n.EnumSuffix, n.FirstIndex)
+	case *Error:
+		_, severity := docutilsLevel(n.Severity)
+		severity = strings.ToLower(severity)
+		return fmt.Sprintf("%s %s %q", name, severity, n.Message)
+	case *Comment:
+		return fmt.Sprintf("%s %q", name, dumpTruncate(n.Text, limit))
+	case *LiteralBlock:
+		return fmt.Sprintf("%s %q", name, dumpTruncate(n.Text, limit))
+	case *Raw:
+		return fmt.Sprintf("%s %q %q", name, n.Format, dumpTruncate(n.Text, limit))
+	case CharData:
+		return fmt.Sprintf("%s %q", name, dumpTruncate(string(n), limit))
+	default:
+		return name
+	}
+}
+
+// dumpAttributes returns a compact description of the given attributes, in
+// a style similar to CSS selectors: "#id", ".class" and "[name=value]".
+func dumpAttributes(a *Attributes) string {
+	var parts []string
+	for _, id := range a.IDs {
+		parts = append(parts, "#"+id)
+	}
+	for _, class := range a.Classes {
+		parts = append(parts, "."+class)
+	}
+	for _, name := range a.Names {
+		parts = append(parts, fmt.Sprintf("[name=%q]", name))
+	}
+	extras := make([]string, 0, len(a.Extra))
+	for name := range a.Extra {
+		extras = append(extras, name)
+	}
+	sort.Strings(extras)
+	for _, name := range extras {
+		parts = append(parts, fmt.Sprintf("[%s=%q]", name, a.Extra[name]))
+	}
+	return strings.Join(parts, "")
+}
+
+func dumpTruncate(s string, limit int) string {
+	if limit == 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
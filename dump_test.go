@@ -0,0 +1,147 @@
+package rst
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpTree(t *testing.T) {
+	inputPath := filepath.Join("testdata", "dump", "nested.rst")
+	wantPath := filepath.Join("testdata", "dump", "nested.txt")
+
+	src, err := ioutil.ReadFile(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragment := ParseFragmentBytes(src, "nested.rst")
+
+	var buf bytes.Buffer
+	if err := DumpTree(&buf, fragment); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	if *updateGolden {
+		if err := ioutil.WriteFile(wantPath, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(wantPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := diffLines(string(want), got); diff != "" {
+		t.Errorf("dump does not match %s\n%s", wantPath, diff)
+	}
+}
+
+func TestDumpDOT(t *testing.T) {
+	fragment := ParseFragmentString("* a \"quoted\" item", "test.rst")
+
+	var buf bytes.Buffer
+	if err := DumpDOT(&buf, fragment); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		`digraph rst {`,
+		`  node [shape=box, fontname=monospace];`,
+		`  n0 [label="Fragment @1:1 \"test.rst\""];`,
+		`  n1 [label="BulletList @1:1 \"*\""];`,
+		`  n0 -> n1;`,
+		`  n2 [label="ListItem @1:1"];`,
+		`  n1 -> n2;`,
+		`  n3 [label="Paragraph @1:3"];`,
+		`  n2 -> n3;`,
+		`  n4 [label="CharData \"a \\\"quoted\\\" item\""];`,
+		`  n3 -> n4;`,
+		`}`,
+		``,
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDumpString(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Filename: "test.rst"}
+	long := strings.Repeat("long text ", 10)
+	doc := &Document{
+		Title:    Text{CharData("Title")},
+		Subtitle: Text{CharData("Subtitle")},
+		Decoration: &Decoration{
+			Footer: Body{&Paragraph{Text: Text{CharData("footer")}, Pos: pos}},
+			Pos:    pos,
+		},
+		DocInfo: &DocInfo{
+			Authors: []Text{{CharData("A")}, {CharData("B")}},
+			Version: Text{CharData("1.0")},
+			Fields: []*DocInfoField{
+				{
+					Name: Text{CharData("Extra")},
+					Body: Body{&Paragraph{Text: Text{CharData("value")}, Pos: pos}},
+					Pos:  pos,
+				},
+			},
+			Pos: pos,
+		},
+		Body: Body{
+			&BlockQuote{
+				Quote:       Body{&Paragraph{Text: Text{CharData(long)}, Pos: pos}},
+				Attribution: Text{CharData("someone")},
+				Pos:         pos,
+			},
+			&Error{Message: "oops", Skipped: long, Pos: pos},
+		},
+		Pos: pos,
+	}
+
+	want := strings.Join([]string{
+		`Document @1:1`,
+		`  Title`,
+		`    CharData "Title"`,
+		`  Subtitle`,
+		`    CharData "Subtitle"`,
+		`  Decoration @1:1 header=0 footer=1`,
+		`    Footer`,
+		`      Paragraph @1:1`,
+		`        CharData "footer"`,
+		`  DocInfo @1:1`,
+		`    Authors`,
+		`      CharData "A"`,
+		`    Authors`,
+		`      CharData "B"`,
+		`    Version`,
+		`      CharData "1.0"`,
+		`    DocInfoField @1:1`,
+		`      Name`,
+		`        CharData "Extra"`,
+		`      Paragraph @1:1`,
+		`        CharData "value"`,
+		`  BlockQuote @1:1`,
+		`    Paragraph @1:1`,
+		`      CharData "` + long + `"`,
+		`    Attribution`,
+		`      CharData "someone"`,
+		`  Error @1:1 error "oops"`,
+		`    Skipped "` + long + `"`,
+		``,
+	}, "\n")
+	if diff := diffLines(want, DumpString(doc)); diff != "" {
+		t.Errorf("wrong output\n%s", diff)
+	}
+
+	// DumpTree produces the same outline with the text truncated.
+	var buf bytes.Buffer
+	if err := DumpTree(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Contains(got, long) || !strings.Contains(got, `"long text long text long text long text…"`) {
+		t.Errorf("DumpTree did not truncate text\n%s", got)
+	}
+}
@@ -0,0 +1,181 @@
+package rst
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// needsDecoding returns true if the options call for the source to be
+// converted to UTF-8 before it is scanned.
+func (o *Options) needsDecoding() bool {
+	if o.Decoder != nil {
+		return true
+	}
+	switch normalizeEncoding(o.Encoding) {
+	case "", "utf8":
+		return false
+	default:
+		return true
+	}
+}
+
+// decodeReader returns a reader that produces the content of r converted
+// to UTF-8 as the options specify.
+//
+// If the options name an encoding that isn't supported then the resulting
+// reader fails on its first read, so that the problem is reported in the
+// same way as any other failure to read the source.
+func (o *Options) decodeReader(r io.Reader) io.Reader {
+	name := normalizeEncoding(o.Encoding)
+	if name == "auto" {
+		br := bufio.NewReader(r)
+		bom, _ := br.Peek(3)
+		switch {
+		case bytes.HasPrefix(bom, []byte{0xEF, 0xBB, 0xBF}):
+			br.Discard(3)
+			return br
+		case bytes.HasPrefix(bom, []byte{0xFF, 0xFE}):
+			br.Discard(2)
+			return newDecodingReader(br, decodeUTF16(binary.LittleEndian))
+		case bytes.HasPrefix(bom, []byte{0xFE, 0xFF}):
+			br.Discard(2)
+			return newDecodingReader(br, decodeUTF16(binary.BigEndian))
+		}
+		if o.Decoder != nil {
+			return o.Decoder(br)
+		}
+		return br
+	}
+
+	if o.Decoder != nil {
+		return o.Decoder(r)
+	}
+	switch name {
+	case "", "utf8":
+		return r
+	case "latin1", "iso88591":
+		return newDecodingReader(r, decodeLatin1)
+	case "windows1252", "cp1252":
+		return newDecodingReader(r, decodeWindows1252)
+	default:
+		return &errReader{fmt.Errorf("unsupported encoding %q", o.Encoding)}
+	}
+}
+
+// normalizeEncoding returns the given encoding name in lowercase without
+// any hyphens or underscores, so that the common spellings of each name
+// are all accepted.
+func normalizeEncoding(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer("-", "", "_", "").Replace(name)
+}
+
+// decodingReader is an io.Reader that converts the bytes read from another
+// reader to UTF-8, one character at a time.
+type decodingReader struct {
+	r io.Reader
+
+	// decode returns the first character in the given bytes and the
+	// number of bytes it occupies, or zero if more bytes are needed to
+	// decide. When atEOF is set there are no more bytes to come, and so it
+	// must make progress.
+	decode func(p []byte, atEOF bool) (rune, int)
+
+	in  []byte
+	out bytes.Buffer
+	err error
+}
+
+func newDecodingReader(r io.Reader, decode func(p []byte, atEOF bool) (rune, int)) *decodingReader {
+	return &decodingReader{
+		r:      r,
+		decode: decode,
+	}
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	var buf [4096]byte
+	for d.out.Len() == 0 && d.err == nil {
+		n, err := d.r.Read(buf[:])
+		d.in = append(d.in, buf[:n]...)
+		d.err = err
+
+		consumed := 0
+		for consumed < len(d.in) {
+			r, size := d.decode(d.in[consumed:], err != nil)
+			if size == 0 {
+				break
+			}
+			d.out.WriteRune(r)
+			consumed += size
+		}
+		d.in = append(d.in[:0], d.in[consumed:]...)
+	}
+	if d.out.Len() == 0 {
+		return 0, d.err
+	}
+	return d.out.Read(p)
+}
+
+func decodeLatin1(p []byte, atEOF bool) (rune, int) {
+	return rune(p[0]), 1
+}
+
+func decodeWindows1252(p []byte, atEOF bool) (rune, int) {
+	if c := p[0]; c >= 0x80 && c < 0xA0 {
+		return windows1252[c-0x80], 1
+	}
+	return rune(p[0]), 1
+}
+
+// windows1252 gives the characters for the bytes 0x80 through 0x9F, where
+// Windows-1252 differs from Latin-1. The five bytes that Windows-1252 does
+// not define are mapped to the corresponding control characters, as web
+// browsers do.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+func decodeUTF16(order binary.ByteOrder) func(p []byte, atEOF bool) (rune, int) {
+	return func(p []byte, atEOF bool) (rune, int) {
+		if len(p) < 2 {
+			if atEOF {
+				return utf8.RuneError, len(p)
+			}
+			return 0, 0
+		}
+		r1 := rune(order.Uint16(p))
+		if !utf16.IsSurrogate(r1) {
+			return r1, 2
+		}
+		if len(p) < 4 {
+			if atEOF {
+				return utf8.RuneError, 2
+			}
+			return 0, 0
+		}
+		r := utf16.DecodeRune(r1, rune(order.Uint16(p[2:])))
+		if r == utf8.RuneError {
+			return r, 2
+		}
+		return r, 4
+	}
+}
+
+// errReader is an io.Reader that always fails with the same error.
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
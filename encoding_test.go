@@ -0,0 +1,150 @@
+package rst
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseFragmentEncoding(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("testdata", "encoding", "latin1.rst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"latin-1", "Latin1", "ISO-8859-1", "iso_8859_1"} {
+		frag, err := ParseFragmentErr(bytes.NewReader(src), "latin1.rst", Options{
+			Encoding:   name,
+			KeepSource: true,
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		if got, want := frag.Body[0].(*Paragraph).Text.String(), "Café au lait."; got != want {
+			t.Errorf("%s: wrong paragraph %q; want %q", name, got, want)
+		}
+		item := frag.Body[1].(*BulletList).Items[0]
+		if got, want := item.Body[0].(*Paragraph).Text.String(), "naïve"; got != want {
+			t.Errorf("%s: wrong list item %q; want %q", name, got, want)
+		}
+
+		// The source is kept as it was after conversion, so that
+		// positions in the tree refer to it.
+		if got, want := frag.Source.Line(1), "Café au lait."; got != want {
+			t.Errorf("%s: wrong source line %q; want %q", name, got, want)
+		}
+	}
+
+	// The string variant converts too, rather than slicing the source.
+	frag := ParseFragmentBytes(src, "latin1.rst", Options{Encoding: "latin-1"})
+	if got, want := frag.Body[0].(*Paragraph).Text.String(), "Café au lait."; got != want {
+		t.Errorf("wrong paragraph from bytes %q; want %q", got, want)
+	}
+
+	// Without the option the source is taken to be UTF-8, which it isn't.
+	frag = ParseFragmentBytes(src, "latin1.rst")
+	if got := frag.Body[0].(*Paragraph).Text.String(); got == "Café au lait." {
+		t.Errorf("source decoded as latin-1 without Encoding")
+	}
+}
+
+func TestParseFragmentEncodingWindows1252(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("testdata", "encoding", "windows1252.rst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The reader returns one byte at a time, to check that characters
+	// split across reads are converted correctly.
+	frag, err := ParseFragmentErr(iotest.OneByteReader(bytes.NewReader(src)), "windows1252.rst", Options{
+		Encoding: "cp1252",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := frag.Body[0].(*Paragraph).Text.String(), "“Quoted” € text"; got != want {
+		t.Errorf("wrong paragraph %q; want %q", got, want)
+	}
+}
+
+func TestParseFragmentEncodingAuto(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Src     string
+		Decoder func(io.Reader) io.Reader
+		Want    string
+	}{
+		{
+			"no BOM",
+			"café",
+			nil,
+			"café",
+		},
+		{
+			"UTF-8 BOM",
+			"\xEF\xBB\xBFcafé",
+			nil,
+			"café",
+		},
+		{
+			"UTF-16LE BOM",
+			"\xFF\xFEc\x00a\x00f\x00\xE9\x00=\xD8\x00\xDE",
+			nil,
+			"café😀",
+		},
+		{
+			"UTF-16BE BOM",
+			"\xFE\xFF\x00c\x00a\x00f\x00\xE9",
+			nil,
+			"café",
+		},
+		{
+			"BOM overrides decoder",
+			"\xEF\xBB\xBFcafé",
+			func(r io.Reader) io.Reader {
+				return newDecodingReader(r, decodeLatin1)
+			},
+			"café",
+		},
+		{
+			"decoder without BOM",
+			"caf\xE9",
+			func(r io.Reader) io.Reader {
+				return newDecodingReader(r, decodeLatin1)
+			},
+			"café",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			frag, err := ParseFragmentErr(strings.NewReader(test.Src), "test.rst", Options{
+				Encoding: "auto",
+				Decoder:  test.Decoder,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := frag.Body[0].(*Paragraph).Text.String(); got != test.Want {
+				t.Errorf("wrong paragraph %q; want %q", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestParseFragmentEncodingUnsupported(t *testing.T) {
+	_, err := ParseFragmentErr(strings.NewReader("a\n"), "test.rst", Options{Encoding: "ebcdic"})
+	if err == nil || err.Error() != `unsupported encoding "ebcdic"` {
+		t.Errorf("wrong error %v", err)
+	}
+
+	// Without a way to return an error, it is recorded in the tree.
+	frag := ParseFragmentString("a\n", "test.rst", Options{Encoding: "ebcdic"})
+	if errs := AllErrors(frag); len(errs) != 1 {
+		t.Errorf("wrong errors %#v", errs)
+	}
+}
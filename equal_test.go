@@ -0,0 +1,64 @@
+package rst
+
+import (
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		A, B interface{}
+		Want bool
+	}{
+		{
+			&Fragment{},
+			&Fragment{Body: Body{}},
+			true,
+		},
+		{
+			&Fragment{Body: Body{}},
+			&Fragment{Body: Body{&Paragraph{}}},
+			false,
+		},
+		{
+			&ListItem{Body: nil},
+			&ListItem{Body: Body{}},
+			true,
+		},
+		{
+			&Paragraph{Text: Text{CharData("a")}},
+			&Paragraph{Text: Text{CharData("a")}},
+			true,
+		},
+		{
+			&Paragraph{Text: Text{CharData("a")}},
+			&Paragraph{Text: Text{CharData("b")}},
+			false,
+		},
+		{
+			&Paragraph{},
+			&BlockQuote{},
+			false,
+		},
+		{
+			Body{&Paragraph{Pos: Position{Line: 1}}},
+			Body{&Paragraph{Pos: Position{Line: 2}}},
+			false,
+		},
+		{
+			nil,
+			nil,
+			true,
+		},
+		{
+			nil,
+			&Fragment{},
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		if got := Equal(test.A, test.B); got != test.Want {
+			t.Errorf("Equal(%#v, %#v) = %v; want %v", test.A, test.B, got, test.Want)
+		}
+	}
+}
@@ -1,22 +1,101 @@
 package rst
 
+import (
+	"errors"
+	"strings"
+)
+
 // Error is an element that can appear in structural, body and inline context
 // which replaces an element that failed to parse correctly for some reason,
 // giving some context about what failed.
 type Error struct {
-	Message string
-	Pos     Position
+	Message  string
+	Pos      Position
+	Severity Severity
+
+	// End is the position just after the end of the source text that the
+	// error relates to, if the parser knows its extent, or the zero
+	// Position otherwise. See also Range.
+	End Position
+
+	// Skipped is the source text, if any, that the parser discarded while
+	// recovering from the problem.
+	Skipped string
+
+	// Source is the full text of the source line at Pos, if known, for
+	// use in showing the problem to the user. See also Excerpt.
+	Source string
+
+	// Found is the type of the token the parser encountered when it
+	// detected the problem, or INVALID if the problem was not caused by
+	// an unexpected token.
+	Found TokenType
+
+	// Expected describes what the parser was expecting to find instead,
+	// if anything in particular.
+	Expected string
+
 	bodyElementImpl
+	Attributes
 }
 
 func (e *Error) Error() string {
 	return e.Message
 }
 
+// Excerpt returns the source line at the position of the error followed by
+// a second line with a caret marking the error's column, or an empty string
+// if the source line is not known.
+//
+// Tabs in the source line are expanded to 8-column tab stops so that the
+// caret lines up with the column number.
+func (e *Error) Excerpt() string {
+	if e.Source == "" {
+		return ""
+	}
+	line := expandTabs(e.Source)
+	col := e.Pos.Column - 1
+	if col < 0 {
+		col = 0
+	}
+	if col > len(line) {
+		col = len(line)
+	}
+	return line + "\n" + strings.Repeat(" ", col) + "^"
+}
+
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var buf strings.Builder
+	col := 0
+	for _, r := range s {
+		if r == '\t' {
+			n := 8 - (col % 8)
+			buf.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		buf.WriteRune(r)
+		col++
+	}
+	return buf.String()
+}
+
 func (e *Error) Position() Position {
 	return e.Pos
 }
 
+// Range returns the span of source text that the error relates to, which
+// is a single point at Pos if its extent is not known.
+func (e *Error) Range() Range {
+	if e.End == (Position{}) {
+		return Range{Start: e.Pos, End: e.Pos}
+	}
+	return Range{Start: e.Pos, End: e.End}
+}
+
 func (e *Error) StructureChildElements() Structure {
 	return nil
 }
@@ -24,3 +103,97 @@ func (e *Error) StructureChildElements() Structure {
 func (e *Error) InlineChildNodes() Text {
 	return nil
 }
+
+// Severity indicates how serious a problem reported by an Error is.
+//
+// The zero value is SeverityError, so that Error elements created without
+// an explicit severity are treated as errors.
+type Severity int
+
+const (
+	// SeverityInfo marks a note about how the parser interpreted some
+	// markup that the author might have intended differently, which is
+	// not necessarily a mistake.
+	SeverityInfo Severity = iota - 2
+
+	// SeverityWarning marks a problem that did not prevent parsing but
+	// that probably indicates a mistake in the source document.
+	SeverityWarning
+
+	// SeverityError marks a problem that caused some markup to be
+	// misinterpreted or discarded.
+	SeverityError
+)
+
+// docutilsLevel returns the docutils system message level and type that
+// correspond to the given severity.
+func docutilsLevel(severity Severity) (int, string) {
+	switch {
+	case severity <= SeverityInfo:
+		return 1, "INFO"
+	case severity == SeverityWarning:
+		return 2, "WARNING"
+	default:
+		return 3, "ERROR"
+	}
+}
+
+// AllErrors returns all of the Error elements within the given node and
+// its descendents, in source order.
+//
+// The node may be any value accepted by Walk. If node is itself an Error
+// then the result contains only that error.
+func AllErrors(node interface{}) []*Error {
+	var errs []*Error
+	Walk(node, func(node interface{}) bool {
+		if err, ok := node.(*Error); ok {
+			errs = append(errs, err)
+		}
+		return true
+	})
+	return errs
+}
+
+// AllErrors returns all of the Error elements within the fragment, in
+// source order.
+func (f *Fragment) AllErrors() []*Error {
+	return AllErrors(f)
+}
+
+// AllErrors returns all of the Error elements within the document, in
+// source order.
+func (d *Document) AllErrors() []*Error {
+	return AllErrors(d)
+}
+
+// ErrorsAsError returns a Go error describing all of the Error elements
+// within the given node whose severity is at least min, or nil if there
+// are none.
+//
+// The result joins the errors with errors.Join, each wrapped so that its
+// message is prefixed with its position. errors.As can extract the first
+// of them as an *Error, and the Unwrap method of the result gives access
+// to all of them.
+func ErrorsAsError(node interface{}, min Severity) error {
+	var errs []error
+	for _, err := range AllErrors(node) {
+		if err.Severity >= min {
+			errs = append(errs, positionedError{err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// positionedError wraps an *Error so that its message includes its
+// position, as is conventional for errors outside of the tree.
+type positionedError struct {
+	err *Error
+}
+
+func (e positionedError) Error() string {
+	return e.err.Pos.String() + ": " + e.err.Message
+}
+
+func (e positionedError) Unwrap() error {
+	return e.err
+}
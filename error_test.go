@@ -0,0 +1,133 @@
+package rst
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorExcerpt(t *testing.T) {
+	tests := []struct {
+		Error *Error
+		Want  string
+	}{
+		{
+			&Error{
+				Pos: Position{Line: 1, Column: 5},
+			},
+			"",
+		},
+		{
+			&Error{
+				Pos:    Position{Line: 1, Column: 5},
+				Source: "    literal",
+			},
+			"    literal\n    ^",
+		},
+		{
+			&Error{
+				Pos:    Position{Line: 1, Column: 9},
+				Source: "\tnested",
+			},
+			"        nested\n        ^",
+		},
+		{
+			&Error{
+				Pos:    Position{Line: 1, Column: 20},
+				Source: "short",
+			},
+			"short\n     ^",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Want, func(t *testing.T) {
+			got := test.Error.Excerpt()
+			if got != test.Want {
+				t.Errorf("wrong excerpt\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	src := "    quote\n\n    -- someone\n\nafter\n"
+	errs := ParseFragmentString(src, "test.rst").AllErrors()
+	if len(errs) == 0 {
+		t.Fatal("no errors")
+	}
+
+	got := errs[0]
+	if got.Message != "missing dedent after attribution" {
+		t.Fatalf("wrong first error %q", got.Message)
+	}
+	if got.Found != BLANK {
+		t.Errorf("wrong Found %s; want BLANK", got.Found)
+	}
+	if got.Expected != "DEDENT" {
+		t.Errorf("wrong Expected %q; want \"DEDENT\"", got.Expected)
+	}
+	if want := "    -- someone\n    ^"; got.Excerpt() != want {
+		t.Errorf("wrong excerpt\ngot:\n%s\nwant:\n%s", got.Excerpt(), want)
+	}
+}
+
+func TestErrorsAsError(t *testing.T) {
+	body := Body{
+		&Paragraph{Text: Text{CharData("fine")}},
+		&Error{
+			Message:  "tabs",
+			Pos:      Position{Line: 2, Column: 1, Filename: "a.rst"},
+			Severity: SeverityWarning,
+		},
+		&BlockQuote{
+			Quote: Body{
+				&Error{
+					Message: "first",
+					Pos:     Position{Line: 3, Column: 3, Filename: "a.rst"},
+				},
+			},
+		},
+		&Error{
+			Message: "second",
+			Pos:     Position{Line: 5, Column: 1, Filename: "a.rst"},
+		},
+	}
+
+	if err := ErrorsAsError(Body{&Paragraph{}}, SeverityWarning); err != nil {
+		t.Errorf("unexpected error for tree without errors: %s", err)
+	}
+
+	err := ErrorsAsError(body, SeverityError)
+	if err == nil {
+		t.Fatalf("no error")
+	}
+	if got, want := err.Error(), "a.rst:3:3: first\na.rst:5:1: second"; got != want {
+		t.Errorf("wrong message\ngot:  %q\nwant: %q", got, want)
+	}
+
+	var first *Error
+	if !errors.As(err, &first) {
+		t.Fatalf("errors.As did not find an *Error")
+	}
+	if got, want := first.Pos, (Position{Line: 3, Column: 3, Filename: "a.rst"}); got != want {
+		t.Errorf("wrong position %s; want %s", got, want)
+	}
+
+	var positions []string
+	for _, wrapped := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var rstErr *Error
+		if !errors.As(wrapped, &rstErr) {
+			t.Fatalf("errors.As did not find an *Error in %#v", wrapped)
+		}
+		positions = append(positions, rstErr.Pos.String())
+	}
+	if got, want := strings.Join(positions, " "), "a.rst:3:3 a.rst:5:1"; got != want {
+		t.Errorf("wrong positions %q; want %q", got, want)
+	}
+
+	err = ErrorsAsError(body, SeverityWarning)
+	if got, want := strings.Count(err.Error(), "\n"), 2; got != want {
+		t.Errorf("got %d newlines with warnings included; want %d\n%s", got, want, err)
+	}
+}
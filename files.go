@@ -0,0 +1,130 @@
+package rst
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ParseFiles parses each of the named files as a fragment, using a bounded
+// number of concurrent workers, and returns the resulting fragments keyed
+// by filename.
+//
+// A failure to read one file does not prevent the others from being parsed.
+// Instead, any such failures are collected into a *FileErrors value that is
+// returned alongside the fragments for the files that were parsed
+// successfully.
+//
+// If the given context is cancelled then any files not yet parsed are
+// skipped, and the context's error is recorded against each of them.
+//
+// At most one Options value may be given, as with ParseFragment.
+func ParseFiles(ctx context.Context, filenames []string, opts ...Options) (map[string]*Fragment, error) {
+	p := &Parser{Options: optionsArg(opts)}
+	return p.ParseFiles(ctx, filenames)
+}
+
+// ParseFiles is the Parser equivalent of the package-level function of
+// the same name.
+func (pp *Parser) ParseFiles(ctx context.Context, filenames []string) (map[string]*Fragment, error) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(filenames) {
+		workers = len(filenames)
+	}
+
+	type result struct {
+		filename string
+		fragment *Fragment
+		err      error
+	}
+
+	jobs := make(chan string)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for filename := range jobs {
+				fragment, err := pp.parseFile(ctx, filename)
+				results <- result{filename, fragment, err}
+			}
+		}()
+	}
+	go func() {
+		for _, filename := range filenames {
+			jobs <- filename
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	fragments := make(map[string]*Fragment, len(filenames))
+	var errs FileErrors
+	for r := range results {
+		if r.err != nil {
+			errs = append(errs, &FileError{Filename: r.filename, Err: r.err})
+			continue
+		}
+		fragments[r.filename] = r.fragment
+	}
+
+	if len(errs) != 0 {
+		return fragments, errs
+	}
+	return fragments, nil
+}
+
+func (pp *Parser) parseFile(ctx context.Context, filename string) (*Fragment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return pp.ParseFragmentContext(ctx, f, filename)
+}
+
+// FileError describes a failure to parse one of the files given to
+// ParseFiles.
+type FileError struct {
+	Filename string
+	Err      error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Filename, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// FileErrors is the error type returned by ParseFiles when one or more of
+// the files could not be parsed. The order of the errors is not defined.
+type FileErrors []*FileError
+
+func (e FileErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the individual errors, for use with errors.Is and
+// errors.As.
+func (e FileErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,70 @@
+package rst
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseFiles parses the golden test corpus concurrently and checks that
+// the results match parsing each file alone. Run with -race to check that
+// concurrent parsers share no mutable state.
+func TestParseFiles(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "golden", "*.rst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no test inputs found")
+	}
+
+	// Parse each file several times over so that there's plenty of
+	// opportunity for concurrent parsers to interfere with one another.
+	var filenames []string
+	for i := 0; i < 8; i++ {
+		filenames = append(filenames, inputs...)
+	}
+	missing := filepath.Join("testdata", "golden", "does-not-exist.rst")
+	filenames = append(filenames, missing)
+
+	got, err := ParseFiles(context.Background(), filenames)
+
+	var fileErrs FileErrors
+	if !errors.As(err, &fileErrs) {
+		t.Fatalf("wrong error %#v; want FileErrors", err)
+	}
+	if len(fileErrs) != 1 || fileErrs[0].Filename != missing || !errors.Is(fileErrs[0], os.ErrNotExist) {
+		t.Errorf("wrong errors: %s", err)
+	}
+
+	if len(got) != len(inputs) {
+		t.Errorf("got %d fragments; want %d", len(got), len(inputs))
+	}
+	for _, filename := range inputs {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := ParseFragmentBytes(src, filename)
+		if diff := Diff(want, got[filename]); diff != "" {
+			t.Errorf("wrong result for %s\n%s", filename, diff)
+		}
+	}
+}
+
+func TestParseFilesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	filename := filepath.Join("testdata", "golden", "block-quote.rst")
+	got, err := ParseFiles(ctx, []string{filename})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong error %#v; want context.Canceled", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("unexpected fragments: %#v", got)
+	}
+}
@@ -4,4 +4,24 @@ type Fragment struct {
 	Body Body
 
 	ChildElements Structure
+
+	Pos Position
+
+	// SourceName is the filename that was given to the parser for the
+	// source of the fragment. For a fragment produced by MergeFragments it
+	// is that of the first of the merged fragments.
+	SourceName string
+
+	// SourceNames lists the source names of each of the fragments that
+	// were combined by MergeFragments to produce this one, in order, or
+	// is nil for a fragment from a single source.
+	SourceNames []string
+
+	// Source is the text from which the fragment was parsed, if the parser
+	// was asked to retain it using Options.KeepSource, or nil otherwise.
+	Source *Source
+}
+
+func (f *Fragment) Position() Position {
+	return f.Pos
 }
@@ -0,0 +1,88 @@
+package rst
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// fuzzSeeds are the initial corpus for the fuzz tests, drawn from the inputs
+// used by the other tests.
+var fuzzSeeds = []string{
+	"",
+	"\n",
+	"hello\nworld",
+	"hello\n    world\n    foo\nbaz",
+	"* foo",
+	"* foo\n* bar",
+	"*",
+	"1. foo\n2. bar\n(3) baz\n(5) pizza\n6) cheese",
+	"9. foo\n10. bar",
+	"    blockquote\n    baz",
+	"    nested-blockquote\n  baz",
+	"    quote\n\n    -- attribution",
+	"    quote\n\tnested",
+	"before::\n\n    literal 1\n    literal 2\n\nafter",
+	"- push-indent\n  a\n\n:lazy-indent:\n    b",
+}
+
+func FuzzParseFragment(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		done := make(chan *Fragment)
+		go func() {
+			done <- ParseFragmentString(src, "fuzz.rst")
+		}()
+
+		select {
+		case fragment := <-done:
+			if fragment == nil {
+				t.Fatalf("ParseFragmentString returned nil")
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("parser did not terminate")
+		}
+	})
+}
+
+// FuzzParseFragmentString checks that parsing from a string, which slices
+// the tree's text from the source, produces the same tree as parsing the
+// same source from a reader.
+func FuzzParseFragmentString(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		want := ParseFragment(strings.NewReader(src), "fuzz.rst")
+		got := ParseFragmentString(src, "fuzz.rst")
+		if diff := Diff(want, got); diff != "" {
+			t.Fatalf("string and reader results differ\n%s", diff)
+		}
+	})
+}
+
+func FuzzScanner(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		scanner := NewScanner(strings.NewReader(src), "fuzz.rst")
+
+		// Each line can produce at most one token of its own plus one
+		// indentation token, and there can be one DEDENT for each
+		// line at the end, so this is a generous bound.
+		maxTokens := 3*(strings.Count(src, "\n")+1) + 1
+		for i := 0; i < maxTokens; i++ {
+			token := scanner.Read()
+			if token.Type == EOF || token.Type == ERROR {
+				return
+			}
+		}
+		t.Fatalf("scanner produced more than %d tokens", maxTokens)
+	})
+}
@@ -0,0 +1,89 @@
+package rst
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+// TestGolden parses each of the testdata/golden/*.rst files and compares
+// a dump of the resulting tree with the corresponding .tree file.
+//
+// Run the tests with -update to regenerate the .tree files after an
+// intentional change to the parser's output.
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "golden", "*.rst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no golden test inputs found")
+	}
+
+	for _, inputPath := range inputs {
+		name := strings.TrimSuffix(filepath.Base(inputPath), ".rst")
+		treePath := strings.TrimSuffix(inputPath, ".rst") + ".tree"
+
+		t.Run(name, func(t *testing.T) {
+			src, err := ioutil.ReadFile(inputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fragment := ParseFragmentBytes(src, filepath.Base(inputPath))
+			got := DumpString(fragment)
+
+			if *updateGolden {
+				err := ioutil.WriteFile(treePath, []byte(got), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := ioutil.ReadFile(treePath)
+			if err != nil {
+				t.Fatalf("%s (run with -update to create it)", err)
+			}
+
+			if diff := diffLines(string(want), got); diff != "" {
+				t.Errorf("tree does not match %s\n%s", treePath, diff)
+			}
+		})
+	}
+}
+
+// diffLines returns a description of the first difference between the two
+// given multi-line strings, or an empty string if they are equal.
+func diffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	i := 0
+	for i < len(wantLines) && i < len(gotLines) && wantLines[i] == gotLines[i] {
+		i++
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "first difference at line %d:\n", i+1)
+	for _, label := range []string{"want", "got"} {
+		lines := wantLines
+		if label == "got" {
+			lines = gotLines
+		}
+		for j := i; j < i+3 && j < len(lines); j++ {
+			fmt.Fprintf(&buf, "%-5s %4d: %s\n", label, j+1, lines[j])
+		}
+	}
+	return buf.String()
+}
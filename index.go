@@ -0,0 +1,104 @@
+package rst
+
+// IndexEntry describes one section of a document for the purposes of a
+// search index, as returned by ExtractIndex.
+type IndexEntry struct {
+	// Title is the title of the section. For the entry describing the
+	// content before the first section, this is the document title, if
+	// any.
+	Title Text
+
+	// ID is an anchor id for the section, derived from its title using
+	// the default settings of Anchors. It is empty for the entry that
+	// describes the content before the first section.
+	ID string
+
+	// Depth is the nesting depth of the section, starting at 1 for
+	// top-level sections. The entry for the content before the first
+	// section has depth zero.
+	Depth int
+
+	// Text is the plain text of the section's own body, as returned by
+	// PlainText. It does not include the text of any subsections, which
+	// have entries of their own.
+	Text string
+
+	Pos Position
+}
+
+// IndexOptions customizes the result of ExtractIndex.
+type IndexOptions struct {
+	// ExcludeLiteral leaves the text of literal blocks out of the Text of
+	// each entry, since code samples are often noise in a search index.
+	ExcludeLiteral bool
+}
+
+// ExtractIndex returns one IndexEntry for each section in the given
+// *Document or *Fragment, in document order, for building a search index.
+//
+// If there is any content before the first section, or if a document has
+// a title, the result begins with an additional entry of depth zero
+// describing that content.
+//
+// Entries are given unique ids within the result.
+//
+// At most one IndexOptions value may be given. If it is omitted then the
+// default options are used.
+func ExtractIndex(node interface{}, opts ...IndexOptions) []IndexEntry {
+	var opt IndexOptions
+	switch len(opts) {
+	case 0:
+	case 1:
+		opt = opts[0]
+	default:
+		panic("at most one IndexOptions value may be given")
+	}
+	text := func(body Body) string {
+		return plainText(body, opt.ExcludeLiteral)
+	}
+
+	var entries []IndexEntry
+	var anchors Anchors
+
+	var visit func(structure Structure, depth int)
+	visit = func(structure Structure, depth int) {
+		for _, elem := range structure {
+			section, ok := elem.(*Section)
+			if !ok {
+				continue
+			}
+
+			id := anchors.ID(section.Title)
+			entries = append(entries, IndexEntry{
+				Title: section.Title,
+				ID:    id,
+				Depth: depth,
+				Text:  text(section.Body),
+				Pos:   section.Pos,
+			})
+			visit(section.ChildElements, depth+1)
+		}
+	}
+
+	switch n := node.(type) {
+	case *Document:
+		if len(n.Title) != 0 || len(n.Body) != 0 {
+			entries = append(entries, IndexEntry{
+				Title: n.Title,
+				Text:  text(n.Body),
+				Pos:   n.Pos,
+			})
+		}
+		visit(n.ChildElements, 1)
+	case *Fragment:
+		if len(n.Body) != 0 {
+			entries = append(entries, IndexEntry{
+				Text: text(n.Body),
+				Pos:  n.Pos,
+			})
+		}
+		visit(n.ChildElements, 1)
+	}
+
+	return entries
+}
@@ -0,0 +1,65 @@
+package rst
+
+import (
+	"testing"
+)
+
+func TestExtractIndex(t *testing.T) {
+	para := func(s string) *Paragraph {
+		return &Paragraph{Text: Text{CharData(s)}}
+	}
+	doc := &Document{
+		Title: Text{CharData("Guide")},
+		Body:  Body{para("intro")},
+		ChildElements: Structure{
+			&Section{
+				Title: Text{CharData("Getting Started")},
+				Body:  Body{para("first"), para("second")},
+				ChildElements: Structure{
+					&Section{
+						Title: Text{CharData("Install")},
+						Body:  Body{para("install text")},
+					},
+				},
+			},
+			&Transition{},
+			&Section{
+				Title: Text{CharData("Install")},
+				Body:  Body{para("again")},
+			},
+			&Section{
+				Title: Text{CharData("???")},
+			},
+		},
+	}
+
+	got := ExtractIndex(doc)
+	want := []IndexEntry{
+		{Title: Text{CharData("Guide")}, Depth: 0, Text: "intro"},
+		{Title: Text{CharData("Getting Started")}, ID: "getting-started", Depth: 1, Text: "first\nsecond"},
+		{Title: Text{CharData("Install")}, ID: "install", Depth: 2, Text: "install text"},
+		{Title: Text{CharData("Install")}, ID: "id1", Depth: 1, Text: "again"},
+		{Title: Text{CharData("???")}, ID: "id2", Depth: 1},
+	}
+	if diff := Diff(want, got); diff != "" {
+		t.Errorf("wrong index\n%s", diff)
+	}
+
+	if got := ExtractIndex(&Fragment{}); got != nil {
+		t.Errorf("wrong result for empty fragment: %#v", got)
+	}
+}
+
+func TestExtractIndexExcludeLiteral(t *testing.T) {
+	fragment := ParseFragmentString("Run this::\n\n    go-rst render\n\n- or this::\n\n    go-rst dump\n", "test.rst")
+
+	got := ExtractIndex(fragment)
+	if len(got) != 1 || got[0].Text != "Run this:\ngo-rst render\nor this:\ngo-rst dump" {
+		t.Fatalf("wrong index by default: %#v", got)
+	}
+
+	got = ExtractIndex(fragment, IndexOptions{ExcludeLiteral: true})
+	if len(got) != 1 || got[0].Text != "Run this:\nor this:" {
+		t.Errorf("wrong index with ExcludeLiteral: %#v", got)
+	}
+}
@@ -0,0 +1,38 @@
+package rst
+
+// Limits places bounds on the resources the scanner and parser will consume
+// when processing a document, which is useful when handling untrusted input.
+//
+// A zero value for any field means that the corresponding resource is not
+// limited, so the zero value of Limits imposes no limits at all.
+//
+// When a limit is exceeded the scanner produces an ERROR token describing
+// which limit was hit, and then continues to produce ERROR tokens as it
+// would for any other error. The parser therefore stops at that point,
+// leaving a single Error element at the end of the truncated tree to
+// explain which limit was hit.
+type Limits struct {
+	// MaxLineLength is the maximum length of a single line in bytes,
+	// excluding its line terminator and any trailing whitespace.
+	MaxLineLength int
+
+	// MaxIndentDepth is the maximum number of nested indentation levels,
+	// including the synthetic levels created by constructs such as list
+	// items.
+	MaxIndentDepth int
+
+	// MaxTokens is the maximum number of tokens the scanner will produce,
+	// not counting the final EOF or ERROR token.
+	MaxTokens int
+
+	// MaxElements is the maximum number of block-level elements, such as
+	// paragraphs, block quotes and lists, that the parser will produce.
+	// Each list counts as one element along with its first item, and each
+	// subsequent item counts as another. Inline elements are not counted.
+	MaxElements int
+
+	// MaxErrors is the maximum number of Error elements the parser will
+	// produce, not counting the final one that reports a limit has been
+	// exceeded.
+	MaxErrors int
+}
@@ -2,29 +2,67 @@ package rst
 
 import (
 	"bufio"
+	"strings"
 )
 
+// lineReader is the source of the lines that a Scanner tokenizes. It is
+// implemented by bufio.Scanner, using splitRSTLines, for input read from
+// an io.Reader, and by stringLines for input that is already in memory.
+type lineReader interface {
+	Scan() bool
+	Text() string
+	Err() error
+}
+
 // splitRSTLines is a SplitFunc for bufio.Scanner that frames "lines" from
-// an RST document. This is similar to the built-in ScanLines implementation,
-// but it additionally trims off trailing whitespace from lines.
+// an RST document. It behaves as the built-in ScanLines implementation,
+// keeping trailing whitespace because it is significant in literal blocks;
+// the Scanner trims it from all other lines.
 func splitRSTLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	advance, token, err = bufio.ScanLines(data, atEOF)
-
-	if token != nil {
-	Loop:
-		for {
-			if len(token) == 0 {
-				break
-			}
-
-			switch token[len(token)-1] {
-			case 8, 9, 32, 12, 11:
-				token = token[:len(token)-1]
-			default:
-				break Loop
-			}
-		}
+	return bufio.ScanLines(data, atEOF)
+}
+
+// stringLines is a lineReader that frames the same lines as splitRSTLines,
+// but from a string rather than a reader. Each line is a substring of the
+// original string rather than a copy, so that the tokens and elements
+// produced from it share the original's memory.
+type stringLines struct {
+	src  string
+	text string
+	err  error
+}
+
+func (l *stringLines) Scan() bool {
+	if l.err != nil || len(l.src) == 0 {
+		return false
+	}
+
+	// bufio.Scanner refuses lines that don't fit in its largest buffer,
+	// and so we must too in order to behave identically.
+	end := strings.IndexByte(l.src, '\n')
+	if (end < 0 && len(l.src) >= bufio.MaxScanTokenSize) || end >= bufio.MaxScanTokenSize {
+		l.err = bufio.ErrTooLong
+		l.text = ""
+		return false
+	}
+
+	var line string
+	if end < 0 {
+		line, l.src = l.src, ""
+	} else {
+		line, l.src = l.src[:end], l.src[end+1:]
+	}
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
 	}
+	l.text = line
+	return true
+}
+
+func (l *stringLines) Text() string {
+	return l.text
+}
 
-	return advance, token, err
+func (l *stringLines) Err() error {
+	return l.err
 }
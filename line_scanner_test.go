@@ -45,26 +45,33 @@ func TestSplitRSTLines(t *testing.T) {
 		{
 			"Hello \nWorld ",
 			[]string{
-				"Hello",
-				"World",
+				"Hello ",
+				"World ",
 			},
 		},
 		{
 			" Hello   \n World   ",
 			[]string{
-				" Hello",
-				" World",
+				" Hello   ",
+				" World   ",
 			},
 		},
 		{
 			"\tHello\t\n\tWorld\t",
 			[]string{
-				"\tHello",
-				"\tWorld",
+				"\tHello\t",
+				"\tWorld\t",
 			},
 		},
 		{
 			"Hello\v\f\nWorld\v\f",
+			[]string{
+				"Hello\v\f",
+				"World\v\f",
+			},
+		},
+		{
+			"Hello\r\nWorld\r\n",
 			[]string{
 				"Hello",
 				"World",
@@ -74,21 +81,75 @@ func TestSplitRSTLines(t *testing.T) {
 
 	for i, test := range tests {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
-			r := strings.NewReader(test.Input)
-			scanner := bufio.NewScanner(r)
-			scanner.Split(splitRSTLines)
-			got := make([]string, 0, len(test.Expected))
-			for scanner.Scan() {
-				got = append(got, scanner.Text())
+			for name, lines := range testLineReaders(test.Input) {
+				got := make([]string, 0, len(test.Expected))
+				for lines.Scan() {
+					got = append(got, lines.Text())
+				}
+				if lines.Err() != nil {
+					t.Fatalf("%s got error: %s", name, lines.Err())
+				}
+				if !reflect.DeepEqual(got, test.Expected) {
+					t.Errorf(
+						"incorrect %s output for %q\ngot:  %#v\nwant: %#v",
+						name, test.Input, got, test.Expected,
+					)
+				}
+			}
+		})
+	}
+}
+
+// testLineReaders returns each of the lineReader implementations, reading
+// the given input.
+func testLineReaders(input string) map[string]lineReader {
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	scanner.Split(splitRSTLines)
+	return map[string]lineReader{
+		"bufio":  scanner,
+		"string": &stringLines{src: input},
+	}
+}
+
+// TestStringLinesEquivalence checks that stringLines frames lines exactly
+// as bufio.Scanner does, including its refusal of overlong lines.
+func TestStringLinesEquivalence(t *testing.T) {
+	long := func(n int) string {
+		return strings.Repeat("x", n)
+	}
+	inputs := []string{
+		"a\r\nb\r\n",
+		"a\r\r\nb\r",
+		"a\n\n\n",
+		"\r",
+		"\n\n",
+		" \t\n",
+		long(bufio.MaxScanTokenSize-2) + "\n" + "b",
+		long(bufio.MaxScanTokenSize-1) + "\n" + "b",
+		long(bufio.MaxScanTokenSize) + "\n" + "b",
+		"a\n" + long(bufio.MaxScanTokenSize-1),
+		"a\n" + long(bufio.MaxScanTokenSize),
+		"a\n" + long(bufio.MaxScanTokenSize+1),
+	}
+
+	for i, input := range inputs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var results [][]string
+			var errs []error
+			for _, name := range []string{"bufio", "string"} {
+				lines := testLineReaders(input)[name]
+				var got []string
+				for lines.Scan() {
+					got = append(got, lines.Text())
+				}
+				results = append(results, got)
+				errs = append(errs, lines.Err())
 			}
-			if scanner.Err() != nil {
-				t.Fatalf("got error: %s", scanner.Err())
+			if !reflect.DeepEqual(results[0], results[1]) {
+				t.Errorf("different lines\nbufio:  %q\nstring: %q", results[0], results[1])
 			}
-			if !reflect.DeepEqual(got, test.Expected) {
-				t.Errorf(
-					"incorrect output for %q\ngot:  %#v\nwant: %#v",
-					test.Input, got, test.Expected,
-				)
+			if errs[0] != errs[1] {
+				t.Errorf("different errors\nbufio:  %v\nstring: %v", errs[0], errs[1])
 			}
 		})
 	}
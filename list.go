@@ -2,20 +2,43 @@ package rst
 
 type BulletList struct {
 	bodyElementImpl
+	Attributes
+
+	// Bullet is the bullet character used to mark each item in the
+	// source, such as "*" or "-".
+	Bullet string
+
 	Items []*ListItem
+	Pos   Position
+}
+
+func (l *BulletList) Position() Position {
+	return l.Pos
 }
 
 type EnumeratedList struct {
 	bodyElementImpl
+	Attributes
 	EnumType   EnumType
 	EnumPrefix string
 	EnumSuffix string
 	FirstIndex int
 	Items      []*ListItem
+	Pos        Position
+}
+
+func (l *EnumeratedList) Position() Position {
+	return l.Pos
 }
 
 type ListItem struct {
+	Attributes
 	Body
+	Pos Position
+}
+
+func (i *ListItem) Position() Position {
+	return i.Pos
 }
 
 type EnumType string
@@ -0,0 +1,26 @@
+package rst
+
+// A LiteralBlock is a block of text that is presented exactly as written,
+// typically in a monospace font, such as a code sample. It is introduced
+// by a paragraph ending with "::" and consists of the indented lines that
+// follow it:
+//
+//	An example::
+//
+//	    for x in range(10):
+//	        print(x)
+type LiteralBlock struct {
+	bodyElementImpl
+	Attributes
+
+	// Text is the content of the block, with the indentation that is
+	// common to all of its lines removed. Lines are separated by
+	// newlines, and blank lines within the block are preserved.
+	Text string
+
+	Pos Position
+}
+
+func (b *LiteralBlock) Position() Position {
+	return b.Pos
+}
@@ -0,0 +1,303 @@
+package rst
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteMarkdown writes the given node to w as CommonMark, for moving content
+// into systems that only understand Markdown.
+//
+// Sections become ATX headings, block quotes are prefixed with ">", and
+// lists become CommonMark lists, with enumerated lists keeping their starting
+// number. CommonMark has only arabic enumerators, so alphabetic and roman
+// lists are numbered instead. Attributions become a final paragraph of
+// their block quote, prefixed with an em dash.
+//
+// Raw elements are included only if their format is "markdown" or "html",
+// since CommonMark passes HTML through unchanged.
+//
+// Error elements have no CommonMark equivalent, so they are written as HTML
+// comments that are clearly marked as errors but hidden when rendered.
+//
+// Text is escaped so that it is never interpreted as Markdown syntax. The
+// conversion is not intended to round-trip.
+func WriteMarkdown(w io.Writer, node interface{}) error {
+	r := &markdownRenderer{}
+	lines := r.node(node, 1)
+	if r.err != nil {
+		return r.err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, line := range lines {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// markdownRenderer renders CommonMark, retaining the first error encountered
+// so that callers need not check after each node.
+type markdownRenderer struct {
+	err error
+}
+
+// node returns the lines of CommonMark representing the given node.
+// headingLevel is the level of ATX heading to use for any section titles.
+func (r *markdownRenderer) node(node interface{}, headingLevel int) []string {
+	switch n := node.(type) {
+	case *Fragment:
+		return joinBlocks(r.body(n.Body, headingLevel), r.structure(n.ChildElements, headingLevel))
+	case *Document:
+		var blocks [][]string
+		if n.Decoration != nil {
+			blocks = append(blocks, r.body(n.Decoration.Header, headingLevel)...)
+		}
+		if len(n.Title) != 0 {
+			blocks = append(blocks, []string{markdownHeading(n.Title, headingLevel)})
+			headingLevel++
+		}
+		if len(n.Subtitle) != 0 {
+			blocks = append(blocks, []string{markdownHeading(n.Subtitle, headingLevel)})
+		}
+		blocks = append(blocks, r.body(n.Body, headingLevel)...)
+		blocks = append(blocks, r.structure(n.ChildElements, headingLevel)...)
+		if n.Decoration != nil {
+			blocks = append(blocks, r.body(n.Decoration.Footer, headingLevel)...)
+		}
+		return joinBlocks(blocks)
+	case *Section:
+		blocks := [][]string{
+			{markdownHeading(n.Title, headingLevel)},
+		}
+		blocks = append(blocks, r.body(n.Body, headingLevel+1)...)
+		blocks = append(blocks, r.structure(n.ChildElements, headingLevel+1)...)
+		return joinBlocks(blocks)
+	case *Comment:
+		return nil
+	case *LiteralBlock:
+		// The fence must be longer than any run of backticks in the
+		// text, so that the text cannot end the block early.
+		fence := "```"
+		for strings.Contains(n.Text, fence) {
+			fence += "`"
+		}
+		lines := []string{fence}
+		lines = append(lines, strings.Split(n.Text, "\n")...)
+		return append(lines, fence)
+	case *Raw:
+		if !n.HasFormat("markdown") && !n.HasFormat("html") {
+			return nil
+		}
+		return strings.Split(n.Text, "\n")
+	case *Transition:
+		return []string{"***"}
+	case *Paragraph:
+		return markdownLines(n.Text)
+	case *BlockQuote:
+		blocks := r.body(n.Quote, headingLevel)
+		if len(n.Attribution) != 0 {
+			blocks = append(blocks, prefixLines(markdownLines(n.Attribution), "— ", ""))
+		}
+		return prefixLines(joinBlocks(blocks), "> ", "> ")
+	case *BulletList:
+		return r.bulletList(n, "-", headingLevel)
+	case *EnumeratedList:
+		return r.enumeratedList(n, markdownEnumDelimiter(n), headingLevel)
+	case *ListItem:
+		return joinBlocks(r.body(n.Body, headingLevel))
+	case *Error:
+		_, severity := docutilsLevel(n.Severity)
+		msg := fmt.Sprintf("%s: (%s) %s", n.Pos, severity, n.Message)
+		return []string{markdownComment(msg)}
+	case Body:
+		return joinBlocks(r.body(n, headingLevel))
+	case Structure:
+		return joinBlocks(r.structure(n, headingLevel))
+	case Text:
+		return markdownLines(n)
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("cannot write %T as Markdown", node)
+		}
+		return nil
+	}
+}
+
+// body renders each of the given body elements as a block.
+//
+// CommonMark continues a list for as long as the items that follow use the
+// same bullet character or enumerator delimiter, even across blank lines,
+// so consecutive lists alternate between two of each to keep them apart.
+func (r *markdownRenderer) body(body Body, headingLevel int) [][]string {
+	var blocks [][]string
+	prevDelimiter := ""
+	for _, elem := range body {
+		var block []string
+		delimiter := ""
+		switch n := elem.(type) {
+		case *BulletList:
+			delimiter = "-"
+			if prevDelimiter == delimiter {
+				delimiter = "*"
+			}
+			block = r.bulletList(n, delimiter, headingLevel)
+		case *EnumeratedList:
+			delimiter = markdownEnumDelimiter(n)
+			if prevDelimiter == delimiter {
+				delimiter = markdownOtherEnumDelimiter[delimiter]
+			}
+			block = r.enumeratedList(n, delimiter, headingLevel)
+		default:
+			block = r.node(elem, headingLevel)
+		}
+
+		// Elements that produce nothing, such as comments, do not
+		// separate the lists on either side of them.
+		if len(block) != 0 || delimiter != "" {
+			prevDelimiter = delimiter
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func (r *markdownRenderer) bulletList(list *BulletList, bullet string, headingLevel int) []string {
+	markers := make([]string, len(list.Items))
+	for i := range markers {
+		markers[i] = bullet
+	}
+	return r.list(list.Items, markers, headingLevel)
+}
+
+func (r *markdownRenderer) enumeratedList(list *EnumeratedList, delimiter string, headingLevel int) []string {
+	markers := make([]string, len(list.Items))
+	for i := range markers {
+		markers[i] = strconv.Itoa(list.FirstIndex+i) + delimiter
+	}
+	return r.list(list.Items, markers, headingLevel)
+}
+
+// markdownEnumDelimiter returns the CommonMark delimiter that best matches
+// the suffix of the given list's enumerators.
+func markdownEnumDelimiter(list *EnumeratedList) string {
+	if list.EnumSuffix == ")" {
+		return ")"
+	}
+	return "."
+}
+
+// markdownOtherEnumDelimiter maps each CommonMark enumerator delimiter to
+// the other one.
+var markdownOtherEnumDelimiter = map[string]string{
+	".": ")",
+	")": ".",
+}
+
+// markdownComment returns an HTML comment containing the given text,
+// modified as necessary so that it cannot end the comment early.
+func markdownComment(text string) string {
+	// "--" may not appear within a comment at all. Separating a pair of
+	// hyphens can form another pair from a longer run, so this repeats
+	// until there are none left.
+	for strings.Contains(text, "--") {
+		text = strings.Replace(text, "--", "- -", -1)
+	}
+	// The spaces around the text keep a hyphen at either end of it from
+	// combining with the delimiters of the comment to form "--->" or
+	// "<!---".
+	return "<!-- " + text + " -->"
+}
+
+func (r *markdownRenderer) structure(structure Structure, headingLevel int) [][]string {
+	var blocks [][]string
+	for _, elem := range structure {
+		blocks = append(blocks, r.node(elem, headingLevel))
+	}
+	return blocks
+}
+
+// list renders the given list items, each marked with the corresponding
+// marker. As in CommonMark, continuation lines are indented to align with
+// the content after the marker, and the list is loose if any item contains
+// more than one line.
+func (r *markdownRenderer) list(items []*ListItem, markers []string, headingLevel int) []string {
+	compact := true
+	rendered := make([][]string, len(items))
+	for i, item := range items {
+		lines := r.node(item, headingLevel)
+		if len(lines) == 0 {
+			rendered[i] = []string{markers[i]}
+			continue
+		}
+		indent := strings.Repeat(" ", len(markers[i])+1)
+		rendered[i] = prefixLines(lines, markers[i]+" ", indent)
+		if len(lines) > 1 {
+			compact = false
+		}
+	}
+
+	if compact {
+		var lines []string
+		for _, item := range rendered {
+			lines = append(lines, item...)
+		}
+		return lines
+	}
+	return joinBlocks(rendered)
+}
+
+// markdownHeading returns an ATX heading of the given level. CommonMark
+// has only six levels, so deeper headings are clamped to level six.
+func markdownHeading(title Text, level int) string {
+	if level > 6 {
+		level = 6
+	}
+	return strings.Repeat("#", level) + " " + strings.Join(markdownLines(title), " ")
+}
+
+// markdownLines returns the given text as lines of escaped CommonMark
+// inline content, one for each source line.
+func markdownLines(text Text) []string {
+	var lines []string
+	for _, elem := range text {
+		switch n := elem.(type) {
+		case CharData:
+			lines = append(lines, markdownEscape(strings.TrimSpace(string(n))))
+		default:
+			lines = append(lines, markdownLines(elem.InlineChildNodes())...)
+		}
+	}
+	return lines
+}
+
+// markdownEscape backslash-escapes any characters in the given line that
+// could otherwise be interpreted as CommonMark syntax.
+func markdownEscape(line string) string {
+	var buf strings.Builder
+	for i, r := range line {
+		switch r {
+		case '\\', '`', '*', '_', '[', ']', '<', '>', '!', '&', '#', '|', '~':
+			buf.WriteByte('\\')
+		case '-', '+', '=':
+			// These are only significant at the start of a line, where
+			// they might begin a list item, a thematic break, or a
+			// setext heading underline.
+			if i == 0 {
+				buf.WriteByte('\\')
+			}
+		case '.', ')':
+			// These could end an ordered list marker if everything
+			// before them on the line is digits.
+			if i > 0 && i <= 9 && strings.Trim(line[:i], "0123456789") == "" {
+				buf.WriteByte('\\')
+			}
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
@@ -0,0 +1,181 @@
+package rst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Input string
+		Want  string
+	}{
+		{
+			"paragraph",
+			"Hello *world*\nsecond line",
+			"Hello \\*world\\*\nsecond line\n",
+		},
+		{
+			"escaped line starts",
+			"text\n- not a list\n1. not a list either\n# not a heading",
+			"text\n\\- not a list\n1\\. not a list either\n\\# not a heading\n",
+		},
+		{
+			"bullet list",
+			"• one\n• two",
+			"- one\n- two\n",
+		},
+		{
+			"enumerated list",
+			"3) three\n\n   more\n4) four",
+			"3) three\n\n   more\n\n4) four\n",
+		},
+		{
+			"adjacent bullet lists",
+			"* a\n* b\n\n- c\n- d\n\n+ e",
+			"- a\n- b\n\n* c\n* d\n\n- e\n",
+		},
+		{
+			"adjacent enumerated lists",
+			"1. a\n2. b\n\n5. c\n\n(1) d",
+			"1. a\n2. b\n\n5) c\n\n1. d\n",
+		},
+		{
+			"block quote",
+			"before\n\n    quoted\n\n    -- someone",
+			"before\n\n> quoted\n>\n> — someone\n",
+		},
+		{
+			"literal block",
+			"before::\n\n    code\n    ```\n\nafter",
+			"before:\n\n````\ncode\n```\n````\n\nafter\n",
+		},
+		{
+			"error",
+			"before::\n    literal\n\nafter",
+			"before:\n\n<!-- test.rst:2:1: (ERROR) unexpected token: LITERAL -->\n\nafter\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			fragment := ParseFragmentString(test.Input, "test.rst")
+			var buf bytes.Buffer
+			if err := WriteMarkdown(&buf, fragment); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.Want {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestWriteMarkdownSections(t *testing.T) {
+	doc := &Document{
+		Title: Text{CharData("Title")},
+		ChildElements: Structure{
+			&Section{
+				Title: Text{CharData("Section")},
+				Body:  Body{&Paragraph{Text: Text{CharData("body")}}},
+			},
+			&Transition{},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Title\n\n## Section\n\nbody\n\n***\n"
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarkdownComment(t *testing.T) {
+	tests := map[string]string{
+		"plain":           "<!-- plain -->",
+		"bad -->":         "<!-- bad - -> -->",
+		"bad --->":        "<!-- bad - - -> -->",
+		"a----b":          "<!-- a- - - -b -->",
+		"bad -":           "<!-- bad - -->",
+		"- leading":       "<!-- - leading -->",
+		"<!-- nested -->": "<!-- <!- - nested - -> -->",
+	}
+	for input, want := range tests {
+		got := markdownComment(input)
+		if got != want {
+			t.Errorf("wrong comment for %q\ngot:  %s\nwant: %s", input, got, want)
+		}
+		if inner := got[len("<!--") : len(got)-len("-->")]; strings.Contains(inner, "--") {
+			t.Errorf("comment for %q contains \"--\": %s", input, got)
+		}
+	}
+}
+
+// TestWriteMarkdownCommonMark checks that the output means what it should
+// to a real CommonMark implementation, by rendering it as HTML.
+func TestWriteMarkdownCommonMark(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Input interface{}
+		Want  string
+	}{
+		{
+			"adjacent bullet lists",
+			"* a\n* b\n\n- c\n- d\n\n+ e",
+			"<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n" +
+				"<ul>\n<li>c</li>\n<li>d</li>\n</ul>\n" +
+				"<ul>\n<li>e</li>\n</ul>\n",
+		},
+		{
+			"adjacent enumerated lists",
+			"1. a\n2. b\n\n5. c\n\n(1) d",
+			"<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n" +
+				"<ol start=\"5\">\n<li>c</li>\n</ol>\n" +
+				"<ol>\n<li>d</li>\n</ol>\n",
+		},
+		{
+			"lists separated by a comment",
+			"* a\n\n.. comment\n\n* b",
+			"<ul>\n<li>a</li>\n</ul>\n<ul>\n<li>b</li>\n</ul>\n",
+		},
+		{
+			"error comment",
+			&Fragment{
+				Body: Body{
+					&Error{Message: "bad --->", Pos: Position{Line: 1, Column: 1, Filename: "test.rst"}},
+					&Paragraph{Text: Text{CharData("after")}},
+				},
+			},
+			"<!-- test.rst:1:1: (ERROR) bad - - -> -->\n<p>after</p>\n",
+		},
+	}
+
+	md := goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe()))
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			node := test.Input
+			if src, ok := node.(string); ok {
+				node = ParseFragmentString(src, "test.rst")
+			}
+			var buf bytes.Buffer
+			if err := WriteMarkdown(&buf, node); err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err := md.Convert(buf.Bytes(), &out); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != test.Want {
+				t.Errorf("wrong HTML for Markdown\n%s\ngot:\n%s\nwant:\n%s", buf.String(), got, test.Want)
+			}
+		})
+	}
+}
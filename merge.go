@@ -0,0 +1,79 @@
+package rst
+
+// MergeFragments concatenates the given fragments into a single fragment,
+// as if their sources had been parsed together.
+//
+// The result follows the same rule the parser applies within a single
+// fragment: body elements may not appear after sections. Any body element
+// that appears in a fragment after an earlier fragment has contributed
+// sections is replaced with an Error element in the result, and each such
+// error is also returned so that callers can report it.
+//
+// The result's position and SourceName are those of the first non-nil
+// fragment, and its SourceNames lists the non-empty source names of all of
+// them, including each of those recorded by a fragment that was itself
+// merged.
+// It has no Source even if the given fragments do. Nil fragments are
+// ignored. The given fragments are not modified, but the
+// result shares elements with them.
+func MergeFragments(frags ...*Fragment) (*Fragment, []*Error) {
+	var model structureModelBuilder
+	var errs []*Error
+	result := &Fragment{}
+	first := true
+
+	for _, frag := range frags {
+		if frag == nil {
+			continue
+		}
+		if first {
+			result.Pos = frag.Pos
+			result.SourceName = frag.SourceName
+			first = false
+		}
+		if frag.SourceNames != nil {
+			result.SourceNames = append(result.SourceNames, frag.SourceNames...)
+		} else if frag.SourceName != "" {
+			result.SourceNames = append(result.SourceNames, frag.SourceName)
+		}
+
+		for _, elem := range frag.Body {
+			pos := frag.Pos
+			if node, ok := elem.(Node); ok {
+				pos = node.Position()
+			}
+			wasInStructure := model.inStructure
+			model.appendBody(elem, pos)
+			if wasInStructure {
+				errs = append(errs, model.structure[len(model.structure)-1].(*Error))
+			}
+		}
+		for _, elem := range frag.ChildElements {
+			model.appendStructure(elem, elem.Position())
+		}
+	}
+
+	result.Body = model.body
+	result.ChildElements = model.structure
+	return result, errs
+}
+
+// InSection returns a new fragment whose only content is a single section
+// with the given title, containing the content of the receiver. This can
+// be used to demote each of a set of fragments to a section of its own
+// before combining them with MergeFragments.
+func (f *Fragment) InSection(title Text) *Fragment {
+	return &Fragment{
+		ChildElements: Structure{
+			&Section{
+				Title:         title,
+				Body:          f.Body,
+				ChildElements: f.ChildElements,
+				Pos:           f.Pos,
+			},
+		},
+		Pos:         f.Pos,
+		SourceName:  f.SourceName,
+		SourceNames: f.SourceNames,
+	}
+}
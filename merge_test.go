@@ -0,0 +1,140 @@
+package rst
+
+import (
+	"testing"
+)
+
+func TestMergeFragments(t *testing.T) {
+	pos := func(line int) Position {
+		return Position{Line: line, Column: 1, Filename: "test.rst"}
+	}
+	para := func(s string, line int) *Paragraph {
+		return &Paragraph{Text: Text{CharData(s)}, Pos: pos(line)}
+	}
+	section := func(s string, line int) *Section {
+		return &Section{Title: Text{CharData(s)}, Pos: pos(line)}
+	}
+
+	tests := []struct {
+		Name     string
+		Input    []*Fragment
+		Want     *Fragment
+		WantErrs int
+	}{
+		{
+			"none",
+			nil,
+			&Fragment{},
+			0,
+		},
+		{
+			"body only",
+			[]*Fragment{
+				{Body: Body{para("a", 1)}, Pos: pos(1)},
+				nil,
+				{Body: Body{para("b", 2)}, Pos: pos(2)},
+			},
+			&Fragment{
+				Body: Body{para("a", 1), para("b", 2)},
+				Pos:  pos(1),
+			},
+			0,
+		},
+		{
+			"body then sections",
+			[]*Fragment{
+				{Body: Body{para("a", 1)}, Pos: pos(1)},
+				{
+					Body:          Body{para("b", 2)},
+					ChildElements: Structure{section("c", 3)},
+					Pos:           pos(2),
+				},
+				{ChildElements: Structure{section("d", 4)}, Pos: pos(4)},
+			},
+			&Fragment{
+				Body:          Body{para("a", 1), para("b", 2)},
+				ChildElements: Structure{section("c", 3), section("d", 4)},
+				Pos:           pos(1),
+			},
+			0,
+		},
+		{
+			"body after sections",
+			[]*Fragment{
+				{ChildElements: Structure{section("a", 1)}, Pos: pos(1)},
+				{Body: Body{para("b", 2)}, Pos: pos(2)},
+			},
+			&Fragment{
+				ChildElements: Structure{
+					section("a", 1),
+					&Error{
+						Message: "body elements may not appear after sections",
+						Pos:     pos(2),
+					},
+				},
+				Pos: pos(1),
+			},
+			1,
+		},
+		{
+			"source names",
+			[]*Fragment{
+				{Body: Body{para("a", 1)}, Pos: pos(1), SourceName: "a.rst"},
+				{Body: Body{para("b", 1)}, Pos: pos(1)},
+				{
+					Body:        Body{para("c", 1), para("d", 1)},
+					Pos:         pos(1),
+					SourceName:  "c.rst",
+					SourceNames: []string{"c.rst", "d.rst"},
+				},
+			},
+			&Fragment{
+				Body:        Body{para("a", 1), para("b", 1), para("c", 1), para("d", 1)},
+				Pos:         pos(1),
+				SourceName:  "a.rst",
+				SourceNames: []string{"a.rst", "c.rst", "d.rst"},
+			},
+			0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, errs := MergeFragments(test.Input...)
+			if diff := Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+			if len(errs) != test.WantErrs {
+				t.Errorf("got %d errors; want %d", len(errs), test.WantErrs)
+			}
+		})
+	}
+}
+
+func TestFragmentInSection(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Filename: "test.rst"}
+	frag := &Fragment{
+		Body:       Body{&Paragraph{Text: Text{CharData("a")}, Pos: pos}},
+		Pos:        pos,
+		SourceName: "test.rst",
+	}
+	got, errs := MergeFragments(frag.InSection(Text{CharData("Title")}))
+	want := &Fragment{
+		ChildElements: Structure{
+			&Section{
+				Title: Text{CharData("Title")},
+				Body:  Body{&Paragraph{Text: Text{CharData("a")}, Pos: pos}},
+				Pos:   pos,
+			},
+		},
+		Pos:         pos,
+		SourceName:  "test.rst",
+		SourceNames: []string{"test.rst"},
+	}
+	if diff := Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors: %#v", errs)
+	}
+}
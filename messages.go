@@ -0,0 +1,233 @@
+package rst
+
+// MessagePolicy selects how the Error elements in a tree are presented when
+// it is rendered, as applied by its Apply method.
+//
+// The zero value renders warnings and errors in place and removes info
+// messages, as the docutils rst2html tool does by default.
+type MessagePolicy struct {
+	// Placement selects where messages appear in the output.
+	Placement MessagePlacement
+
+	// Report selects the least severe messages that are kept. Less severe
+	// messages are removed, and the rest are placed as selected by
+	// Placement.
+	Report ReportLevel
+}
+
+// ReportLevel selects which Error elements a MessagePolicy keeps, according
+// to their severity. It corresponds to the report_level setting of docutils.
+type ReportLevel int
+
+const (
+	// ReportWarnings keeps warnings and errors. This is the default.
+	ReportWarnings ReportLevel = iota
+
+	// ReportErrors keeps only errors.
+	ReportErrors
+
+	// ReportAll keeps all messages, including those with SeverityInfo.
+	ReportAll
+)
+
+// minSeverity returns the least severe severity that is kept at level l.
+func (l ReportLevel) minSeverity() Severity {
+	switch l {
+	case ReportErrors:
+		return SeverityError
+	case ReportAll:
+		return SeverityInfo
+	default:
+		return SeverityWarning
+	}
+}
+
+// MessagePlacement selects where a MessagePolicy places Error elements.
+type MessagePlacement int
+
+const (
+	// MessagesInline leaves each Error element in place, where it will be
+	// rendered at the position of the problem it describes. This is most
+	// useful for previewing documents while editing them.
+	MessagesInline MessagePlacement = iota
+
+	// MessagesAtEnd moves all Error elements to the end of the tree, in
+	// source order, so that they are rendered together after the content.
+	MessagesAtEnd
+
+	// MessagesHidden removes all Error elements, for rendering documents
+	// that are to be published.
+	MessagesHidden
+)
+
+// Apply returns a copy of the tree rooted at the given node with its Error
+// elements placed as the policy requires. The given tree is not modified,
+// though elements that need no changes are shared between the two trees.
+//
+// Only block-level Error elements are affected. The parser never produces
+// Error elements within Text, so any that appear there are left as they are.
+//
+// With MessagesAtEnd, the messages are appended to the ChildElements of a
+// *Fragment, *Document or *Section, or to the end of a Body or Structure. For
+// any other node there is nowhere to put them, so they are removed.
+func (p MessagePolicy) Apply(node interface{}) interface{} {
+	if p.Placement == MessagesInline && p.Report == ReportAll {
+		return node
+	}
+
+	a := &messageApplier{policy: p}
+	switch n := node.(type) {
+	case *Fragment:
+		ret := *n
+		ret.Body = a.body(n.Body)
+		ret.ChildElements = a.appendCollected(a.structure(n.ChildElements))
+		return &ret
+	case *Document:
+		ret := *n
+		if n.Decoration != nil {
+			deco := *n.Decoration
+			deco.Header = a.body(n.Decoration.Header)
+			deco.Footer = a.body(n.Decoration.Footer)
+			ret.Decoration = &deco
+		}
+		if n.DocInfo != nil {
+			info := *n.DocInfo
+			info.Fields = nil
+			for _, field := range n.DocInfo.Fields {
+				newField := *field
+				newField.Body = a.body(field.Body)
+				info.Fields = append(info.Fields, &newField)
+			}
+			ret.DocInfo = &info
+		}
+		ret.Body = a.body(n.Body)
+		ret.ChildElements = a.appendCollected(a.structure(n.ChildElements))
+		return &ret
+	case *Section:
+		ret := a.section(n)
+		ret.ChildElements = a.appendCollected(ret.ChildElements)
+		return ret
+	case Structure:
+		return a.appendCollected(a.structure(n))
+	case Body:
+		body := a.body(n)
+		for _, msg := range a.collected {
+			body = append(body, msg)
+		}
+		return body
+	case BodyElement:
+		return a.bodyElement(n)
+	default:
+		return node
+	}
+}
+
+// Transform returns a Transform that applies the policy to a document in
+// place, for use with Document.ApplyTransforms. It runs at
+// PriorityMessages, after all of the standard transforms that might add
+// messages.
+func (p MessagePolicy) Transform() Transform {
+	return NewTransform("messages", PriorityMessages, func(doc *Document) []*Error {
+		*doc = *p.Apply(doc).(*Document)
+		return nil
+	})
+}
+
+// messageApplier implements MessagePolicy.Apply, collecting the messages
+// that are to be moved to the end of the tree.
+type messageApplier struct {
+	policy    MessagePolicy
+	collected []*Error
+}
+
+// keep returns true if the given message should remain where it is, after
+// collecting it if it is to be moved elsewhere.
+func (a *messageApplier) keep(msg *Error) bool {
+	if msg.Severity < a.policy.Report.minSeverity() {
+		return false
+	}
+	switch a.policy.Placement {
+	case MessagesInline:
+		return true
+	case MessagesAtEnd:
+		a.collected = append(a.collected, msg)
+	}
+	return false
+}
+
+func (a *messageApplier) appendCollected(structure Structure) Structure {
+	for _, msg := range a.collected {
+		structure = append(structure, msg)
+	}
+	return structure
+}
+
+func (a *messageApplier) structure(structure Structure) Structure {
+	var ret Structure
+	for _, elem := range structure {
+		switch n := elem.(type) {
+		case *Error:
+			if a.keep(n) {
+				ret = append(ret, n)
+			}
+		case *Section:
+			ret = append(ret, a.section(n))
+		default:
+			ret = append(ret, elem)
+		}
+	}
+	return ret
+}
+
+func (a *messageApplier) section(section *Section) *Section {
+	ret := *section
+	ret.Body = a.body(section.Body)
+	ret.ChildElements = a.structure(section.ChildElements)
+	return &ret
+}
+
+func (a *messageApplier) body(body Body) Body {
+	var ret Body
+	for _, elem := range body {
+		if msg, ok := elem.(*Error); ok {
+			if a.keep(msg) {
+				ret = append(ret, msg)
+			}
+			continue
+		}
+		ret = append(ret, a.bodyElement(elem))
+	}
+	return ret
+}
+
+func (a *messageApplier) bodyElement(elem BodyElement) BodyElement {
+	switch n := elem.(type) {
+	case *BlockQuote:
+		ret := *n
+		ret.Quote = a.body(n.Quote)
+		return &ret
+	case *BulletList:
+		ret := *n
+		ret.Items = a.items(n.Items)
+		return &ret
+	case *EnumeratedList:
+		ret := *n
+		ret.Items = a.items(n.Items)
+		return &ret
+	default:
+		return elem
+	}
+}
+
+func (a *messageApplier) items(items []*ListItem) []*ListItem {
+	if items == nil {
+		return nil
+	}
+	ret := make([]*ListItem, len(items))
+	for i, item := range items {
+		copied := *item
+		copied.Body = a.body(item.Body)
+		ret[i] = &copied
+	}
+	return ret
+}
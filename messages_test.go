@@ -0,0 +1,210 @@
+package rst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMessagePolicy(t *testing.T) {
+	para := func(s string) *Paragraph {
+		return &Paragraph{Text: Text{CharData(s)}}
+	}
+	warning := &Error{
+		Message:  "inconsistent indentation",
+		Pos:      Position{Line: 2, Column: 1, Filename: "test.rst"},
+		Severity: SeverityWarning,
+	}
+	problem := &Error{
+		Message: "unexpected EOF",
+		Pos:     Position{Line: 5, Column: 1, Filename: "test.rst"},
+	}
+	note := &Error{
+		Message:  "enumerated list interpreted as text",
+		Pos:      Position{Line: 4, Column: 1, Filename: "test.rst"},
+		Severity: SeverityInfo,
+	}
+	fixture := func() *Fragment {
+		return &Fragment{
+			Body: Body{
+				&BulletList{
+					Bullet: "-",
+					Items: []*ListItem{
+						{Body: Body{para("item"), warning}},
+					},
+				},
+			},
+			ChildElements: Structure{
+				&Section{
+					Title: Text{CharData("Title")},
+					Body:  Body{para("text"), note, problem},
+				},
+			},
+		}
+	}
+	withoutMessages := func(itemBody, sectionBody Body, extra ...StructureElement) *Fragment {
+		f := &Fragment{
+			Body: Body{
+				&BulletList{
+					Bullet: "-",
+					Items:  []*ListItem{{Body: itemBody}},
+				},
+			},
+			ChildElements: Structure{
+				&Section{
+					Title: Text{CharData("Title")},
+					Body:  sectionBody,
+				},
+			},
+		}
+		f.ChildElements = append(f.ChildElements, extra...)
+		return f
+	}
+
+	tests := []struct {
+		Name   string
+		Policy MessagePolicy
+		Want   *Fragment
+	}{
+		{
+			"inline",
+			MessagePolicy{},
+			withoutMessages(Body{para("item"), warning}, Body{para("text"), problem}),
+		},
+		{
+			"inline including info",
+			MessagePolicy{Report: ReportAll},
+			fixture(),
+		},
+		{
+			"inline errors only",
+			MessagePolicy{Report: ReportErrors},
+			withoutMessages(Body{para("item")}, Body{para("text"), problem}),
+		},
+		{
+			"at end",
+			MessagePolicy{Placement: MessagesAtEnd},
+			withoutMessages(Body{para("item")}, Body{para("text")}, warning, problem),
+		},
+		{
+			"errors at end",
+			MessagePolicy{Placement: MessagesAtEnd, Report: ReportErrors},
+			withoutMessages(Body{para("item")}, Body{para("text")}, problem),
+		},
+		{
+			"all at end",
+			MessagePolicy{Placement: MessagesAtEnd, Report: ReportAll},
+			withoutMessages(Body{para("item")}, Body{para("text")}, warning, note, problem),
+		},
+		{
+			"hidden",
+			MessagePolicy{Placement: MessagesHidden},
+			withoutMessages(Body{para("item")}, Body{para("text")}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			input := fixture()
+			got := test.Policy.Apply(input)
+			if diff := Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+			if diff := Diff(fixture(), input); diff != "" {
+				t.Errorf("input was modified\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWritePlainTextMessages(t *testing.T) {
+	para := func(s string) *Paragraph {
+		return &Paragraph{Text: Text{CharData(s)}}
+	}
+	fragment := &Fragment{
+		Body: Body{
+			para("before"),
+			&Error{Message: "a warning", Pos: Position{Line: 2, Column: 1, Filename: "test.rst"}, Severity: SeverityWarning},
+			&Error{Message: "an error", Pos: Position{Line: 3, Column: 1, Filename: "test.rst"}},
+			para("after"),
+		},
+	}
+
+	tests := []struct {
+		Policy MessagePolicy
+		Want   string
+	}{
+		{
+			MessagePolicy{},
+			"before\n\ntest.rst:2:1: (WARNING) a warning\n\ntest.rst:3:1: (ERROR) an error\n\nafter\n",
+		},
+		{
+			MessagePolicy{Placement: MessagesAtEnd, Report: ReportErrors},
+			"before\n\nafter\n\ntest.rst:3:1: (ERROR) an error\n",
+		},
+		{
+			MessagePolicy{Placement: MessagesHidden},
+			"before\n\nafter\n",
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := WritePlainText(&buf, fragment, PlainTextOptions{Messages: test.Policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != test.Want {
+			t.Errorf("wrong output for %#v\ngot:\n%s\nwant:\n%s", test.Policy, got, test.Want)
+		}
+	}
+}
+
+func TestWithMessagePolicy(t *testing.T) {
+	fragment := &Fragment{
+		Body: Body{&Error{Message: "an error"}},
+	}
+	r := WithMessagePolicy(RendererFunc(WritePseudoXML), MessagePolicy{Placement: MessagesHidden})
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, fragment); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "<document>\n"; got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTemplateRendererMessages(t *testing.T) {
+	// The parser notes that this is not an enumerated list, with an info
+	// message that is omitted by default.
+	fragment := ParseFragmentString("A. Einstein was a\nphysicist.\n", "test.rst")
+	if errs := fragment.AllErrors(); len(errs) != 1 || errs[0].Severity != SeverityInfo {
+		t.Fatalf("fixture does not produce a single info message: %#v", errs)
+	}
+
+	r, ok := LookupRenderer("html")
+	if !ok {
+		t.Fatal("no html renderer registered")
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, fragment); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "<p>A. Einstein was a\nphysicist.</p>\n"; got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	all, err := NewTemplateRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all.Messages = MessagePolicy{Report: ReportAll}
+	buf.Reset()
+	if err := all.Render(&buf, fragment); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, "System Message: INFO") {
+		t.Errorf("info message not rendered with ReportAll\n%s", got)
+	}
+}
@@ -0,0 +1,10 @@
+package rst
+
+// Node is implemented by all of the block-level and structural elements in
+// the document model, as well as by the Fragment and Document types that
+// contain them.
+type Node interface {
+	// Position returns the location in the source where the element
+	// begins.
+	Position() Position
+}
@@ -0,0 +1,119 @@
+package rst
+
+import (
+	"io"
+)
+
+// Options customizes the behavior of the parser.
+//
+// The zero value of Options selects the default behavior, so new fields
+// must be designed such that their zero values preserve the existing
+// behavior of the parser.
+type Options struct {
+	// Limits bounds the resources used while parsing, for use with
+	// untrusted input. The default is no limits.
+	Limits Limits
+
+	// MaxNestingDepth is the maximum depth to which block-level constructs
+	// such as block quotes and lists may be nested. Content nested more
+	// deeply is replaced with a single Error element. Block quotes implied
+	// by a decrease in indentation count towards the same limit; beyond
+	// it, the content that follows stays in the current block instead,
+	// again with an Error.
+	//
+	// If zero, DefaultMaxNestingDepth is used. If negative, nesting depth
+	// is not limited, which allows maliciously-crafted input to exhaust
+	// the stack.
+	MaxNestingDepth int
+
+	// DropComments causes the parser to omit comments from the tree, for
+	// callers that have no use for them. By default each comment is
+	// represented by a Comment element.
+	DropComments bool
+
+	// BulletRunes are the characters that may mark the items of a bullet
+	// list. If empty, the characters given in the reStructuredText
+	// specification are used: "*", "+", "-", "•", "‣" and "⁃".
+	//
+	// Regardless of this setting, all of the items of a list must use the
+	// same marker, and a different marker begins a new list.
+	BulletRunes []rune
+
+	// KeepSource causes the parser to retain a copy of the whole source
+	// in the Source field of the resulting fragment, so that the original
+	// text of its elements can be recovered. This roughly doubles the
+	// memory used while parsing, so it is off by default.
+	KeepSource bool
+
+	// StrictErrors causes ParseFragmentErr and ParseFragmentContext to
+	// treat Error elements of SeverityError in the result as a failure,
+	// returning them as an error as ErrorsAsError would. Warnings are not
+	// affected.
+	StrictErrors bool
+
+	// OnToken, if set, is called with each token from the scanner as the
+	// parser consumes it, as described for Scanner.SetOnToken. This allows
+	// callers to relate the elements of the tree to the tokens that
+	// produced them without scanning the input a second time. If a Parser
+	// is used concurrently then the function must be safe to call
+	// concurrently too.
+	OnToken func(*Token)
+
+	// Encoding is the name of the character encoding of the source, which
+	// is converted to UTF-8 before it is scanned, so that the text and
+	// positions in the tree all refer to the converted text. The default
+	// is "utf-8", which is used as-is.
+	//
+	// The other encodings supported directly are "latin-1" (also known as
+	// "iso-8859-1") and "windows-1252" (also known as "cp1252"). Names are
+	// not case-sensitive, and hyphens and underscores are ignored.
+	//
+	// The name "auto" selects UTF-8 or UTF-16 if the source begins with
+	// the corresponding byte order mark, which is then removed. Otherwise
+	// it uses Decoder if that is set, and UTF-8 if not.
+	//
+	// Parsing a source in an unsupported encoding fails as if reading it
+	// had failed.
+	Encoding string
+
+	// Decoder, if set, converts the source to UTF-8 in place of Encoding,
+	// for encodings not supported directly. For example, to use a decoder
+	// from golang.org/x/text:
+	//
+	//	func(r io.Reader) io.Reader {
+	//		return transform.NewReader(r, charmap.ISO8859_15.NewDecoder())
+	//	}
+	//
+	// If Encoding is "auto" then Decoder is used only for sources that do
+	// not begin with a byte order mark.
+	Decoder func(io.Reader) io.Reader
+
+	// PreferEnumeratedLists causes the parser to treat a line that begins
+	// with a single letter enumerator as a list item even when the next
+	// line is not indented, as in "A. Einstein was a\nphysicist.". By
+	// default such a line is taken to be the start of a paragraph instead,
+	// since that is more often what authors intend, and an Error of
+	// SeverityInfo notes the decision.
+	PreferEnumeratedLists bool
+}
+
+// DefaultMaxNestingDepth is the nesting depth limit used when
+// Options.MaxNestingDepth is zero.
+const DefaultMaxNestingDepth = 100
+
+// defaultBulletRunes are the bullet list markers used when
+// Options.BulletRunes is empty.
+var defaultBulletRunes = []rune{'*', '+', '-', '•', '‣', '⁃'}
+
+// optionsArg deals with the optional trailing Options argument accepted by
+// the package-level parsing functions.
+func optionsArg(opts []Options) Options {
+	switch len(opts) {
+	case 0:
+		return Options{}
+	case 1:
+		return opts[0]
+	default:
+		panic("at most one Options value may be given")
+	}
+}
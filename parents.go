@@ -0,0 +1,74 @@
+package rst
+
+import (
+	"reflect"
+)
+
+// Parents records the parent of each node within a tree, since the nodes
+// themselves refer only to their children.
+//
+// Nodes are identified by their pointers, so only nodes of pointer types
+// are recorded. Values of other types, such as CharData, cannot be
+// distinguished from other equal values elsewhere in the tree, and so have
+// no recorded parent.
+//
+// Parents describes the tree as it was when it was created. Once the tree
+// is modified, such as by Rewrite, a new Parents must be created to take
+// account of the changes.
+type Parents struct {
+	parents map[interface{}]interface{}
+}
+
+// NewParents returns the parents of the nodes within the tree rooted at the
+// given node, which may be any value accepted by Walk. The root itself has
+// no parent.
+func NewParents(root interface{}) *Parents {
+	p := &Parents{
+		parents: make(map[interface{}]interface{}),
+	}
+	var visit func(node interface{})
+	visit = func(node interface{}) {
+		for _, child := range Children(node) {
+			if isPointerNode(child) && isPointerNode(node) {
+				p.parents[child] = node
+			}
+			visit(child)
+		}
+	}
+	visit(root)
+	return p
+}
+
+// ParentOf returns the parent of the given node, or nil if it has none or
+// was not within the tree.
+//
+// The parent is the node of which the given node is a direct child, as
+// returned by Children. Sequences such as Body are not nodes, and so for
+// example the parent of a paragraph in the body of a section is the
+// section.
+func (p *Parents) ParentOf(node interface{}) interface{} {
+	if !isPointerNode(node) {
+		return nil
+	}
+	return p.parents[node]
+}
+
+// Ancestors returns the ancestors of the given node, starting with its
+// parent and ending with the root of the tree, or nil if it has no parent.
+func (p *Parents) Ancestors(node interface{}) []interface{} {
+	var ret []interface{}
+	for {
+		node = p.ParentOf(node)
+		if node == nil {
+			return ret
+		}
+		ret = append(ret, node)
+	}
+}
+
+// isPointerNode returns true if the given value is a non-nil pointer, and
+// so can be used to identify a node.
+func isPointerNode(node interface{}) bool {
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && !v.IsNil()
+}
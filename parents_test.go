@@ -0,0 +1,65 @@
+package rst
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParents(t *testing.T) {
+	doc := cloneTestTree()
+	parents := NewParents(doc)
+
+	para := doc.Body[0].(*Paragraph)
+	inlineErr := para.Text[1].(*Error)
+	list := doc.Body[2].(*BulletList)
+	item := list.Items[0]
+	section := doc.ChildElements[0].(*Section)
+	subsection := section.ChildElements[0].(*Section)
+	sectionPara := section.Body[0].(*Paragraph)
+	field := doc.DocInfo.Fields[0]
+
+	tests := []struct {
+		Name   string
+		Node   interface{}
+		Parent interface{}
+	}{
+		{"body", para, doc},
+		{"text", inlineErr, para},
+		{"list", item, list},
+		{"list item", item.Body[0], item},
+		{"structure", subsection, section},
+		{"section body", sectionPara, section},
+		{"docinfo", field, doc.DocInfo},
+		{"docinfo field", field.Body[0], field},
+		{"decoration", doc.Decoration, doc},
+		{"root", doc, nil},
+		{"chardata", para.Text[0], nil},
+		{"outside tree", &Paragraph{}, nil},
+		{"nil", nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := parents.ParentOf(test.Node); got != test.Parent {
+				t.Errorf("wrong parent %#v; want %#v", got, test.Parent)
+			}
+		})
+	}
+
+	got := parents.Ancestors(sectionPara)
+	want := []interface{}{section, doc}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong ancestors\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if got := parents.Ancestors(doc); got != nil {
+		t.Errorf("wrong ancestors for root %#v", got)
+	}
+
+	// When the root is a sequence, its elements have no parent.
+	parents = NewParents(doc.Body)
+	if got := parents.ParentOf(para); got != nil {
+		t.Errorf("wrong parent of top-level element %#v", got)
+	}
+	if got := parents.ParentOf(inlineErr); got != para {
+		t.Errorf("wrong parent of nested element %#v", got)
+	}
+}
@@ -1,76 +1,386 @@
 package rst
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 )
 
-func ParseFragment(r io.Reader, filename string) *Fragment {
-	scanner := NewScanner(r, filename)
-	p := &parser{scanner}
+// ParseFragment parses the RST source read from r as a fragment.
+//
+// At most one Options value may be given, to customize the parser's
+// behavior. If it is omitted then the default options are used.
+func ParseFragment(r io.Reader, filename string, opts ...Options) *Fragment {
+	p := &Parser{Options: optionsArg(opts)}
+	return p.ParseFragment(r, filename)
+}
+
+// ParseFragmentErr is like ParseFragment except that if reading from r fails
+// then the read error is returned, rather than being recorded as an Error
+// element within the returned tree.
+//
+// Problems with the markup itself are still reported as Error elements, so
+// a nil error indicates only that the whole input was read successfully,
+// unless Options.StrictErrors is set. When an error is returned the
+// fragment is nil.
+func ParseFragmentErr(r io.Reader, filename string, opts ...Options) (*Fragment, error) {
+	p := &Parser{Options: optionsArg(opts)}
+	return p.ParseFragmentErr(r, filename)
+}
+
+// ParseFragmentContext is like ParseFragmentErr except that parsing is
+// abandoned if the given context is cancelled, in which case the partial
+// fragment parsed so far is returned along with the context's error.
+func ParseFragmentContext(ctx context.Context, r io.Reader, filename string, opts ...Options) (*Fragment, error) {
+	p := &Parser{Options: optionsArg(opts)}
+	return p.ParseFragmentContext(ctx, r, filename)
+}
+
+// ParseFragmentString is like ParseFragment but takes its source from a
+// string rather than a reader.
+//
+// The text in the resulting tree is sliced from src rather than copied, so
+// this uses considerably less memory than parsing the same source from a
+// reader, but the tree will keep all of src reachable for as long as any of
+// its text is. That is not so if Options.Encoding or Options.Decoder calls
+// for the source to be converted to UTF-8, in which case the converted text
+// is copied.
+func ParseFragmentString(src, filename string, opts ...Options) *Fragment {
+	p := &Parser{Options: optionsArg(opts)}
+	return p.ParseFragmentString(src, filename)
+}
+
+// ParseFragmentBytes is like ParseFragmentString but takes its source from
+// a byte slice. The slice is copied once, into the string from which all of
+// the text in the resulting tree is sliced, so the caller may modify it
+// once this function returns.
+func ParseFragmentBytes(src []byte, filename string, opts ...Options) *Fragment {
+	return ParseFragmentString(string(src), filename, opts...)
+}
+
+// Parser parses RST source using a particular set of options.
+//
+// The zero value of Parser uses the default options, and so behaves
+// identically to the package-level parsing functions called without
+// options. A Parser may be used for any number of parsing operations,
+// including concurrently.
+type Parser struct {
+	Options Options
+}
+
+// ParseFragment parses the RST source read from r as a fragment.
+func (pp *Parser) ParseFragment(r io.Reader, filename string) *Fragment {
+	p := newParser(r, filename, pp.Options)
 	return p.ParseFragment()
 }
 
+// ParseFragmentString is the Parser equivalent of the package-level
+// function of the same name.
+func (pp *Parser) ParseFragmentString(src, filename string) *Fragment {
+	p := newStringParser(src, filename, pp.Options)
+	return p.ParseFragment()
+}
+
+// ParseFragmentBytes is the Parser equivalent of the package-level function
+// of the same name.
+func (pp *Parser) ParseFragmentBytes(src []byte, filename string) *Fragment {
+	return pp.ParseFragmentString(string(src), filename)
+}
+
+// ParseFragmentErr is the Parser equivalent of the package-level function
+// of the same name.
+func (pp *Parser) ParseFragmentErr(r io.Reader, filename string) (*Fragment, error) {
+	p := newParser(r, filename, pp.Options)
+	fragment := p.ParseFragment()
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	if err := p.strictErr(fragment); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+// ParseFragmentContext is the Parser equivalent of the package-level
+// function of the same name.
+func (pp *Parser) ParseFragmentContext(ctx context.Context, r io.Reader, filename string) (*Fragment, error) {
+	p := newParser(r, filename, pp.Options)
+	p.ctx = ctx
+	fragment := p.ParseFragment()
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return fragment, err
+	}
+	if err := p.strictErr(fragment); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
 type parser struct {
 	*Scanner
+
+	opts Options
+
+	// failed is set once an ERROR token from the scanner has been reported,
+	// so that the enclosing contexts that also see it don't report it again.
+	failed bool
+
+	// depth is the number of structure model parsers currently active,
+	// which is limited by opts.MaxNestingDepth.
+	depth int
+
+	// deepest is the greatest depth reached so far by the content of the
+	// current structure model, which LATE_INDENT tokens increase by
+	// wrapping that content in further block quotes.
+	deepest int
+
+	// elements and errors count the elements produced so far, which are
+	// limited by opts.Limits.
+	elements int
+	errors   int
+
+	// starts holds, for each active structure model parser, the first
+	// line of the element it is parsing. An error about that element may
+	// be reported once the scanner no longer retains the line, such as
+	// an unexpected token that is reported only after the parser has
+	// skipped the lines that follow it, so cite falls back on these.
+	starts []lineText
+
+	// sourceText returns the whole of the input once parsing is complete,
+	// if opts.KeepSource is set, and is nil otherwise.
+	sourceText func() string
+}
+
+func newParser(r io.Reader, filename string, opts Options) *parser {
+	if opts.needsDecoding() {
+		r = opts.decodeReader(r)
+	}
+	var sourceText func() string
+	if opts.KeepSource {
+		buf := &bytes.Buffer{}
+		r = io.TeeReader(r, buf)
+		sourceText = buf.String
+	}
+	p := newScannerParser(NewScanner(r, filename), opts)
+	p.sourceText = sourceText
+	return p
+}
+
+// newStringParser is like newParser but takes its source from a string,
+// from which the text of the tree is sliced without copying.
+func newStringParser(src, filename string, opts Options) *parser {
+	if opts.needsDecoding() {
+		// The tree cannot share the source's memory if it must be
+		// converted, so this is no better than reading it.
+		return newParser(strings.NewReader(src), filename, opts)
+	}
+	p := newScannerParser(newStringScanner(src, filename), opts)
+	if opts.KeepSource {
+		p.sourceText = func() string {
+			return src
+		}
+	}
+	return p
+}
+
+func newScannerParser(scanner *Scanner, opts Options) *parser {
+	scanner.SetLimits(opts.Limits)
+	scanner.SetOnToken(opts.OnToken)
+	return &parser{
+		Scanner: scanner,
+		opts:    opts,
+	}
+}
+
+// strictErr returns the errors within the given fragment as a single
+// error, if Options.StrictErrors is set.
+func (p *parser) strictErr(fragment *Fragment) error {
+	if !p.opts.StrictErrors {
+		return nil
+	}
+	return ErrorsAsError(fragment, SeverityError)
 }
 
 func (p *parser) ParseFragment() *Fragment {
 	body, structure := p.parseStructureModel(EOF)
-	return &Fragment{
+	fragment := &Fragment{
 		Body:          body,
 		ChildElements: structure,
+		Pos: Position{
+			Line:     1,
+			Column:   1,
+			Filename: p.filename,
+		},
+		SourceName: p.filename,
+	}
+	if p.sourceText != nil {
+		fragment.Source = newSource(p.filename, p.sourceText())
 	}
+	return fragment
 }
 
-// structureModelParser is a temporary helper construct used within the parser
-// to parse the "structure model": body elements followed by structure
-// elements, possibly with transitions interspersed.
-type structureModelParser struct {
-	parser          *parser
-	appendBody      func(BodyElement, Position)
-	appendStructure func(StructureElement, Position)
-	appendMixed     func(interface{}, Position)
-	blockQuoteBody  func(Position)
+// lineText is a line of the source, numbered from 1, and its text.
+type lineText struct {
+	line int
+	text string
+}
 
-	// used when parsing blockquote bodies, to capture the attribution.
-	// if nil, attributions are not parsed.
-	appendAttribution func(content Text, pos Position)
+// startElement records that the current structure model parser is about
+// to parse an element beginning at the given position.
+func (p *parser) startElement(pos Position) {
+	text, _ := p.sourceLine(pos.Line)
+	p.starts[len(p.starts)-1] = lineText{line: pos.Line, text: text}
+}
+
+// cite populates the Source field of the given error with the line at its
+// position, and returns the error. The scanner retains only the lines that
+// the parser is still working on, so each error must be cited as soon as
+// it is created, rather than once parsing is complete.
+//
+// cite may be called on a nil parser, as by a model that MergeFragments
+// uses outside of parsing, in which case it leaves the error unchanged.
+func (p *parser) cite(err *Error) *Error {
+	if p == nil || err.Source != "" {
+		return err
+	}
+	if text, ok := p.sourceLine(err.Pos.Line); ok {
+		err.Source = text
+		return err
+	}
+	for _, start := range p.starts {
+		if start.line == err.Pos.Line {
+			err.Source = start.text
+			break
+		}
+	}
+	return err
+}
+
+// structureModel is implemented by each of the contexts in which the parser
+// can parse the "structure model": body elements followed by structure
+// elements, possibly with transitions interspersed.
+//
+// parseModel recognizes the elements and reports them to the model, which
+// decides how (and whether) each one is incorporated into the tree.
+type structureModel interface {
+	appendBody(elem BodyElement, pos Position)
+	appendStructure(elem StructureElement, pos Position)
+
+	// appendMixed appends an element that is valid in both body and
+	// structure context, such as an Error.
+	appendMixed(elem interface{}, pos Position)
+
+	// blockQuoteBody wraps everything appended so far in a block quote,
+	// in response to a LATE_INDENT token.
+	blockQuoteBody(pos Position)
+
+	// acceptsAttribution returns true if the model is a block quote body,
+	// in which case attributions will be parsed and reported to
+	// appendAttribution.
+	acceptsAttribution() bool
+	appendAttribution(content Text, pos Position)
 }
 
-func (m *structureModelParser) parse(endType TokenType) {
-	p := m.parser
+func (p *parser) parseModel(m structureModel, endType TokenType) {
+	p.depth++
+	outerDeepest := p.deepest
+	p.deepest = p.depth
+	p.starts = append(p.starts, lineText{})
+	defer func() {
+		p.depth--
+		p.starts = p.starts[:len(p.starts)-1]
+		if outerDeepest > p.deepest {
+			p.deepest = outerDeepest
+		}
+	}()
+
+	// wrapsLimited is set once a LATE_INDENT has been refused for
+	// exceeding the nesting limit, so that it's reported only once.
+	wrapsLimited := false
+
+	if max := p.maxNestingDepth(); max >= 0 && p.depth > max {
+		// Rather than recursing any further we'll skip over the whole
+		// of the current block, leaving only the token that terminates it
+		// for the loop below to deal with.
+		pos := p.Peek().Position
+		p.startElement(pos)
+		skipped, end := p.skipBlock()
+		p.appendError(m, &Error{
+			Message: fmt.Sprintf("content is nested more than %d levels deep", max),
+			Pos:     pos,
+			End:     end,
+			Skipped: skipped,
+		})
+	}
 
 	for {
+		// A literal block must be separated by a blank line from the
+		// paragraph that introduces it.
+		afterBlank := p.Peek().Type == BLANK
 		p.SkipBlanks()
 
+		// Peeking may have caused the scanner to notice problems that
+		// don't affect the token stream, which we report in-place.
+		// Reporting them may exceed the error limit, so we must peek
+		// again afterwards.
+		for _, warning := range p.TakeWarnings() {
+			p.appendError(m, warning)
+		}
+
 		next := p.Peek()
+		p.startElement(next.Position)
 
 		if next.Type == endType {
 			p.Read() // consume terminator
 			break
 		}
 
+		if next.Type == ERROR {
+			// The scanner cannot continue after an error, so we must
+			// unwind all of the way out of the parser.
+			if !p.failed {
+				m.appendMixed(p.cite(&Error{
+					Message: next.Data,
+					Pos:     next.Position,
+				}), next.Position)
+				p.failed = true
+			}
+			break
+		}
+
 		if next.Type == EOF {
-			m.appendMixed(&Error{
-				Message: "unexpected EOF",
-				Pos:     next.Position,
-			}, next.Position)
+			p.appendError(m, &Error{
+				Message:  "unexpected EOF",
+				Pos:      next.Position,
+				Found:    EOF,
+				Expected: endType.String(),
+			})
 			break
 		}
 
+		if !p.countElement(next.Position) {
+			// The ERROR token that reports the limit is dealt with on
+			// the next iteration.
+			continue
+		}
+
 		if next.Type == INDENT {
 			// An indent signals the beginning of a blockquote.
 			// The parsing function for blockquotes can potentially return
 			// multiple blockquotes if there is a chain of them separated by
 			// attribution markers.
-			startPos := next.Position
 			blockQuoteElems := p.parseBlockQuotes(DEDENT)
 			for _, elem := range blockQuoteElems {
-				m.appendBody(elem, startPos)
+				m.appendBody(elem, elem.(*BlockQuote).Pos)
 			}
 			continue
 		}
@@ -82,13 +392,28 @@ func (m *structureModelParser) parse(endType TokenType) {
 			// seen so far was actually inside a blockquote, so we now
 			// need to restructure the DOM to reflect that.
 			p.Read() // eat LATE_INDENT token
+			if max := p.maxNestingDepth(); max >= 0 && p.deepest >= max {
+				// Wrapping again would nest the earlier content too
+				// deeply, so the content that follows joins the current
+				// block instead. Input that keeps decreasing its
+				// indentation could otherwise nest one level per line.
+				if !wrapsLimited {
+					p.appendError(m, &Error{
+						Message: fmt.Sprintf("content is nested more than %d levels deep", max),
+						Pos:     next.Position,
+					})
+					wrapsLimited = true
+				}
+				continue
+			}
+			p.deepest++
 			m.blockQuoteBody(next.Position)
 			continue
 		}
 
 		// Only look for attribution syntax if the caller provided an
 		// event handler for it.
-		if m.appendAttribution != nil && next.Type == LINE {
+		if m.acceptsAttribution() && next.Type == LINE {
 			if strings.HasPrefix(next.Data, "--") {
 				nextChar, ncLen := utf8.DecodeRuneInString(next.Data[2:])
 				if unicode.IsSpace(nextChar) {
@@ -98,13 +423,15 @@ func (m *structureModelParser) parse(endType TokenType) {
 					p.PushBackSuffix(firstLine, ncLen+2)
 					attribution := p.parseText()
 
-					if p.Peek().Type == DEDENT {
+					if next := p.Peek(); next.Type == DEDENT {
 						p.Eat(DEDENT)
 					} else {
-						m.appendMixed(&Error{
-							Message: "missing dedent after attribution",
-							Pos:     startPos,
-						}, startPos)
+						p.appendError(m, &Error{
+							Message:  "missing dedent after attribution",
+							Pos:      startPos,
+							Found:    next.Type,
+							Expected: DEDENT.String(),
+						})
 					}
 
 					m.appendAttribution(attribution, startPos)
@@ -113,6 +440,20 @@ func (m *structureModelParser) parse(endType TokenType) {
 			}
 		}
 
+		if p.detectComment(next) {
+			startPos := next.Position
+			comment := p.parseComment()
+			if !p.opts.DropComments {
+				m.appendBody(comment, startPos)
+			}
+			continue
+		}
+
+		if next.Type == EXPLICIT_MARKUP {
+			p.parseUnsupportedMarkup(m)
+			continue
+		}
+
 		if marker, _ := p.detectBulletListItem(next); marker != 0 {
 			startPos := next.Position
 			listElem := p.parseBulletList(marker)
@@ -120,7 +461,7 @@ func (m *structureModelParser) parse(endType TokenType) {
 			continue
 		}
 
-		if seq, marker, start, _ := p.detectEnumeratedListItem(next); seq != 0 {
+		if seq, marker, start, _ := p.detectEnumeratedListItem(next, enumSeqInvalid); seq != 0 {
 			startPos := next.Position
 			listElem := p.parseEnumeratedList(seq, marker, start)
 			m.appendBody(listElem, startPos)
@@ -129,99 +470,171 @@ func (m *structureModelParser) parse(endType TokenType) {
 
 		if next.Type == LINE {
 			startPos := next.Position
+			info := p.ambiguousEnumerator(next)
 			text := p.parseText()
-			m.appendBody(&Paragraph{Text: text}, startPos)
+			m.appendBody(&Paragraph{Text: text, Pos: startPos}, startPos)
+			if info != nil {
+				p.appendError(m, info)
+			}
+			continue
+		}
+
+		if next.Type == LITERAL && afterBlank {
+			startPos := next.Position
+			block := p.parseLiteralBlock()
+			m.appendBody(block, startPos)
 			continue
 		}
 
-		// If we manage to get here then we've encountered a parser bug,
-		// since by this point we should've dealt with all possible situations.
-		p.Read() // Eat whatever is bothering us (TODO: seek forward to recover?)
-		m.appendMixed(&Error{
-			Message: "unexpected token: " + next.Type.String(),
-			Pos:     next.Position,
-		}, next.Position)
+		// If we manage to get here then we've encountered a token we don't
+		// know how to deal with in this context, so we'll skip forward to
+		// somewhere we're likely to be able to resume parsing.
+		var expected string
+		if next.Type == LITERAL {
+			// The scanner reports lines indented beyond the current
+			// level as literal text once it has seen a literal block
+			// marker, so the author probably meant them to be at the
+			// current level.
+			expected = fmt.Sprintf("content indented to column %d", p.CurrentIndent()+1)
+		}
+		skipped, end := p.resync()
+		p.appendError(m, &Error{
+			Message:  "unexpected token: " + next.Type.String(),
+			Pos:      next.Position,
+			End:      end,
+			Skipped:  skipped,
+			Found:    next.Type,
+			Expected: expected,
+		})
 	}
 }
 
-func (p *parser) parseStructureModel(endType TokenType) (Body, Structure) {
-	var body Body
-	var structure Structure
-
-	var model structureModelParser
-	model = structureModelParser{
-		parser: p,
-		appendBody: func(elem BodyElement, pos Position) {
-			body = append(body, elem)
-		},
-		blockQuoteBody: func(pos Position) {
-			body = Body{
-				&BlockQuote{
-					Quote: body,
-				},
-			}
-		},
-		appendStructure: func(elem StructureElement, pos Position) {
-			// transition into structure context
-			model.appendStructure = func(elem StructureElement, pos Position) {
-				structure = append(structure, elem)
+// countElement records that the parser is about to produce an element at
+// the given position. If doing so would exceed Limits.MaxElements then it
+// instead stops the scanner and returns false, in which case the caller
+// must not produce the element.
+func (p *parser) countElement(pos Position) bool {
+	max := p.opts.Limits.MaxElements
+	if max <= 0 {
+		return true
+	}
+	if p.elements >= max {
+		p.stop(fmt.Sprintf("document exceeds maximum of %d elements", max), pos)
+		return false
+	}
+	p.elements++
+	return true
+}
+
+// appendError appends the given error to the model, unless doing so would
+// exceed Limits.MaxErrors, in which case it instead stops the scanner so
+// that the ERROR token reporting the limit takes its place.
+func (p *parser) appendError(m structureModel, err *Error) {
+	p.cite(err)
+	if max := p.opts.Limits.MaxErrors; max > 0 {
+		if p.errors >= max {
+			if p.Scanner.failed == nil {
+				p.stop(fmt.Sprintf("document exceeds maximum of %d errors", max), err.Pos)
 			}
-			model.appendBody = func(elem BodyElement, pos Position) {
-				model.appendStructure(&Error{
-					Message: "body elements may not appear after sections",
-					Pos:     pos,
-				}, pos)
+			return
+		}
+		p.errors++
+	}
+	m.appendMixed(err, err.Pos)
+}
+
+// resync consumes the next token and then any following tokens up to and
+// including the next BLANK token, returning the source text of the lines
+// that were skipped and the position just after the last of them.
+//
+// Any indented blocks encountered while skipping are skipped in their
+// entirety, so that the INDENT and DEDENT tokens remain balanced. A DEDENT
+// that would leave the current block terminates recovery without being
+// consumed, so that the caller's context can deal with it as normal.
+func (p *parser) resync() (string, Position) {
+	var skipped []string
+	var end Position
+	depth := 0
+
+	for first := true; ; first = false {
+		next := p.Peek()
+
+		switch next.Type {
+		case EOF, ERROR:
+			return strings.Join(skipped, "\n"), end
+		case BLANK:
+			if depth == 0 && !first {
+				p.Read()
+				return strings.Join(skipped, "\n"), end
 			}
-			model.blockQuoteBody = func(pos Position) {
-				model.appendStructure(&Error{
-					Message: "block quote cannot terminate here",
-					Pos:     pos,
-				}, pos)
+		case INDENT:
+			depth++
+		case LATE_INDENT, DEDENT:
+			if depth == 0 && !first {
+				return strings.Join(skipped, "\n"), end
 			}
-			model.appendMixed = func(elem interface{}, pos Position) {
-				model.appendStructure(elem.(StructureElement), pos)
+			if next.Type == DEDENT && depth > 0 {
+				depth--
 			}
+		case LINE, LITERAL:
+			skipped = append(skipped, next.Data)
+			end = tokenEnd(next)
+		case EXPLICIT_MARKUP:
+			// The body that follows ends with a DEDENT but has no INDENT.
+			depth++
+			text := p.markupText(next)
+			skipped = append(skipped, text)
+			end = next.Position
+			end.Column += len(text)
+		}
 
-			model.appendStructure(elem, pos)
-		},
-		appendMixed: func(elem interface{}, pos Position) {
-			model.appendBody(elem.(BodyElement), pos)
-		},
+		p.Read()
 	}
-	model.parse(endType)
-
-	return body, structure
 }
 
-func (p *parser) parseBody(endType TokenType) Body {
-	var body Body
+// tokenEnd returns the position just after the end of the text of the
+// given LINE or LITERAL token.
+func tokenEnd(token *Token) Position {
+	end := token.Position
+	if token.Type == LITERAL {
+		// Literal tokens begin at the start of the line, with their
+		// indentation included in their data, so we must expand any tabs
+		// in it just as the scanner does when measuring indentation.
+		end.Column += lineColumns(token.Data)
+	} else {
+		end.Column += len(token.Data)
+	}
+	return end
+}
 
-	var model structureModelParser
-	model = structureModelParser{
-		parser: p,
-		appendBody: func(elem BodyElement, pos Position) {
-			body = append(body, elem)
-		},
-		blockQuoteBody: func(pos Position) {
-			body = Body{
-				&BlockQuote{
-					Quote: body,
-				},
-			}
-		},
-		appendStructure: func(elem StructureElement, pos Position) {
-			body = append(body, &Error{
-				Message: "structure elements may not appear here",
-				Pos:     pos,
-			})
-		},
-		appendMixed: func(elem interface{}, pos Position) {
-			model.appendBody(elem.(BodyElement), pos)
-		},
+// lineColumns returns the number of columns the given line occupies, where
+// tabs in its indentation advance to the next multiple of eight and all
+// other bytes occupy one column each.
+func lineColumns(line string) int {
+	col := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			col++
+		case '\t':
+			col += 8 - col%8
+		default:
+			return col + len(line) - i
+		}
 	}
-	model.parse(endType)
+	return col
+}
 
-	return body
+func (p *parser) parseStructureModel(endType TokenType) (Body, Structure) {
+	model := structureModelBuilder{p: p}
+	p.parseModel(&model, endType)
+	return model.body, model.structure
+}
+
+func (p *parser) parseBody(endType TokenType) Body {
+	model := bodyBuilder{p: p}
+	p.parseModel(&model, endType)
+	return model.body
 }
 
 func (p *parser) parseBlockQuotes(endType TokenType) Body {
@@ -231,72 +644,474 @@ func (p *parser) parseBlockQuotes(endType TokenType) Body {
 		panic("parseBlockQuote called when block quote can't start")
 	}
 
-	var current *BlockQuote
-	quotes := make(Body, 0, 1)
+	model := blockQuoteBuilder{p: p}
+	p.parseModel(&model, endType)
+	return model.quotes
+}
 
-	ensureCurrent := func() {
-		if current == nil {
-			current = &BlockQuote{}
-			quotes = append(quotes, current)
-		}
+// structureModelBuilder is the structureModel for contexts that can contain
+// both body and structure elements, such as the top level of a fragment.
+type structureModelBuilder struct {
+	// p is the parser that is using the model, if any, for citing the
+	// source of errors.
+	p *parser
+
+	body      Body
+	structure Structure
+
+	// inStructure is set once the first structure element has been
+	// appended, after which body elements are no longer allowed.
+	inStructure bool
+}
+
+func (m *structureModelBuilder) appendBody(elem BodyElement, pos Position) {
+	if m.inStructure {
+		m.appendStructure(m.p.cite(&Error{
+			Message: "body elements may not appear after sections",
+			Pos:     pos,
+		}), pos)
+		return
 	}
+	m.body = append(m.body, elem)
+}
 
-	var model structureModelParser
-	model = structureModelParser{
-		parser: p,
-		appendBody: func(elem BodyElement, pos Position) {
-			ensureCurrent()
-			current.Quote = append(current.Quote, elem)
-		},
-		blockQuoteBody: func(pos Position) {
-			ensureCurrent()
-			current.Quote = Body{
-				&BlockQuote{
-					Quote: current.Quote,
-				},
+func (m *structureModelBuilder) appendStructure(elem StructureElement, pos Position) {
+	m.inStructure = true
+	m.structure = append(m.structure, elem)
+}
+
+func (m *structureModelBuilder) appendMixed(elem interface{}, pos Position) {
+	if m.inStructure {
+		m.appendStructure(elem.(StructureElement), pos)
+		return
+	}
+	m.appendBody(elem.(BodyElement), pos)
+}
+
+func (m *structureModelBuilder) blockQuoteBody(pos Position) {
+	if m.inStructure {
+		m.appendStructure(m.p.cite(&Error{
+			Message: "block quote cannot terminate here",
+			Pos:     pos,
+		}), pos)
+		return
+	}
+	m.body = Body{newBlockQuote(m.body, pos)}
+}
+
+func (m *structureModelBuilder) acceptsAttribution() bool {
+	return false
+}
+
+func (m *structureModelBuilder) appendAttribution(content Text, pos Position) {
+	panic("attributions not accepted in structure model")
+}
+
+// bodyBuilder is the structureModel for contexts that can contain only
+// body elements, such as list items.
+type bodyBuilder struct {
+	p    *parser
+	body Body
+}
+
+func (m *bodyBuilder) appendBody(elem BodyElement, pos Position) {
+	m.body = append(m.body, elem)
+}
+
+func (m *bodyBuilder) appendStructure(elem StructureElement, pos Position) {
+	m.body = append(m.body, m.p.cite(&Error{
+		Message: "structure elements may not appear here",
+		Pos:     pos,
+	}))
+}
+
+func (m *bodyBuilder) appendMixed(elem interface{}, pos Position) {
+	m.appendBody(elem.(BodyElement), pos)
+}
+
+func (m *bodyBuilder) blockQuoteBody(pos Position) {
+	m.body = Body{newBlockQuote(m.body, pos)}
+}
+
+func (m *bodyBuilder) acceptsAttribution() bool {
+	return false
+}
+
+func (m *bodyBuilder) appendAttribution(content Text, pos Position) {
+	panic("attributions not accepted in body")
+}
+
+// blockQuoteBuilder is the structureModel for the content of a block quote,
+// which may actually produce a sequence of block quotes if attributions
+// are present.
+type blockQuoteBuilder struct {
+	p       *parser
+	current *BlockQuote
+	quotes  Body
+}
+
+func (m *blockQuoteBuilder) ensureCurrent(pos Position) {
+	if m.current == nil {
+		m.current = &BlockQuote{Pos: pos}
+		m.quotes = append(m.quotes, m.current)
+	}
+}
+
+func (m *blockQuoteBuilder) appendBody(elem BodyElement, pos Position) {
+	m.ensureCurrent(pos)
+	m.current.Quote = append(m.current.Quote, elem)
+}
+
+func (m *blockQuoteBuilder) appendStructure(elem StructureElement, pos Position) {
+	m.appendBody(m.p.cite(&Error{
+		Message: "structure elements may not appear here",
+		Pos:     pos,
+	}), pos)
+}
+
+func (m *blockQuoteBuilder) appendMixed(elem interface{}, pos Position) {
+	m.appendBody(elem.(BodyElement), pos)
+}
+
+func (m *blockQuoteBuilder) blockQuoteBody(pos Position) {
+	// Everything in this block so far, including any earlier quotes that
+	// were terminated by attributions, was at a deeper level than the
+	// block itself, so it all moves into a single new quote that
+	// remains open for the content that follows.
+	if len(m.quotes) == 0 {
+		m.ensureCurrent(pos)
+		return
+	}
+	m.current = newBlockQuote(m.quotes, pos)
+	m.quotes = Body{m.current}
+}
+
+func (m *blockQuoteBuilder) acceptsAttribution() bool {
+	return true
+}
+
+func (m *blockQuoteBuilder) appendAttribution(content Text, pos Position) {
+	// An attribution with no quote before it produces a quote
+	// with an empty body, rather than being lost.
+	m.ensureCurrent(pos)
+	m.current.Attribution = content
+
+	// an attribution signals the end of the current quote.
+	// any further elements will begin another.
+	m.current = nil
+}
+
+// skipBlock consumes all of the tokens up to the end of the current
+// indented block, returning the source text of the lines that were skipped
+// and the position just after the last of them. The DEDENT that terminates
+// the block is not consumed.
+func (p *parser) skipBlock() (string, Position) {
+	var skipped []string
+	var end Position
+	depth := 0
+
+	for {
+		next := p.Peek()
+
+		switch next.Type {
+		case EOF, ERROR:
+			return strings.Join(skipped, "\n"), end
+		case INDENT:
+			depth++
+		case LATE_INDENT, DEDENT:
+			if depth == 0 {
+				return strings.Join(skipped, "\n"), end
 			}
-		},
-		appendStructure: func(elem StructureElement, pos Position) {
-			model.appendBody(&Error{
-				Message: "structure elements may not appear here",
-				Pos:     pos,
-			}, pos)
-		},
-		appendMixed: func(elem interface{}, pos Position) {
-			model.appendBody(elem.(BodyElement), pos)
-		},
-		appendAttribution: func(elem Text, pos Position) {
-			current.Attribution = elem
+			if next.Type == DEDENT {
+				depth--
+			}
+		case LINE, LITERAL:
+			skipped = append(skipped, next.Data)
+			end = tokenEnd(next)
+		case EXPLICIT_MARKUP:
+			depth++
+			text := p.markupText(next)
+			skipped = append(skipped, text)
+			end = next.Position
+			end.Column += len(text)
+		}
 
-			// an attribution signals the end of the current quote.
-			// any further elements will begin another.
-			current = nil
-		},
+		p.Read()
 	}
-	model.parse(endType)
+}
 
-	return quotes
+func (p *parser) maxNestingDepth() int {
+	if p.opts.MaxNestingDepth == 0 {
+		return DefaultMaxNestingDepth
+	}
+	return p.opts.MaxNestingDepth
+}
+
+// newBlockQuote wraps the given body in a block quote, as is required when
+// the scanner signals a LATE_INDENT. The quote's position is that of its
+// first element, or pos if the body is empty.
+func newBlockQuote(body Body, pos Position) *BlockQuote {
+	if len(body) > 0 {
+		if node, ok := body[0].(Node); ok {
+			pos = node.Position()
+		}
+	}
+	return &BlockQuote{
+		Quote: body,
+		Pos:   pos,
+	}
+}
+
+// ParseText parses the given string as inline markup only, without any of
+// the block-level constructs, and returns the resulting Text.
+//
+// pos gives the position of the start of s in some larger source, and is
+// used as the base for the positions of any elements in the result. If s
+// contains newlines then it is treated as a sequence of lines, just as the
+// lines of a paragraph would be.
+//
+// The result never contains block elements. An empty string produces a
+// nil Text.
+func ParseText(s string, pos Position) Text {
+	if s == "" {
+		return nil
+	}
+	return parseInline(strings.Split(s, "\n"), pos)
 }
 
 // parseText reads zero or more sequential LINE tokens, parses the result
 // as inline markup, and returns a Text value representing the inline
 // markup structure.
 func (p *parser) parseText() Text {
-	// This is currently just a placeholder implementation that doesn't
-	// do any parsing of inline markup, since we don't yet have an inline
-	// markup parser.
-	result := make(Text, 0, 1)
+	var lines []string
+	var pos Position
 	for {
 		next := p.Peek()
 		if next.Type != LINE {
 			break
 		}
 		token := p.Read()
-		result = append(result, CharData(token.Data))
+		if lines == nil {
+			pos = token.Position
+		}
+		lines = append(lines, token.Data)
+	}
+	return parseInline(lines, pos)
+}
+
+// parseInline is the inline markup parser shared by ParseText and
+// parser.parseText. lines are the source lines to parse and pos is the
+// position of the start of the first line.
+func parseInline(lines []string, pos Position) Text {
+	// This is currently just a placeholder implementation that doesn't
+	// do any parsing of inline markup, since we don't yet have an inline
+	// markup parser. Once we do, pos will be the basis for the positions
+	// of the inline elements it produces.
+	var result Text
+	for _, line := range lines {
+		result = append(result, CharData(line))
 	}
 	return result
 }
 
+// directivePattern matches the start of the text following an explicit
+// markup start that makes it a directive rather than a comment.
+var directivePattern = regexp.MustCompile(`^[A-Za-z0-9]+(?:[-_+:.][A-Za-z0-9]+)*::(?:\s|$)`)
+
+// detectComment returns true if the given token is the first line of a
+// comment.
+//
+// A comment is any explicit markup block that is not one of the other
+// explicit markup constructs. Since the parser doesn't support those yet,
+// blocks that look like them are not treated as comments, and are instead
+// dealt with by parseUnsupportedMarkup.
+func (p *parser) detectComment(next *Token) bool {
+	if next.Type != EXPLICIT_MARKUP {
+		return false
+	}
+	rest := next.Data
+
+	switch {
+	case strings.HasPrefix(rest, "["): // footnote or citation
+		return false
+	case strings.HasPrefix(rest, "_"): // hyperlink target
+		return false
+	case strings.HasPrefix(rest, "|"): // substitution definition
+		return false
+	}
+	return !directivePattern.MatchString(rest)
+}
+
+// parseComment reads a comment, which detectComment must already have
+// recognized, along with the body that continues it.
+func (p *parser) parseComment() *Comment {
+	firstLine := p.Read()
+	comment := &Comment{Pos: firstLine.Position}
+
+	var lines []string
+	if first := firstLine.Data; first != "" {
+		lines = append(lines, first)
+
+		// Blank lines may separate the first line from the rest of the
+		// comment, but an empty comment ends at the first blank line.
+		for p.Peek().Type == BLANK {
+			p.Read()
+			lines = append(lines, "")
+		}
+	}
+	lines = append(lines, dedentLines(p.commentBlock())...)
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	comment.Text = strings.Join(lines, "\n")
+	return comment
+}
+
+// parseUnsupportedMarkup reads an explicit markup construct that the parser
+// doesn't support yet. Its first line becomes a paragraph, and its body a
+// block quote, which is how such constructs were parsed before the scanner
+// recognized explicit markup.
+func (p *parser) parseUnsupportedMarkup(m structureModel) {
+	firstLine := p.Read()
+	startPos := firstLine.Position
+	text := parseInline([]string{p.markupText(firstLine)}, startPos)
+	m.appendBody(&Paragraph{Text: text, Pos: startPos}, startPos)
+
+	p.SkipBlanks()
+	if p.Peek().Type == DEDENT {
+		p.Read() // the body is empty
+		return
+	}
+	body := p.parseBody(DEDENT)
+	if len(body) > 0 {
+		quote := newBlockQuote(body, startPos)
+		m.appendBody(quote, quote.Pos)
+	}
+}
+
+// markupText returns the text of the line that the given EXPLICIT_MARKUP
+// token represents, starting from the explicit markup start.
+func (p *parser) markupText(token *Token) string {
+	line := strings.TrimRightFunc(p.SourceLine(token.Position.Line), unicode.IsSpace)
+	if strings.HasSuffix(line, token.Data) {
+		line = line[:len(line)-len(token.Data)]
+		if i := strings.LastIndex(line, ".."); i >= 0 {
+			return line[i:] + token.Data
+		}
+	}
+	return strings.TrimSpace(".. " + token.Data)
+}
+
+// commentBlock reads the remainder of an indented block, including the
+// DEDENT that ends it, and returns its lines with their indentation. The
+// block is either the body of an explicit markup construct whose first line
+// has already been read, or one whose INDENT token has.
+func (p *parser) commentBlock() []string {
+	var lines []string
+	depth := 0
+
+	for {
+		next := p.Peek()
+
+		switch next.Type {
+		case EOF, ERROR:
+			return lines
+		case INDENT:
+			depth++
+		case LATE_INDENT:
+			if depth == 0 {
+				// The block has ended at an indent level that the
+				// enclosing context must deal with.
+				return lines
+			}
+		case DEDENT:
+			if depth == 0 {
+				p.Read()
+				return lines
+			}
+			depth--
+		case BLANK:
+			lines = append(lines, "")
+		case LINE:
+			lines = append(lines, strings.Repeat(" ", next.Position.Column-1)+next.Data)
+		case EXPLICIT_MARKUP:
+			// The body of nested explicit markup ends with a DEDENT
+			// but has no INDENT.
+			depth++
+			lines = append(lines, strings.Repeat(" ", next.Position.Column-1)+p.markupText(next))
+		case LITERAL:
+			lines = append(lines, expandTabs(strings.TrimRight(next.Data, "\b\t \f\v")))
+		}
+
+		p.Read()
+	}
+}
+
+// parseLiteralBlock parses a literal block from the LITERAL tokens that
+// follow, along with any blank lines between them.
+func (p *parser) parseLiteralBlock() *LiteralBlock {
+	pos := p.Peek().Position
+	var lines []string
+	blanks := 0
+	for {
+		next := p.Peek()
+		if next.Type == BLANK {
+			p.Read()
+			blanks++
+			continue
+		}
+		if next.Type != LITERAL {
+			break
+		}
+		for ; blanks > 0; blanks-- {
+			lines = append(lines, "")
+		}
+		lines = append(lines, expandTabs(p.Read().Data))
+	}
+
+	// Literal tokens begin at the start of their line, but the block
+	// begins where its text does.
+	pos.Column += commonIndent(lines)
+	return &LiteralBlock{
+		Text: strings.Join(dedentLines(lines), "\n"),
+		Pos:  pos,
+	}
+}
+
+// dedentLines removes the indentation that is common to all of the given
+// lines, ignoring blank lines.
+func dedentLines(lines []string) []string {
+	common := commonIndent(lines)
+	ret := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= common && common > 0 {
+			line = line[common:]
+		}
+		ret[i] = line
+	}
+	return ret
+}
+
+// commonIndent returns the number of leading spaces that all of the given
+// lines have in common, ignoring blank lines.
+func commonIndent(lines []string) int {
+	common := -1
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			continue
+		}
+		if indent := len(line) - len(trimmed); common < 0 || indent < common {
+			common = indent
+		}
+	}
+	if common < 0 {
+		return 0
+	}
+	return common
+}
+
 // Attempts to interpret the given token as the beginning of a bullet list
 // item.
 //
@@ -309,23 +1124,36 @@ func (p *parser) detectBulletListItem(next *Token) (marker rune, indent int) {
 	}
 
 	firstChar, firstCharLen := utf8.DecodeRuneInString(next.Data)
-	switch firstChar {
-	case '*', '+', '-', '•', '‣', '⁃':
-		// possibly a bullet list
-		nextChar, nextCharLen := utf8.DecodeRuneInString(next.Data[firstCharLen:])
+	if !p.isBulletRune(firstChar) {
+		return 0, 0
+	}
 
-		switch {
-		case nextChar == utf8.RuneError:
-			return firstChar, firstCharLen
-		case unicode.IsSpace(nextChar):
-			return firstChar, firstCharLen + nextCharLen
-		default:
-			return 0, 0
-		}
+	// possibly a bullet list
+	nextChar, nextCharLen := utf8.DecodeRuneInString(next.Data[firstCharLen:])
+
+	switch {
+	case nextChar == utf8.RuneError:
+		return firstChar, firstCharLen
+	case unicode.IsSpace(nextChar):
+		return firstChar, firstCharLen + nextCharLen
 	default:
 		return 0, 0
 	}
+}
 
+// isBulletRune returns true if the given character is one of the bullet
+// list markers selected by the parser's options.
+func (p *parser) isBulletRune(r rune) bool {
+	runes := p.opts.BulletRunes
+	if len(runes) == 0 {
+		runes = defaultBulletRunes
+	}
+	for _, bullet := range runes {
+		if r == bullet {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *parser) parseBulletList(marker rune) BodyElement {
@@ -339,6 +1167,9 @@ func (p *parser) parseBulletList(marker rune) BodyElement {
 			// next is either not a list item or belongs to a different list
 			break
 		}
+		if len(items) > 0 && !p.countElement(next.Position) {
+			break
+		}
 
 		firstLine := p.Read()
 
@@ -351,11 +1182,16 @@ func (p *parser) parseBulletList(marker rune) BodyElement {
 		p.PushBackSuffix(firstLine, indent)
 
 		itemContent := p.parseBody(DEDENT)
-		items = append(items, &ListItem{itemContent})
+		items = append(items, &ListItem{
+			Body: itemContent,
+			Pos:  firstLine.Position,
+		})
 	}
 
 	return &BulletList{
-		Items: items,
+		Bullet: string(marker),
+		Items:  items,
+		Pos:    items[0].Pos,
 	}
 }
 
@@ -382,10 +1218,31 @@ const (
 // If it is, returns the sequence, marker type, item ordinal, and indent level.
 // If it is not, returns 0, 0, 0, 0.
 //
-// If it is, returns the bullet list marker and the number of bytes
-// of indent to require for subsequent lines. If it is not, returns
-// (0, 0).
-func (p *parser) detectEnumeratedListItem(next *Token) (enumSeq, enumMarker, int, int) {
+// A single letter may be either alphabetic or a roman numeral, so expected
+// gives the sequence of the list that the item would continue, if any, to
+// resolve that ambiguity. Otherwise the letters "i" and "I" are taken to be
+// roman numerals and other single letters alphabetic, as in docutils.
+//
+// A letter enumerator begins an item only if enumeratorFollowed allows it,
+// except that with Options.PreferEnumeratedLists a single letter always
+// does.
+func (p *parser) detectEnumeratedListItem(next *Token, expected enumSeq) (enumSeq, enumMarker, int, int) {
+	seq, marker, ordinal, indent := p.scanEnumerator(next, expected)
+	if seq == enumSeqInvalid || seq == enumSeqArabic {
+		return seq, marker, ordinal, indent
+	}
+	if p.enumeratorFollowed(next, seq, marker, ordinal) {
+		return seq, marker, ordinal, indent
+	}
+	if p.opts.PreferEnumeratedLists && singleLetterEnumerator(seq, ordinal) {
+		return seq, marker, ordinal, indent
+	}
+	return 0, 0, 0, 0
+}
+
+// scanEnumerator implements detectEnumeratedListItem, except that it does
+// not consider the line after the given token.
+func (p *parser) scanEnumerator(next *Token, expected enumSeq) (enumSeq, enumMarker, int, int) {
 	if next.Type != LINE {
 		return 0, 0, 0, 0
 	}
@@ -411,15 +1268,12 @@ func (p *parser) detectEnumeratedListItem(next *Token) (enumSeq, enumMarker, int
 	switch {
 	case first >= '0' && first <= '9':
 		end := 0
-		for end = 0; end < len(remain); end++ {
-			if remain[end] < '0' || remain[end] > '9' {
-				break
-			}
+		for end < len(remain) && remain[end] >= '0' && remain[end] <= '9' {
 			end++
 		}
-		indent = indent + end - 1
-		num := remain[0 : end-1]
-		remain = remain[end-1:]
+		indent = indent + end
+		num := remain[:end]
+		remain = remain[end:]
 
 		var err error
 		ordinal, err = strconv.Atoi(num)
@@ -429,9 +1283,36 @@ func (p *parser) detectEnumeratedListItem(next *Token) (enumSeq, enumMarker, int
 
 		seq = enumSeqArabic
 
-		//case first >= 'A' && first <= 'Z':
+	case (first >= 'A' && first <= 'Z') || (first >= 'a' && first <= 'z'):
+		end := 0
+		for end < len(remain) && isASCIILetter(remain[end]) {
+			end++
+		}
+		indent = indent + end
+		letters := remain[:end]
+		remain = remain[end:]
+
+		upper := first <= 'Z'
+		alpha, roman := enumSeqAlphaLower, enumSeqRomanLower
+		if upper {
+			alpha, roman = enumSeqAlphaUpper, enumSeqRomanUpper
+		}
+		if strings.ToUpper(letters) != letters && strings.ToLower(letters) != letters {
+			// Mixed case is never an enumerator.
+			return 0, 0, 0, 0
+		}
+		romanOrdinal, isRoman := parseRoman(strings.ToUpper(letters))
 
-		//case first >= 'a' && first <= 'z':
+		switch {
+		case len(letters) == 1 && expected == alpha:
+			seq, ordinal = alpha, int(unicode.ToLower(rune(first))-'a'+1)
+		case isRoman && (expected == roman || len(letters) > 1 || first == 'i' || first == 'I'):
+			seq, ordinal = roman, romanOrdinal
+		case len(letters) == 1:
+			seq, ordinal = alpha, int(unicode.ToLower(rune(first))-'a'+1)
+		default:
+			return 0, 0, 0, 0
+		}
 
 	default:
 		return 0, 0, 0, 0
@@ -474,20 +1355,170 @@ func (p *parser) detectEnumeratedListItem(next *Token) (enumSeq, enumMarker, int
 
 }
 
+// enumeratorFollowed returns true if the line after the given token, which
+// begins with a letter enumerator, allows the token to begin an enumerated
+// list item. That is so if the line is blank, indented beyond the token, or
+// begins with the next enumerator in the same sequence. Otherwise the token
+// begins a paragraph that happens to start with something like an
+// enumerator, as in:
+//
+//	A. Einstein was a
+//	physicist.
+//
+// A line indented less than the token is the end of the enclosing block,
+// and so also allows an item.
+func (p *parser) enumeratorFollowed(token *Token, seq enumSeq, marker enumMarker, ordinal int) bool {
+	line, ok := p.lineAfter(token.Position.Line)
+	if !ok {
+		return true
+	}
+	text := strings.TrimLeft(line, " \t")
+	if text == "" {
+		return true
+	}
+	if indent := lineColumns(line[:len(line)-len(text)]); indent != token.Position.Column-1 {
+		return true
+	}
+	nextEnum := formatEnumerator(seq, marker, ordinal+1)
+	return nextEnum != "" && strings.HasPrefix(text, nextEnum)
+}
+
+// formatEnumerator returns the enumerator for the given ordinal in the
+// given sequence, with the given marker, or an empty string if the
+// sequence has no such ordinal.
+func formatEnumerator(seq enumSeq, marker enumMarker, ordinal int) string {
+	var enum string
+	switch seq {
+	case enumSeqArabic:
+		enum = strconv.Itoa(ordinal)
+	case enumSeqAlphaUpper, enumSeqAlphaLower:
+		if ordinal < 1 || ordinal > 26 {
+			return ""
+		}
+		enum = string(rune(seq) + rune(ordinal-1))
+	case enumSeqRomanUpper:
+		enum = formatRoman(ordinal)
+	case enumSeqRomanLower:
+		enum = strings.ToLower(formatRoman(ordinal))
+	}
+	if enum == "" {
+		return ""
+	}
+	switch marker {
+	case enumMarkerParens:
+		return "(" + enum + ")"
+	case enumMarkerRParen:
+		return enum + ")"
+	default:
+		return enum + "."
+	}
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+var romanNumerals = []struct {
+	Value   int
+	Numeral string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"},
+	{100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"},
+	{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+// formatRoman returns the given number as an uppercase roman numeral, or an
+// empty string if it is outside of the range 1 to 4999 that docutils
+// supports.
+func formatRoman(n int) string {
+	if n < 1 || n > 4999 {
+		return ""
+	}
+	var buf strings.Builder
+	for _, numeral := range romanNumerals {
+		for n >= numeral.Value {
+			buf.WriteString(numeral.Numeral)
+			n -= numeral.Value
+		}
+	}
+	return buf.String()
+}
+
+// parseRoman returns the value of the given uppercase roman numeral, or
+// false if it is not a roman numeral in its usual form.
+func parseRoman(s string) (int, bool) {
+	n := 0
+	rest := s
+	for _, numeral := range romanNumerals {
+		for strings.HasPrefix(rest, numeral.Numeral) {
+			n += numeral.Value
+			rest = rest[len(numeral.Numeral):]
+		}
+	}
+	if rest != "" || n == 0 || formatRoman(n) != s {
+		return 0, false
+	}
+	return n, true
+}
+
+// ambiguousEnumerator returns an informational message if the given token,
+// which is about to be parsed as the start of a paragraph, begins with a
+// single letter enumerator that was not taken as the start of a list only
+// because the next line continues the paragraph, as in:
+//
+//	A. Einstein was a
+//	physicist.
+//
+// Such a line is more often prose that begins with an initial than a list
+// with one item, but the message lets authors see why their list did not
+// appear. Options.PreferEnumeratedLists makes it a list instead. Otherwise
+// ambiguousEnumerator returns nil.
+func (p *parser) ambiguousEnumerator(next *Token) *Error {
+	seq, marker, start, _ := p.scanEnumerator(next, enumSeqInvalid)
+	if seq == enumSeqInvalid || !singleLetterEnumerator(seq, start) {
+		return nil
+	}
+	enum := formatEnumerator(seq, marker, start)
+	return &Error{
+		Message:  fmt.Sprintf("%q treated as the start of a paragraph rather than of a list, because the next line is not indented", enum),
+		Pos:      next.Position,
+		Severity: SeverityInfo,
+	}
+}
+
+// singleLetterEnumerator returns true if the enumerator for the given
+// ordinal in the given sequence is a single letter, and so might instead
+// be an initial.
+func singleLetterEnumerator(seq enumSeq, ordinal int) bool {
+	switch seq {
+	case enumSeqAlphaUpper, enumSeqAlphaLower:
+		return true
+	case enumSeqRomanUpper, enumSeqRomanLower:
+		return len(formatRoman(ordinal)) == 1
+	default:
+		return false
+	}
+}
+
+// parseEnumeratedList parses an enumerated list whose first item has the
+// given sequence, marker and ordinal.
 func (p *parser) parseEnumeratedList(seq enumSeq, marker enumMarker, start int) BodyElement {
 	nextOrd := start
 	items := make([]*ListItem, 0, 2)
 	for {
 		p.SkipBlanks()
 		next := p.Peek()
-		itemSeq, itemMarker, ord, indent := p.detectEnumeratedListItem(next)
+		itemSeq, itemMarker, ord, indent := p.detectEnumeratedListItem(next, seq)
 		if itemSeq != seq || itemMarker != marker || ord != nextOrd {
 			// next is either not a list item or belongs to a different list
 			break
 		}
+		if len(items) > 0 && !p.countElement(next.Position) {
+			break
+		}
 		nextOrd++
 
-		firstLine := p.Read()
+		itemLine := p.Read()
 
 		// Let the scanner know that the subsequent lines will be indented
 		// to align with the first character of the first line.
@@ -495,15 +1526,19 @@ func (p *parser) parseEnumeratedList(seq enumSeq, marker enumMarker, start int)
 
 		// Push back our first-line token with the prefix removed
 		// so that p.parseBody can re-read it.
-		p.PushBackSuffix(firstLine, indent)
+		p.PushBackSuffix(itemLine, indent)
 
 		itemContent := p.parseBody(DEDENT)
-		items = append(items, &ListItem{itemContent})
+		items = append(items, &ListItem{
+			Body: itemContent,
+			Pos:  itemLine.Position,
+		})
 	}
 
 	list := &EnumeratedList{
 		Items:      items,
 		FirstIndex: start,
+		Pos:        items[0].Pos,
 	}
 
 	switch seq {
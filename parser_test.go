@@ -1,12 +1,16 @@
 package rst
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
-
-	"github.com/davecgh/go-spew/spew"
+	"time"
+	"unsafe"
 )
 
 const testParserFilename = "test.rst"
@@ -18,13 +22,17 @@ func TestParseFragment(t *testing.T) {
 	}{
 		{
 			"",
-			&Fragment{},
+			&Fragment{
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
+			},
 		},
 		{
 			"* foo",
 			&Fragment{
 				Body: Body{
 					&BulletList{
+						Bullet: "*",
 						Items: []*ListItem{
 							{
 								Body: Body{
@@ -32,12 +40,36 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("foo"),
 										},
+										Pos: Position{Line: 1, Column: 3, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
 							},
 						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
 					},
 				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
+			},
+		},
+		{
+			"*",
+			&Fragment{
+				Body: Body{
+					&BulletList{
+						Bullet: "*",
+						Items: []*ListItem{
+							{
+								Body: nil,
+								Pos:  Position{Line: 1, Column: 1, Filename: testParserFilename},
+							},
+						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+					},
+				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
 			},
 		},
 		{
@@ -45,6 +77,7 @@ func TestParseFragment(t *testing.T) {
 			&Fragment{
 				Body: Body{
 					&BulletList{
+						Bullet: "*",
 						Items: []*ListItem{
 							{
 								Body: Body{
@@ -52,8 +85,10 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("foo"),
 										},
+										Pos: Position{Line: 1, Column: 3, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
 							},
 							{
 								Body: Body{
@@ -61,12 +96,17 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("bar"),
 										},
+										Pos: Position{Line: 2, Column: 3, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 2, Column: 1, Filename: testParserFilename},
 							},
 						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
 					},
 				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
 			},
 		},
 		{
@@ -85,8 +125,10 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("foo"),
 										},
+										Pos: Position{Line: 1, Column: 4, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
 							},
 							{
 								Body: Body{
@@ -94,10 +136,13 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("bar"),
 										},
+										Pos: Position{Line: 2, Column: 4, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 2, Column: 1, Filename: testParserFilename},
 							},
 						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
 					},
 					&EnumeratedList{
 						EnumType:   EnumArabic,
@@ -111,10 +156,13 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("baz"),
 										},
+										Pos: Position{Line: 3, Column: 5, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 3, Column: 1, Filename: testParserFilename},
 							},
 						},
+						Pos: Position{Line: 3, Column: 1, Filename: testParserFilename},
 					},
 					&EnumeratedList{
 						EnumType:   EnumArabic,
@@ -128,10 +176,13 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("pizza"),
 										},
+										Pos: Position{Line: 4, Column: 5, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 4, Column: 1, Filename: testParserFilename},
 							},
 						},
+						Pos: Position{Line: 4, Column: 1, Filename: testParserFilename},
 					},
 					&EnumeratedList{
 						EnumType:   EnumArabic,
@@ -145,12 +196,57 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("cheese"),
 										},
+										Pos: Position{Line: 5, Column: 4, Filename: testParserFilename},
+									},
+								},
+								Pos: Position{Line: 5, Column: 1, Filename: testParserFilename},
+							},
+						},
+						Pos: Position{Line: 5, Column: 1, Filename: testParserFilename},
+					},
+				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
+			},
+		},
+		{
+			"9. foo\n10. bar",
+			&Fragment{
+				Body: Body{
+					&EnumeratedList{
+						EnumType:   EnumArabic,
+						EnumPrefix: "",
+						EnumSuffix: ".",
+						FirstIndex: 9,
+						Items: []*ListItem{
+							{
+								Body: Body{
+									&Paragraph{
+										Text: Text{
+											CharData("foo"),
+										},
+										Pos: Position{Line: 1, Column: 4, Filename: testParserFilename},
+									},
+								},
+								Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+							},
+							{
+								Body: Body{
+									&Paragraph{
+										Text: Text{
+											CharData("bar"),
+										},
+										Pos: Position{Line: 2, Column: 5, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 2, Column: 1, Filename: testParserFilename},
 							},
 						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
 					},
 				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
 			},
 		},
 		{
@@ -164,10 +260,14 @@ func TestParseFragment(t *testing.T) {
 									CharData("blockquote"),
 									CharData("baz"),
 								},
+								Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
 							},
 						},
+						Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
 					},
 				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
 			},
 		},
 		{
@@ -182,17 +282,23 @@ func TestParseFragment(t *testing.T) {
 										Text: Text{
 											CharData("nested-blockquote"),
 										},
+										Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
 									},
 								},
+								Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
 							},
 							&Paragraph{
 								Text: Text{
 									CharData("baz"),
 								},
+								Pos: Position{Line: 2, Column: 3, Filename: testParserFilename},
 							},
 						},
+						Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
 					},
 				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
 			},
 		},
 		{
@@ -205,37 +311,977 @@ func TestParseFragment(t *testing.T) {
 								Text: Text{
 									CharData("quote"),
 								},
+								Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
 							},
 						},
 						Attribution: Text{
 							CharData("attribution"),
 						},
+						Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
 					},
 				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
+			},
+		},
+		{
+			"    quote\n\tnested",
+			&Fragment{
+				Body: Body{
+					&BlockQuote{
+						Quote: Body{
+							&Paragraph{
+								Text: Text{
+									CharData("quote"),
+								},
+								Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
+							},
+							&Error{
+								Message:  "inconsistent use of tabs and spaces in indentation; compare with test.rst:1:1",
+								Pos:      Position{Line: 2, Column: 1, Filename: testParserFilename},
+								Severity: SeverityWarning,
+								Source:   "\tnested",
+							},
+							&BlockQuote{
+								Quote: Body{
+									&Paragraph{
+										Text: Text{
+											CharData("nested"),
+										},
+										Pos: Position{Line: 2, Column: 9, Filename: testParserFilename},
+									},
+								},
+								Pos: Position{Line: 2, Column: 9, Filename: testParserFilename},
+							},
+						},
+						Pos: Position{Line: 1, Column: 5, Filename: testParserFilename},
+					},
+				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
+			},
+		},
+		{
+			"before::\n\n    literal 1\n    literal 2\n\nafter",
+			&Fragment{
+				Body: Body{
+					&Paragraph{
+						Text: Text{
+							CharData("before:"),
+						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+					},
+					&LiteralBlock{
+						Text: "literal 1\nliteral 2",
+						Pos:  Position{Line: 3, Column: 5, Filename: testParserFilename},
+					},
+					&Paragraph{
+						Text: Text{
+							CharData("after"),
+						},
+						Pos: Position{Line: 6, Column: 1, Filename: testParserFilename},
+					},
+				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
+			},
+		},
+		{
+			"before::  \n\n    literal 1  \n    literal 2\t\n\nafter  ",
+			&Fragment{
+				Body: Body{
+					&Paragraph{
+						Text: Text{
+							CharData("before:"),
+						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+					},
+					&LiteralBlock{
+						Text: "literal 1  \nliteral 2   ",
+						Pos:  Position{Line: 3, Column: 5, Filename: testParserFilename},
+					},
+					&Paragraph{
+						Text: Text{
+							CharData("after"),
+						},
+						Pos: Position{Line: 6, Column: 1, Filename: testParserFilename},
+					},
+				},
+				Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+				SourceName: testParserFilename,
 			},
 		},
 	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			// All of the entry points must produce the same result when
+			// given the default options.
+			entryPoints := map[string]func() *Fragment{
+				"ParseFragment": func() *Fragment {
+					return ParseFragment(strings.NewReader(test.Input), testParserFilename)
+				},
+				"ParseFragment with zero Options": func() *Fragment {
+					return ParseFragment(strings.NewReader(test.Input), testParserFilename, Options{})
+				},
+				"Parser.ParseFragment": func() *Fragment {
+					p := &Parser{}
+					return p.ParseFragment(strings.NewReader(test.Input), testParserFilename)
+				},
+				"ParseFragmentString": func() *Fragment {
+					return ParseFragmentString(test.Input, testParserFilename)
+				},
+				"ParseFragmentBytes": func() *Fragment {
+					return ParseFragmentBytes([]byte(test.Input), testParserFilename)
+				},
+			}
 
-	spewConfig := &spew.ConfigState{
-		Indent:                  "    ",
-		SortKeys:                true,
-		DisablePointerAddresses: true,
-		DisableCapacities:       true,
+			for name, parse := range entryPoints {
+				got := parse()
+
+				if diff := Diff(test.Want, got); diff != "" {
+					t.Errorf(
+						"incorrect result from %s for %q\n%s\ngot:\n%s\nwant:\n%s",
+						name, test.Input, diff, DumpString(got), DumpString(test.Want),
+					)
+				}
+			}
+		})
 	}
 
-	for i, test := range tests {
-		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
-			r := strings.NewReader(test.Input)
-			got := ParseFragment(r, testParserFilename)
+}
+
+func TestParseFragmentLimits(t *testing.T) {
+	r := strings.NewReader("* a\n\n  * b\n\n    * c")
+	got := ParseFragment(r, testParserFilename, Options{
+		Limits: Limits{MaxIndentDepth: 2},
+	})
+	want := &Fragment{
+		Body: Body{
+			&BulletList{
+				Bullet: "*",
+				Items: []*ListItem{
+					{
+						Body: Body{
+							&Paragraph{
+								Text: Text{
+									CharData("a"),
+								},
+								Pos: Position{Line: 1, Column: 3, Filename: testParserFilename},
+							},
+							&BulletList{
+								Bullet: "*",
+								Items: []*ListItem{
+									{
+										Body: Body{
+											&Paragraph{
+												Text: Text{
+													CharData("b"),
+												},
+												Pos: Position{Line: 3, Column: 5, Filename: testParserFilename},
+											},
+											&BulletList{
+												Bullet: "*",
+												Items: []*ListItem{
+													{
+														Body: Body{
+															&Error{
+																Message: "indentation exceeds maximum depth of 2",
+																Pos:     Position{Line: 5, Column: 7, Filename: testParserFilename},
+																Source:  "    * c",
+															},
+														},
+														Pos: Position{Line: 5, Column: 5, Filename: testParserFilename},
+													},
+												},
+												Pos: Position{Line: 5, Column: 5, Filename: testParserFilename},
+											},
+										},
+										Pos: Position{Line: 3, Column: 3, Filename: testParserFilename},
+									},
+								},
+								Pos: Position{Line: 3, Column: 3, Filename: testParserFilename},
+							},
+						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+					},
+				},
+				Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+			},
+		},
+		Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+		SourceName: testParserFilename,
+	}
+
+	if diff := Diff(want, got); diff != "" {
+		t.Errorf("incorrect result\n%s", diff)
+	}
+}
+
+// failingReader returns the first N bytes of its data and then fails.
+type failingReader struct {
+	Data []byte
+	N    int
+}
+
+var errTestRead = errors.New("read failed")
+
+func (r *failingReader) Read(buf []byte) (int, error) {
+	if r.N <= 0 {
+		return 0, errTestRead
+	}
+	n := copy(buf, r.Data[:r.N])
+	r.Data = r.Data[n:]
+	r.N -= n
+	return n, nil
+}
+
+func TestParseFragmentErr(t *testing.T) {
+	src := []byte("* foo\n\n* bar\n")
+
+	t.Run("read error", func(t *testing.T) {
+		r := &failingReader{Data: src, N: 8}
+		got, err := ParseFragmentErr(r, testParserFilename)
+		if err != errTestRead {
+			t.Errorf("wrong error %#v; want %#v", err, errTestRead)
+		}
+		if got != nil {
+			t.Errorf("got non-nil fragment with error")
+		}
+	})
+
+	t.Run("markup problem", func(t *testing.T) {
+		r := strings.NewReader("    foo\n\tbar")
+		got, err := ParseFragmentErr(r, testParserFilename)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		quote := got.Body[0].(*BlockQuote)
+		if _, isErr := quote.Quote[1].(*Error); !isErr {
+			t.Errorf("second element is %T; want *Error", quote.Quote[1])
+		}
+	})
+}
+
+func TestFragmentAllErrors(t *testing.T) {
+	fragment := ParseFragmentString(
+		"    a\n\tb\n\nc::\n    literal",
+		testParserFilename,
+	)
+	got := fragment.AllErrors()
+
+	var gotMsgs []string
+	for _, err := range got {
+		gotMsgs = append(gotMsgs, fmt.Sprintf("%s: %s", err.Pos, err.Message))
+	}
+	wantMsgs := []string{
+		"test.rst:2:1: inconsistent use of tabs and spaces in indentation; compare with test.rst:1:1",
+		"test.rst:5:1: unexpected token: LITERAL",
+	}
+
+	if !reflect.DeepEqual(gotMsgs, wantMsgs) {
+		t.Errorf("wrong errors\ngot:  %#v\nwant: %#v", gotMsgs, wantMsgs)
+	}
+}
+
+func TestParseFragmentErrorSource(t *testing.T) {
+	// The error is reported at the first of the unexpected lines, only
+	// after the parser has skipped all of them, and the nesting error
+	// only after its whole block has been skipped.
+	var buf strings.Builder
+	buf.WriteString("c::\n  first\n")
+	for i := 0; i < 100; i++ {
+		buf.WriteString("  more\n")
+	}
+	buf.WriteString("\nx\n  y\n    z\n")
+	for i := 0; i < 100; i++ {
+		buf.WriteString("    more\n")
+	}
+	src := buf.String()
+	opts := Options{MaxNestingDepth: 1}
 
-			if !reflect.DeepEqual(got, test.Want) {
-				t.Errorf(
-					"\nincorrect result for %q\ngot:  %s\nwant: %s",
-					test.Input,
-					spewConfig.Sdump(got), spewConfig.Sdump(test.Want),
-				)
+	for name, fragment := range map[string]*Fragment{
+		"string": ParseFragmentString(src, testParserFilename, opts),
+		"reader": ParseFragment(strings.NewReader(src), testParserFilename, opts),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, err := range AllErrors(fragment) {
+				got = append(got, fmt.Sprintf("%d: %q", err.Pos.Line, err.Source))
+			}
+			want := []string{
+				`2: "  first"`,
+				`105: "  y"`,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("wrong error sources\ngot:  %q\nwant: %q", got, want)
 			}
 		})
 	}
+}
+
+func TestParserRetainedLines(t *testing.T) {
+	// The scanner keeps only the lines that the parser is still working
+	// on, rather than the whole input.
+	var buf strings.Builder
+	for i := 0; i < 1000; i++ {
+		buf.WriteString("word\n")
+	}
+	p := newParser(strings.NewReader(buf.String()), testParserFilename, Options{})
+	p.ParseFragment()
+	if got := len(p.lines); got > 2 {
+		t.Errorf("scanner retains %d lines after parsing", got)
+	}
+}
+
+func TestParseFragmentNestingDepth(t *testing.T) {
+	const levels = 10000
+
+	var buf bytes.Buffer
+	for i := 0; i < levels; i++ {
+		buf.WriteString(strings.Repeat(" ", i))
+		buf.WriteString("x\n")
+	}
+
+	done := make(chan *Fragment)
+	go func() {
+		done <- ParseFragmentBytes(buf.Bytes(), testParserFilename)
+	}()
+
+	var fragment *Fragment
+	select {
+	case fragment = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("parser did not return promptly")
+	}
+
+	errs := fragment.AllErrors()
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors; want 1", len(errs))
+	}
+
+	err := errs[0]
+	if got, want := err.Message, "content is nested more than 100 levels deep"; got != want {
+		t.Errorf("wrong message %q; want %q", got, want)
+	}
+	wantPos := Position{
+		Line:     DefaultMaxNestingDepth + 1,
+		Column:   DefaultMaxNestingDepth + 1,
+		Filename: testParserFilename,
+	}
+	if got := err.Pos; got != wantPos {
+		t.Errorf("wrong position %s; want %s", got, wantPos)
+	}
+	if got, want := strings.Count(err.Skipped, "\n")+1, levels-DefaultMaxNestingDepth; got != want {
+		t.Errorf("skipped %d lines; want %d", got, want)
+	}
+}
+
+func TestParseFragmentLateIndentDepth(t *testing.T) {
+	// Each line is indented one column less than the one before it, so
+	// each produces a LATE_INDENT that would wrap everything before it in
+	// another block quote.
+	const levels = 3000
+
+	var buf bytes.Buffer
+	for i := levels; i > 0; i-- {
+		buf.WriteString(strings.Repeat(" ", i))
+		buf.WriteString("x\n\n")
+	}
+
+	done := make(chan *Fragment)
+	go func() {
+		done <- ParseFragmentBytes(buf.Bytes(), testParserFilename)
+	}()
+
+	var fragment *Fragment
+	select {
+	case fragment = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("parser did not return promptly")
+	}
+
+	errs := fragment.AllErrors()
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors; want 1", len(errs))
+	}
+	if got, want := errs[0].Message, "content is nested more than 100 levels deep"; got != want {
+		t.Errorf("wrong message %q; want %q", got, want)
+	}
+
+	// The quotes may be nested to the same depth as the nesting limit
+	// allows for explicitly-indented content, and no more.
+	var quoteDepth func(body Body) int
+	quoteDepth = func(body Body) int {
+		deepest := 0
+		for _, elem := range body {
+			if quote, ok := elem.(*BlockQuote); ok {
+				if depth := quoteDepth(quote.Quote) + 1; depth > deepest {
+					deepest = depth
+				}
+			}
+		}
+		return deepest
+	}
+	if got, want := quoteDepth(fragment.Body), DefaultMaxNestingDepth-1; got != want {
+		t.Errorf("block quotes nested %d levels deep; want %d", got, want)
+	}
+
+	// None of the content is lost.
+	if got, want := len(Find[*Paragraph](fragment)), levels; got != want {
+		t.Errorf("got %d paragraphs; want %d", got, want)
+	}
+}
 
+// cancellingReader cancels a context once a given number of bytes have been
+// read from it.
+type cancellingReader struct {
+	R      io.Reader
+	N      int
+	Cancel context.CancelFunc
+}
+
+func (r *cancellingReader) Read(buf []byte) (int, error) {
+	n, err := r.R.Read(buf)
+	r.N -= n
+	if r.N <= 0 {
+		r.Cancel()
+	}
+	return n, err
+}
+
+func TestParseFragmentContext(t *testing.T) {
+	const paragraphs = 100000
+	src := strings.Repeat("paragraph\n\n", paragraphs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &cancellingReader{
+		R:      strings.NewReader(src),
+		N:      len(src) / 2,
+		Cancel: cancel,
+	}
+
+	fragment, err := ParseFragmentContext(ctx, r, testParserFilename)
+	if err != context.Canceled {
+		t.Fatalf("wrong error %#v; want %#v", err, context.Canceled)
+	}
+	if fragment == nil {
+		t.Fatalf("no partial fragment returned")
+	}
+	if got := len(fragment.Body); got >= paragraphs {
+		t.Errorf("parsed %d elements; want fewer than %d", got, paragraphs)
+	}
+
+	fragment, err = ParseFragmentContext(context.Background(), strings.NewReader(src), testParserFilename)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := len(fragment.Body), paragraphs; got != want {
+		t.Errorf("parsed %d elements; want %d", got, want)
+	}
+}
+
+func TestParseFragmentParagraph(t *testing.T) {
+	// Every exported entry point must be able to parse a simple paragraph
+	// without panicking.
+	const src = "hello world"
+	want := &Fragment{
+		Body: Body{
+			&Paragraph{
+				Text: Text{
+					CharData("hello world"),
+				},
+				Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+			},
+		},
+		Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+		SourceName: testParserFilename,
+	}
+
+	parser := &Parser{}
+	entryPoints := map[string]func() (*Fragment, error){
+		"ParseFragment": func() (*Fragment, error) {
+			return ParseFragment(strings.NewReader(src), testParserFilename), nil
+		},
+		"ParseFragmentErr": func() (*Fragment, error) {
+			return ParseFragmentErr(strings.NewReader(src), testParserFilename)
+		},
+		"ParseFragmentContext": func() (*Fragment, error) {
+			return ParseFragmentContext(context.Background(), strings.NewReader(src), testParserFilename)
+		},
+		"ParseFragmentString": func() (*Fragment, error) {
+			return ParseFragmentString(src, testParserFilename), nil
+		},
+		"ParseFragmentBytes": func() (*Fragment, error) {
+			return ParseFragmentBytes([]byte(src), testParserFilename), nil
+		},
+		"Parser.ParseFragment": func() (*Fragment, error) {
+			return parser.ParseFragment(strings.NewReader(src), testParserFilename), nil
+		},
+		"Parser.ParseFragmentErr": func() (*Fragment, error) {
+			return parser.ParseFragmentErr(strings.NewReader(src), testParserFilename)
+		},
+		"Parser.ParseFragmentContext": func() (*Fragment, error) {
+			return parser.ParseFragmentContext(context.Background(), strings.NewReader(src), testParserFilename)
+		},
+	}
+
+	for name, parse := range entryPoints {
+		t.Run(name, func(t *testing.T) {
+			got, err := parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := Diff(want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseText(t *testing.T) {
+	pos := Position{Line: 3, Column: 5, Filename: testParserFilename}
+	tests := []struct {
+		Input string
+		Want  Text
+	}{
+		{
+			"",
+			nil,
+		},
+		{
+			"hello world",
+			Text{
+				CharData("hello world"),
+			},
+		},
+		{
+			"hello\nworld",
+			Text{
+				CharData("hello"),
+				CharData("world"),
+			},
+		},
+		{
+			// Block markup is not recognized in inline-only parsing.
+			"* not a list item",
+			Text{
+				CharData("* not a list item"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Input, func(t *testing.T) {
+			got := ParseText(test.Input, pos)
+			if diff := Diff(test.Want, got); diff != "" {
+				t.Errorf("incorrect result for %q\n%s", test.Input, diff)
+			}
+		})
+	}
+}
+
+func TestParseFragmentBulletRunes(t *testing.T) {
+	kinds := func(fragment *Fragment) []string {
+		var ret []string
+		for _, elem := range fragment.Body {
+			switch n := elem.(type) {
+			case *BulletList:
+				ret = append(ret, fmt.Sprintf("list %s x%d", n.Bullet, len(n.Items)))
+			default:
+				ret = append(ret, fmt.Sprintf("%T", n))
+			}
+		}
+		return ret
+	}
+
+	tests := []struct {
+		Name  string
+		Input string
+		Runes []rune
+		Want  []string
+	}{
+		{
+			"default",
+			"· a\n· b\n\n• c\n",
+			nil,
+			[]string{"*rst.Paragraph", "list • x1"},
+		},
+		{
+			"custom",
+			"· a\n· b\n\n∙ c\n\n* d\n",
+			[]rune{'·', '∙'},
+			[]string{"list · x2", "list ∙ x1", "*rst.Paragraph"},
+		},
+		{
+			"restricted",
+			"* a\n* b\n\n• c\n",
+			[]rune{'*', '-', '+'},
+			[]string{"list * x2", "*rst.Paragraph"},
+		},
+		{
+			"same marker rule",
+			"- a\n+ b\n",
+			[]rune{'*', '-', '+'},
+			[]string{"list - x1", "list + x1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			fragment := ParseFragmentString(test.Input, testParserFilename, Options{
+				BulletRunes: test.Runes,
+			})
+			if got := kinds(fragment); !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong result\ngot:  %q\nwant: %q", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestParseFragmentEnumerators(t *testing.T) {
+	kinds := func(body Body) []string {
+		var ret []string
+		for _, elem := range body {
+			switch n := elem.(type) {
+			case *EnumeratedList:
+				ret = append(ret, fmt.Sprintf("list %s %d x%d", n.EnumType, n.FirstIndex, len(n.Items)))
+			case *Paragraph:
+				ret = append(ret, fmt.Sprintf("paragraph %q", n.Text.String()))
+			case *Error:
+				_, typ := docutilsLevel(n.Severity)
+				ret = append(ret, fmt.Sprintf("%s %d", typ, n.Pos.Line))
+			default:
+				ret = append(ret, fmt.Sprintf("%T", n))
+			}
+		}
+		return ret
+	}
+
+	tests := []struct {
+		Name   string
+		Input  string
+		Want   []string
+		Prefer []string
+	}{
+		{
+			"alphabetic",
+			"A. first\nB. second\n",
+			[]string{"list upperalpha 1 x2"},
+			nil,
+		},
+		{
+			"roman",
+			"(i) first\n(ii) second\n\n(iii) third\n",
+			[]string{"list lowerroman 1 x3"},
+			nil,
+		},
+		{
+			"alphabetic i",
+			"h) eighth\ni) ninth\n",
+			[]string{"list loweralpha 8 x2"},
+			nil,
+		},
+		{
+			"mixed case",
+			"Iv. not roman\n",
+			[]string{`paragraph "Iv. not roman"`},
+			nil,
+		},
+		{
+			"second line not indented",
+			"A. Einstein was a\nphysicist.\n",
+			[]string{`paragraph "A. Einstein was a physicist."`, "INFO 1"},
+			[]string{"list upperalpha 1 x1", `paragraph "physicist."`},
+		},
+		{
+			"second line not indented roman",
+			"I. Newton was a\nphysicist.\n",
+			[]string{`paragraph "I. Newton was a physicist."`, "INFO 1"},
+			[]string{"list upperroman 1 x1", `paragraph "physicist."`},
+		},
+		{
+			"second line not indented multi-letter roman",
+			"iv. fourth\ncontinued\n",
+			[]string{`paragraph "iv. fourth continued"`},
+			nil,
+		},
+		{
+			"second line indented",
+			"A. Einstein was a\n   physicist.\n\nB. Second\n",
+			[]string{"list upperalpha 1 x2"},
+			nil,
+		},
+		{
+			"lone single letter",
+			"A. foo\n",
+			[]string{"list upperalpha 1 x1"},
+			nil,
+		},
+		{
+			"lone single letter in parentheses",
+			"(a) foo\n",
+			[]string{"list loweralpha 1 x1"},
+			nil,
+		},
+		{
+			"lone single letter before paragraph",
+			"A. Smith wrote:\n\nThe rest.\n",
+			[]string{"list upperalpha 1 x1", `paragraph "The rest."`},
+			nil,
+		},
+		{
+			"lone roman numeral",
+			"i. roman\n",
+			[]string{"list lowerroman 1 x1"},
+			nil,
+		},
+		{
+			"lone item with more content",
+			"a. Smith wrote:\n\n   More content.\n",
+			[]string{"list loweralpha 1 x1"},
+			nil,
+		},
+		{
+			"lone multi-letter roman numeral",
+			"iv. fourth\n",
+			[]string{"list lowerroman 4 x1"},
+			nil,
+		},
+		{
+			"lone arabic",
+			"1. first\n",
+			[]string{"list arabic 1 x1"},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			fragment := ParseFragmentString(test.Input, testParserFilename)
+			if got := kinds(fragment.Body); !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong result\ngot:  %q\nwant: %q", got, test.Want)
+			}
+
+			want := test.Prefer
+			if want == nil {
+				want = test.Want
+			}
+			fragment = ParseFragmentString(test.Input, testParserFilename, Options{
+				PreferEnumeratedLists: true,
+			})
+			if got := kinds(fragment.Body); !reflect.DeepEqual(got, want) {
+				t.Errorf("wrong result with PreferEnumeratedLists\ngot:  %q\nwant: %q", got, want)
+			}
+		})
+	}
+
+	// The rule applies to nested items too, relative to their indentation.
+	fragment := ParseFragmentString("1. A. Einstein\n   was a physicist.\n", testParserFilename)
+	item := fragment.Body[0].(*EnumeratedList).Items[0]
+	if got, want := kinds(item.Body), []string{`paragraph "A. Einstein was a physicist."`, "INFO 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong nested result\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestParseFragmentElementLimit(t *testing.T) {
+	got := ParseFragmentString("* a\n* b\n* c\n* d\n", testParserFilename, Options{
+		Limits: Limits{MaxElements: 3},
+	})
+	want := &Fragment{
+		Body: Body{
+			&BulletList{
+				Bullet: "*",
+				Items: []*ListItem{
+					{
+						Body: Body{
+							&Paragraph{
+								Text: Text{CharData("a")},
+								Pos:  Position{Line: 1, Column: 3, Filename: testParserFilename},
+							},
+						},
+						Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+					},
+					{
+						Body: Body{
+							&Error{
+								Message: "document exceeds maximum of 3 elements",
+								Pos:     Position{Line: 2, Column: 3, Filename: testParserFilename},
+								Source:  "* b",
+							},
+						},
+						Pos: Position{Line: 2, Column: 1, Filename: testParserFilename},
+					},
+				},
+				Pos: Position{Line: 1, Column: 1, Filename: testParserFilename},
+			},
+		},
+		Pos:        Position{Line: 1, Column: 1, Filename: testParserFilename},
+		SourceName: testParserFilename,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\n%s", Diff(want, got))
+	}
+
+	// A long list is cut off between items.
+	src := strings.Repeat("* \n", 1000)
+	got = ParseFragmentString(src, testParserFilename, Options{
+		Limits: Limits{MaxElements: 10},
+	})
+	if got, want := len(got.Body[0].(*BulletList).Items), 10; got != want {
+		t.Errorf("list has %d items; want %d", got, want)
+	}
+	errs := AllErrors(got)
+	if len(errs) != 1 || errs[0].Message != "document exceeds maximum of 10 elements" {
+		t.Errorf("wrong errors %#v", errs)
+	}
+}
+
+func TestParseFragmentErrorLimit(t *testing.T) {
+	src := "a::\n  x\n\nb::\n  y\n\nc::\n  z\n\nd\n"
+	got := ParseFragmentString(src, testParserFilename, Options{
+		Limits: Limits{MaxErrors: 2},
+	})
+
+	var messages []string
+	for _, err := range AllErrors(got) {
+		messages = append(messages, fmt.Sprintf("%d: %s", err.Pos.Line, err.Message))
+	}
+	want := []string{
+		"2: unexpected token: LITERAL",
+		"5: unexpected token: LITERAL",
+		"8: document exceeds maximum of 2 errors",
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("wrong errors\ngot:  %q\nwant: %q", messages, want)
+	}
+
+	// Parsing stops at the limit, so the final paragraph is absent.
+	if got, want := len(got.Body), 6; got != want {
+		t.Errorf("fragment has %d elements; want %d", got, want)
+	}
+
+	// Without the limit, all of the errors are reported.
+	got = ParseFragmentString(src, testParserFilename)
+	if got, want := len(AllErrors(got)), 3; got != want {
+		t.Errorf("got %d errors without limit; want %d", got, want)
+	}
+}
+
+func TestParseFragmentErrStrict(t *testing.T) {
+	const src = "a::\n  x\n"
+
+	frag, err := ParseFragmentErr(strings.NewReader(src), testParserFilename)
+	if err != nil || frag == nil {
+		t.Fatalf("unexpected result without StrictErrors: %#v, %v", frag, err)
+	}
+
+	frag, err = ParseFragmentErr(strings.NewReader(src), testParserFilename, Options{StrictErrors: true})
+	if frag != nil {
+		t.Errorf("fragment returned with error")
+	}
+	var rstErr *Error
+	if !errors.As(err, &rstErr) {
+		t.Fatalf("wrong error %#v", err)
+	}
+	if got, want := rstErr.Pos.Line, 2; got != want {
+		t.Errorf("error on line %d; want %d", got, want)
+	}
+
+	// Warnings alone don't fail.
+	frag, err = ParseFragmentErr(strings.NewReader("    a\n\tb\n"), testParserFilename, Options{StrictErrors: true})
+	if err != nil {
+		t.Errorf("unexpected error for warning: %s", err)
+	}
+	if errs := AllErrors(frag); len(errs) != 1 || errs[0].Severity != SeverityWarning {
+		t.Errorf("wrong errors %#v", errs)
+	}
+}
+
+// TestParseFragmentSharesFilename checks that every position in the tree
+// refers to the same copy of the filename, so that positions cost only a
+// string header each however long the filename is.
+func TestParseFragmentSharesFilename(t *testing.T) {
+	filename := strings.Repeat("long/path/", 10) + "doc.rst"
+	frag := ParseFragmentString("a\n\n* b\n\n  c\n\n    d\n\n1. e\n", filename)
+
+	count := 0
+	Walk(frag, func(node interface{}) bool {
+		if node, ok := node.(Node); ok {
+			pos := node.Position()
+			if unsafe.StringData(pos.Filename) != unsafe.StringData(filename) {
+				t.Errorf("%T at %d:%d has its own copy of the filename", node, pos.Line, pos.Column)
+			}
+			count++
+		}
+		return true
+	})
+	if count < 8 {
+		t.Errorf("only %d nodes visited", count)
+	}
+}
+
+func TestParseFragmentOnToken(t *testing.T) {
+	const src = "Intro\nparagraph.\n\n* item one\n  continues\n\n    quoted\n\n* item two\n"
+
+	var tokens []*Token
+	frag := ParseFragmentString(src, testParserFilename, Options{
+		OnToken: func(token *Token) {
+			tokens = append(tokens, token)
+		},
+	})
+
+	// Each source line is reported once, as a LINE or BLANK token.
+	lines := make(map[int]int)
+	var types []string
+	for _, token := range tokens {
+		switch token.Type {
+		case LINE, BLANK:
+			lines[token.Position.Line]++
+		}
+		types = append(types, token.Type.String())
+	}
+	for line := 1; line <= 9; line++ {
+		if lines[line] != 1 {
+			t.Errorf("line %d reported %d times", line, lines[line])
+		}
+	}
+	if got, want := strings.Join(types, " "), "LINE LINE BLANK LINE LINE BLANK INDENT LINE BLANK DEDENT DEDENT LINE DEDENT EOF"; got != want {
+		t.Errorf("wrong token types\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// Relate each paragraph to the LINE tokens that produced it.
+	sources := make(map[*Paragraph][]string)
+	Walk(frag, func(node interface{}) bool {
+		para, ok := node.(*Paragraph)
+		if !ok {
+			return true
+		}
+		for _, token := range tokens {
+			line := token.Position.Line
+			if token.Type == LINE && line >= para.Pos.Line && line < para.Pos.Line+len(para.Text) {
+				sources[para] = append(sources[para], token.Data)
+			}
+		}
+		return true
+	})
+	var got []string
+	Walk(frag, func(node interface{}) bool {
+		if para, ok := node.(*Paragraph); ok {
+			got = append(got, strings.Join(sources[para], "|"))
+		}
+		return true
+	})
+	want := []string{
+		"Intro|paragraph.",
+		"* item one|continues",
+		"quoted",
+		"* item two",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong paragraph sources\ngot:  %q\nwant: %q", got, want)
+	}
+
+	// The hook doesn't affect the result.
+	if diff := Diff(ParseFragmentString(src, testParserFilename), frag); diff != "" {
+		t.Errorf("result differs with hook\n%s", diff)
+	}
 }
@@ -0,0 +1,346 @@
+package rst
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PlainTextOptions customizes the output of WritePlainText.
+type PlainTextOptions struct {
+	// Width is the column at which paragraphs are wrapped. If zero,
+	// DefaultPlainTextWidth is used. If negative, paragraphs are not
+	// wrapped at all and each one is written as a single line.
+	//
+	// A single word longer than the available width is never broken, so
+	// lines containing long words such as URLs may exceed the width.
+	Width int
+
+	// Messages selects how Error elements are presented. By default,
+	// warnings and errors are both written in place, and info messages
+	// are omitted.
+	Messages MessagePolicy
+}
+
+// DefaultPlainTextWidth is the wrapping width used when
+// PlainTextOptions.Width is zero.
+const DefaultPlainTextWidth = 72
+
+// WritePlainText writes the given node to w as readable plain text, for
+// situations where markup cannot be used, such as terminal output or email.
+//
+// Paragraphs are wrapped to the width given in the options. Lists keep their
+// original bullets or enumerators, block quotes are indented, attributions
+// are prefixed with an em dash and section titles are underlined. Block
+// elements are separated by blank lines. Raw elements are included only if
+// their format is "text". The content of literal blocks and raw elements is
+// written as it is, including any trailing whitespace on its lines, but no
+// other line has trailing whitespace.
+//
+// The node may be any element defined in this package, or one of the
+// sequence types Structure, Body or Text.
+func WritePlainText(w io.Writer, node interface{}, opts PlainTextOptions) error {
+	width := opts.Width
+	if width == 0 {
+		width = DefaultPlainTextWidth
+	}
+	r := &plainRenderer{}
+	lines := r.node(opts.Messages.Apply(node), width, 1)
+	if r.err != nil {
+		return r.err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, line := range lines {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// plainRenderer renders plain text, retaining the first error encountered
+// so that callers need not check after each node.
+type plainRenderer struct {
+	err error
+}
+
+// node returns the lines of text representing the given node when rendered
+// in the given width. sectionLevel is the nesting level of any section
+// titles, starting at 1.
+func (r *plainRenderer) node(node interface{}, width int, sectionLevel int) []string {
+	switch n := node.(type) {
+	case *Fragment:
+		return joinBlocks(r.body(n.Body, width, sectionLevel), r.structure(n.ChildElements, width, sectionLevel))
+	case *Document:
+		var blocks [][]string
+		if n.Decoration != nil {
+			blocks = append(blocks, r.body(n.Decoration.Header, width, sectionLevel)...)
+		}
+		if len(n.Title) != 0 {
+			blocks = append(blocks, plainTitle(n.Title.String(), '=', true))
+		}
+		if len(n.Subtitle) != 0 {
+			blocks = append(blocks, plainTitle(n.Subtitle.String(), '-', true))
+		}
+		blocks = append(blocks, r.body(n.Body, width, sectionLevel)...)
+		blocks = append(blocks, r.structure(n.ChildElements, width, sectionLevel)...)
+		if n.Decoration != nil {
+			blocks = append(blocks, r.body(n.Decoration.Footer, width, sectionLevel)...)
+		}
+		return joinBlocks(blocks)
+	case *Section:
+		blocks := [][]string{
+			plainTitle(n.Title.String(), plainUnderline(sectionLevel), false),
+		}
+		blocks = append(blocks, r.body(n.Body, width, sectionLevel)...)
+		blocks = append(blocks, r.structure(n.ChildElements, width, sectionLevel+1)...)
+		return joinBlocks(blocks)
+	case *Comment:
+		return nil
+	case *LiteralBlock:
+		return prefixLines(strings.Split(n.Text, "\n"), "    ", "    ")
+	case *Raw:
+		if !n.HasFormat("text") {
+			return nil
+		}
+		return strings.Split(n.Text, "\n")
+	case *Transition:
+		return []string{"----"}
+	case *Paragraph:
+		return wrapText(n.Text.String(), width)
+	case *BlockQuote:
+		inner := narrower(width, 4)
+		blocks := r.body(n.Quote, inner, sectionLevel)
+		if len(n.Attribution) != 0 {
+			blocks = append(blocks, prefixLines(wrapText(n.Attribution.String(), narrower(inner, 2)), "— ", "  "))
+		}
+		return prefixLines(joinBlocks(blocks), "    ", "    ")
+	case *BulletList:
+		bullet := n.Bullet
+		if bullet == "" {
+			bullet = "*"
+		}
+		markers := make([]string, len(n.Items))
+		for i := range markers {
+			markers[i] = bullet
+		}
+		return r.list(n.Items, markers, width, sectionLevel)
+	case *EnumeratedList:
+		markers := make([]string, len(n.Items))
+		for i := range markers {
+			markers[i] = n.EnumPrefix + enumLabel(n.EnumType, n.FirstIndex+i) + n.EnumSuffix
+		}
+		return r.list(n.Items, markers, width, sectionLevel)
+	case *ListItem:
+		return joinBlocks(r.body(n.Body, width, sectionLevel))
+	case *Error:
+		_, severity := docutilsLevel(n.Severity)
+		return wrapText(fmt.Sprintf("%s: (%s) %s", n.Pos, severity, n.Message), width)
+	case Body:
+		return joinBlocks(r.body(n, width, sectionLevel))
+	case Structure:
+		return joinBlocks(r.structure(n, width, sectionLevel))
+	case Text:
+		return wrapText(n.String(), width)
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("cannot write %T as plain text", node)
+		}
+		return nil
+	}
+}
+
+func (r *plainRenderer) body(body Body, width int, sectionLevel int) [][]string {
+	var blocks [][]string
+	for _, elem := range body {
+		blocks = append(blocks, r.node(elem, width, sectionLevel))
+	}
+	return blocks
+}
+
+func (r *plainRenderer) structure(structure Structure, width int, sectionLevel int) [][]string {
+	var blocks [][]string
+	for _, elem := range structure {
+		blocks = append(blocks, r.node(elem, width, sectionLevel))
+	}
+	return blocks
+}
+
+// list renders the given list items, each marked with the corresponding
+// marker. The items are separated by blank lines only if at least one of
+// them spans more than one line, so that simple lists stay compact.
+func (r *plainRenderer) list(items []*ListItem, markers []string, width int, sectionLevel int) []string {
+	markerWidth := 0
+	for _, marker := range markers {
+		if n := utf8.RuneCountInString(marker); n > markerWidth {
+			markerWidth = n
+		}
+	}
+	indent := strings.Repeat(" ", markerWidth+1)
+
+	compact := true
+	rendered := make([][]string, len(items))
+	for i, item := range items {
+		lines := r.node(item, narrower(width, len(indent)), sectionLevel)
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		first := markers[i] + indent[utf8.RuneCountInString(markers[i]):]
+		rendered[i] = prefixLines(lines, first, indent)
+		if len(lines) > 1 {
+			compact = false
+		}
+	}
+
+	if compact {
+		var lines []string
+		for _, item := range rendered {
+			lines = append(lines, item...)
+		}
+		return lines
+	}
+	return joinBlocks(rendered)
+}
+
+// joinBlocks joins the given blocks of lines with blank lines between them,
+// ignoring any blocks that are empty. Any number of slices of blocks may be
+// given, and they are treated as one sequence.
+func joinBlocks(blockSets ...[][]string) []string {
+	var lines []string
+	for _, blocks := range blockSets {
+		for _, block := range blocks {
+			if len(block) == 0 {
+				continue
+			}
+			if len(lines) != 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, block...)
+		}
+	}
+	return lines
+}
+
+// wrapText splits the given text into words and then arranges them into
+// lines no longer than width, except where a single word is longer than
+// width. If width is negative then the result is a single line.
+func wrapText(s string, width int) []string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return nil
+	}
+	if width < 0 {
+		return []string{strings.Join(words, " ")}
+	}
+
+	var lines []string
+	line := words[0]
+	lineLen := utf8.RuneCountInString(line)
+	for _, word := range words[1:] {
+		wordLen := utf8.RuneCountInString(word)
+		if lineLen+1+wordLen > width {
+			lines = append(lines, line)
+			line, lineLen = word, wordLen
+			continue
+		}
+		line += " " + word
+		lineLen += 1 + wordLen
+	}
+	return append(lines, line)
+}
+
+// narrower returns the width available for content that is indented by n
+// columns within a block of the given width. Nested content is always given
+// at least one column, so that it continues to be wrapped.
+func narrower(width, n int) int {
+	if width < 0 {
+		return width
+	}
+	if width-n < 1 {
+		return 1
+	}
+	return width - n
+}
+
+// prefixLines returns a copy of the given lines with first prepended to the
+// first line and rest prepended to all of the others.
+func prefixLines(lines []string, first, rest string) []string {
+	ret := make([]string, len(lines))
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			ret[i] = strings.TrimRight(prefix, " ")
+			continue
+		}
+		ret[i] = prefix + line
+	}
+	return ret
+}
+
+// plainTitle returns the lines for a title underlined, and optionally also
+// overlined, with the given character.
+func plainTitle(title string, adornment rune, overline bool) []string {
+	rule := strings.Repeat(string(adornment), utf8.RuneCountInString(title))
+	if overline {
+		return []string{rule, title, rule}
+	}
+	return []string{title, rule}
+}
+
+// plainUnderline returns the underline character for section titles at the
+// given nesting level.
+func plainUnderline(level int) rune {
+	const adornments = "=-~^\""
+	if level > len(adornments) {
+		level = len(adornments)
+	}
+	return rune(adornments[level-1])
+}
+
+// enumLabel returns the label for item n of an enumerated list of the given
+// type, without any prefix or suffix.
+func enumLabel(typ EnumType, n int) string {
+	switch typ {
+	case EnumLowerAlpha, EnumUpperAlpha:
+		if n < 1 || n > 26 {
+			break
+		}
+		if typ == EnumLowerAlpha {
+			return string(rune('a' + n - 1))
+		}
+		return string(rune('A' + n - 1))
+	case EnumLowerRoman:
+		if s := romanNumeral(n); s != "" {
+			return strings.ToLower(s)
+		}
+	case EnumUpperRoman:
+		if s := romanNumeral(n); s != "" {
+			return s
+		}
+	}
+	return strconv.Itoa(n)
+}
+
+// romanNumeral returns n as an upper-case roman numeral, or an empty string
+// if n is outside of the range that roman numerals can represent.
+func romanNumeral(n int) string {
+	if n < 1 || n > 3999 {
+		return ""
+	}
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	var buf strings.Builder
+	for i, v := range values {
+		for n >= v {
+			buf.WriteString(symbols[i])
+			n -= v
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,123 @@
+package rst
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWritePlainText(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Input string
+		Width int
+		Want  string
+	}{
+		{
+			"wrapped paragraph",
+			"The quick brown fox\njumps over the lazy dog.",
+			20,
+			"The quick brown fox\njumps over the lazy\ndog.\n",
+		},
+		{
+			"unwrapped paragraph",
+			"The quick brown fox\njumps over the lazy dog.",
+			-1,
+			"The quick brown fox jumps over the lazy dog.\n",
+		},
+		{
+			"long word",
+			"see https://example.com/a/very/long/path for details",
+			20,
+			"see\nhttps://example.com/a/very/long/path\nfor details\n",
+		},
+		{
+			"compact bullet list",
+			"- one\n- two",
+			0,
+			"- one\n- two\n",
+		},
+		{
+			"loose enumerated list",
+			"9. nine\n\n   more nine\n\n10. ten",
+			0,
+			"9.  nine\n\n    more nine\n\n10. ten\n",
+		},
+		{
+			"block quote",
+			"before\n\n    quoted text\n\n    -- someone",
+			0,
+			"before\n\n    quoted text\n\n    — someone\n",
+		},
+		{
+			"literal block",
+			"code::\n\n    x = 1  \n\n    y \n",
+			0,
+			"code:\n\n    x = 1  \n\n    y \n",
+		},
+		{
+			"literal block in list",
+			"- code::\n\n      x  \n\n      y\n- two",
+			0,
+			"- code:\n\n      x  \n\n      y\n\n- two\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			fragment := ParseFragmentString(test.Input, "test.rst")
+			var buf bytes.Buffer
+			err := WritePlainText(&buf, fragment, PlainTextOptions{Width: test.Width})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.Want {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestWritePlainTextSections(t *testing.T) {
+	doc := &Document{
+		Title: Text{CharData("Title")},
+		ChildElements: Structure{
+			&Section{
+				Title: Text{CharData("Section")},
+				Body:  Body{&Paragraph{Text: Text{CharData("body")}}},
+				ChildElements: Structure{
+					&Section{Title: Text{CharData("Sub")}},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePlainText(&buf, doc, PlainTextOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "=====\nTitle\n=====\n\nSection\n=======\n\nbody\n\nSub\n---\n"
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEnumLabel(t *testing.T) {
+	tests := []struct {
+		Type EnumType
+		N    int
+		Want string
+	}{
+		{EnumArabic, 12, "12"},
+		{EnumLowerAlpha, 3, "c"},
+		{EnumUpperAlpha, 26, "Z"},
+		{EnumUpperAlpha, 27, "27"},
+		{EnumLowerRoman, 4, "iv"},
+		{EnumUpperRoman, 1994, "MCMXCIV"},
+	}
+
+	for _, test := range tests {
+		if got := enumLabel(test.Type, test.N); got != test.Want {
+			t.Errorf("enumLabel(%s, %d) = %q; want %q", test.Type, test.N, got, test.Want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package rst
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PlainText returns all of the human-readable text within the given node
+// and its descendents, with all markup removed, for uses such as search
+// indexing.
+//
+// The text of each block element, such as a paragraph or a section title,
+// is on a line of its own. Error, Comment and Raw elements are skipped,
+// since they do not represent human-readable content of the document.
+//
+// The node may be any value accepted by Walk.
+func PlainText(node interface{}) string {
+	return plainText(node, false)
+}
+
+// plainText implements PlainText, optionally leaving out the text of
+// literal blocks.
+func plainText(node interface{}, excludeLiteral bool) string {
+	if text, ok := node.(Text); ok {
+		return text.String()
+	}
+
+	var blocks []string
+	appendText := func(text Text) {
+		if s := text.String(); s != "" {
+			blocks = append(blocks, s)
+		}
+	}
+
+	var visit func(node interface{}) bool
+	visit = func(node interface{}) bool {
+		switch n := node.(type) {
+		case *Error, *Comment, *Raw:
+			return false
+		case *Document:
+			appendText(n.Title)
+			appendText(n.Subtitle)
+			Walk(n.Body, visit)
+			Walk(n.ChildElements, visit)
+			return false
+		case *Section:
+			appendText(n.Title)
+			Walk(n.Body, visit)
+			Walk(n.ChildElements, visit)
+			return false
+		case *Paragraph:
+			appendText(n.Text)
+			return false
+		case *LiteralBlock:
+			if n.Text != "" && !excludeLiteral {
+				blocks = append(blocks, n.Text)
+			}
+			return false
+		case *BlockQuote:
+			Walk(n.Quote, visit)
+			appendText(n.Attribution)
+			return false
+		case InlineElement:
+			// We only get here for inline elements that are not inside
+			// one of the block elements above, such as when the given
+			// node is itself a Text.
+			appendText(Text{n})
+			return false
+		default:
+			return true
+		}
+	}
+	Walk(node, visit)
+
+	return strings.Join(blocks, "\n")
+}
+
+// Summary finds the first paragraph of the given node, for use as a short
+// description or teaser, and returns both its text and a plain string
+// version of that text.
+//
+// Paragraphs are considered in document order, so if a document has no
+// paragraphs before its first section then the first paragraph within that
+// section is used. Paragraphs inside lists are included, but paragraphs
+// inside block quotes are not, since those are usually quotations rather
+// than the author's own introduction.
+//
+// If maxRunes is greater than zero then the string result is truncated to
+// at most that many characters, breaking at a word boundary where possible
+// and marking the truncation with an ellipsis. The Text result is never
+// truncated.
+//
+// If there is no suitable paragraph then the results are nil and an empty
+// string. The node may be any value accepted by Walk.
+func Summary(node interface{}, maxRunes int) (Text, string) {
+	var found *Paragraph
+	Walk(node, func(node interface{}) bool {
+		if found != nil {
+			return false
+		}
+		switch n := node.(type) {
+		case *Paragraph:
+			found = n
+			return false
+		case *BlockQuote, *Error:
+			return false
+		default:
+			return true
+		}
+	})
+	if found == nil {
+		return nil, ""
+	}
+
+	return found.Text, truncateWords(found.Text.String(), maxRunes)
+}
+
+// truncateWords shortens s to at most maxRunes characters, including a
+// trailing ellipsis that marks the truncation. The break is made at the last
+// space that leaves room for the ellipsis, if there is one. If maxRunes is
+// not greater than zero then s is returned unchanged.
+func truncateWords(s string, maxRunes int) string {
+	runes := []rune(s)
+	if maxRunes <= 0 || len(runes) <= maxRunes {
+		return s
+	}
+
+	cut := maxRunes - 1 // leave room for the ellipsis
+	for i := cut; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "…"
+}
@@ -0,0 +1,123 @@
+package rst
+
+import (
+	"testing"
+)
+
+func TestTextString(t *testing.T) {
+	text := Text{
+		CharData("first line"),
+		CharData("second"),
+		&Error{Message: "ignored"},
+		CharData("after"),
+	}
+	got := text.String()
+	want := "first line secondafter"
+	if got != want {
+		t.Errorf("wrong result %q; want %q", got, want)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	src := "Intro paragraph\nwith two lines.\n\n" +
+		"* item one\n* item two\n\n" +
+		"before\n\n" +
+		"    quoted\n\n" +
+		"    -- someone\n" +
+		"after::\n\n    literal\n"
+	fragment := ParseFragmentString(src, "test.rst")
+
+	got := PlainText(fragment)
+	want := "Intro paragraph with two lines.\n" +
+		"item one\nitem two\n" +
+		"before\n" +
+		"quoted\nsomeone\n" +
+		"after:\n" +
+		"literal"
+	if got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	section := &Section{
+		Title: Text{CharData("Title")},
+		Body:  Body{&Paragraph{Text: Text{CharData("body")}}},
+	}
+	if got, want := PlainText(section), "Title\nbody"; got != want {
+		t.Errorf("wrong result for section %q; want %q", got, want)
+	}
+	if got, want := PlainText(Text{CharData("a"), CharData("b")}), "a b"; got != want {
+		t.Errorf("wrong result for text %q; want %q", got, want)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Node     interface{}
+		MaxRunes int
+		WantText Text
+		Want     string
+	}{
+		{
+			"empty document",
+			&Document{},
+			10,
+			nil,
+			"",
+		},
+		{
+			"first paragraph",
+			ParseFragmentString("    quoted epigraph\n\nThe first\nparagraph.\n\nThe second.", "test.rst"),
+			0,
+			Text{CharData("The first"), CharData("paragraph.")},
+			"The first paragraph.",
+		},
+		{
+			"list first",
+			ParseFragmentString("* item one\n* item two", "test.rst"),
+			0,
+			Text{CharData("item one")},
+			"item one",
+		},
+		{
+			"in first section",
+			&Document{
+				ChildElements: Structure{
+					&Section{
+						Title: Text{CharData("Title")},
+						Body:  Body{&Paragraph{Text: Text{CharData("section text")}}},
+					},
+				},
+			},
+			0,
+			Text{CharData("section text")},
+			"section text",
+		},
+		{
+			"truncated",
+			ParseFragmentString("The quick brown fox jumps over the lazy dog.", "test.rst"),
+			20,
+			Text{CharData("The quick brown fox jumps over the lazy dog.")},
+			"The quick brown fox…",
+		},
+		{
+			"truncated long word",
+			ParseFragmentString("Supercalifragilistic", "test.rst"),
+			6,
+			Text{CharData("Supercalifragilistic")},
+			"Super…",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			gotText, got := Summary(test.Node, test.MaxRunes)
+			if diff := Diff(test.WantText, gotText); diff != "" {
+				t.Errorf("wrong text\n%s", diff)
+			}
+			if got != test.Want {
+				t.Errorf("wrong string %q; want %q", got, test.Want)
+			}
+		})
+	}
+}
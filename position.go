@@ -1,6 +1,66 @@
 package rst
 
+import (
+	"fmt"
+)
+
 type Position struct {
 	Line, Column int
 	Filename     string
 }
+
+// String returns a compact "filename:line:column" representation of the
+// position, suitable for inclusion in diagnostic messages.
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// before returns true if p comes before other in the same file.
+func (p Position) before(other Position) bool {
+	if p.Line != other.Line {
+		return p.Line < other.Line
+	}
+	return p.Column < other.Column
+}
+
+// Range is a span of source text, from Start up to but not including End.
+//
+// A Range whose End equals its Start is a single point, as used for
+// problems whose extent is not known.
+type Range struct {
+	Start, End Position
+}
+
+// String returns a compact "filename:line:column-line:column"
+// representation of the range, suitable for inclusion in diagnostic
+// messages. A single-point range is represented as its Start position
+// alone.
+func (r Range) String() string {
+	if r.End == r.Start {
+		return r.Start.String()
+	}
+	return fmt.Sprintf("%s-%d:%d", r.Start, r.End.Line, r.End.Column)
+}
+
+// IsPoint returns true if the range is a single point.
+func (r Range) IsPoint() bool {
+	return r.End == r.Start
+}
+
+// Contains returns true if the given position is within the range. A
+// single-point range contains only its Start position.
+func (r Range) Contains(pos Position) bool {
+	if pos.Filename != r.Start.Filename {
+		return false
+	}
+	if r.IsPoint() {
+		return pos == r.Start
+	}
+	return !pos.before(r.Start) && pos.before(r.End)
+}
+
+// Overlaps returns true if the two ranges have at least one position in
+// common.
+func (r Range) Overlaps(other Range) bool {
+	return r.Contains(other.Start) || other.Contains(r.Start)
+}
@@ -0,0 +1,90 @@
+package rst
+
+import (
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	pos := func(line, col int) Position {
+		return Position{Line: line, Column: col, Filename: "a.rst"}
+	}
+	multi := Range{Start: pos(3, 1), End: pos(5, 10)}
+	point := Range{Start: pos(4, 2), End: pos(4, 2)}
+
+	if got, want := multi.String(), "a.rst:3:1-5:10"; got != want {
+		t.Errorf("wrong string %q; want %q", got, want)
+	}
+	if got, want := point.String(), "a.rst:4:2"; got != want {
+		t.Errorf("wrong string for point %q; want %q", got, want)
+	}
+	if multi.IsPoint() || !point.IsPoint() {
+		t.Errorf("wrong results from IsPoint")
+	}
+
+	contains := []struct {
+		Range Range
+		Pos   Position
+		Want  bool
+	}{
+		{multi, pos(3, 1), true},
+		{multi, pos(2, 80), false},
+		{multi, pos(3, 0), false},
+		{multi, pos(4, 100), true},
+		{multi, pos(5, 9), true},
+		{multi, pos(5, 10), false},
+		{multi, Position{Line: 4, Column: 1, Filename: "b.rst"}, false},
+		{point, pos(4, 2), true},
+		{point, pos(4, 3), false},
+		{point, pos(4, 1), false},
+	}
+	for _, test := range contains {
+		if got := test.Range.Contains(test.Pos); got != test.Want {
+			t.Errorf("%s contains %s: got %t; want %t", test.Range, test.Pos, got, test.Want)
+		}
+	}
+
+	overlaps := []struct {
+		A, B Range
+		Want bool
+	}{
+		{multi, multi, true},
+		{multi, point, true},
+		{point, point, true},
+		{multi, Range{Start: pos(1, 1), End: pos(3, 2)}, true},
+		{multi, Range{Start: pos(1, 1), End: pos(3, 1)}, false},
+		{multi, Range{Start: pos(5, 10), End: pos(6, 1)}, false},
+		{multi, Range{Start: pos(5, 9), End: pos(6, 1)}, true},
+		{multi, Range{Start: pos(1, 1), End: pos(9, 1)}, true},
+		{point, Range{Start: pos(4, 3), End: pos(4, 3)}, false},
+	}
+	for _, test := range overlaps {
+		if got := test.A.Overlaps(test.B); got != test.Want {
+			t.Errorf("%s overlaps %s: got %t; want %t", test.A, test.B, got, test.Want)
+		}
+		if got := test.B.Overlaps(test.A); got != test.Want {
+			t.Errorf("%s overlaps %s: got %t; want %t", test.B, test.A, got, test.Want)
+		}
+	}
+}
+
+func TestErrorRange(t *testing.T) {
+	frag := ParseFragmentString("a::\n\t  x\n\t  yz\n\nb\n", "a.rst")
+	errs := AllErrors(frag)
+	if len(errs) != 1 {
+		t.Fatalf("wrong errors %#v", errs)
+	}
+	if got, want := errs[0].Range().String(), "a.rst:2:1-3:13"; got != want {
+		t.Errorf("wrong range %q; want %q", got, want)
+	}
+
+	// The range covers exactly the skipped source.
+	frag = ParseFragmentString("a::\n\t  x\n\t  yz\n\nb\n", "a.rst", Options{KeepSource: true})
+	r := AllErrors(frag)[0].Range()
+	if got, ok := frag.Source.Slice(r.Start, r.End); !ok || got != "\t  x\n\t  yz" {
+		t.Errorf("wrong source for range %q, %t", got, ok)
+	}
+
+	if got, want := (&Error{Pos: Position{Line: 2, Column: 3}}).Range(), (Range{Start: Position{Line: 2, Column: 3}, End: Position{Line: 2, Column: 3}}); got != want {
+		t.Errorf("wrong range without end %#v; want %#v", got, want)
+	}
+}
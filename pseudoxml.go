@@ -0,0 +1,241 @@
+package rst
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WritePseudoXML writes the given node to w in the "pseudo-XML" format
+// produced by the docutils rst2pseudoxml tool, which shows the structure of
+// a tree as indented XML-like tags with text on lines of its own.
+//
+// The element and attribute names are the same as for WriteXML, and the
+// attributes of each element are written in lexical order as docutils does,
+// so that the output can be compared directly with that of docutils.
+//
+// The node may be any value accepted by Walk. Fragments and documents are
+// both written with a "document" root element.
+func WritePseudoXML(w io.Writer, node interface{}) error {
+	pw := &pseudoXMLWriter{w: bufio.NewWriter(w)}
+	pw.node(0, node)
+	if pw.err != nil {
+		return pw.err
+	}
+	return pw.w.Flush()
+}
+
+// pseudoXMLWriter writes pseudo-XML, retaining the first error encountered
+// so that callers need not check after each write.
+type pseudoXMLWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (pw *pseudoXMLWriter) node(depth int, node interface{}) {
+	switch n := node.(type) {
+	case *Fragment:
+		pw.tag(depth, "document", xmlSourceAttr(n.Pos))
+		pw.body(depth+1, n.Body)
+		pw.structure(depth+1, n.ChildElements)
+	case *Document:
+		pw.tag(depth, "document", append(xmlCommonAttrs(&n.Attributes), xmlSourceAttr(n.Pos)...))
+		pw.text(depth+1, "title", n.Title)
+		pw.text(depth+1, "subtitle", n.Subtitle)
+		pw.node(depth+1, n.Decoration)
+		pw.node(depth+1, n.DocInfo)
+		pw.body(depth+1, n.Body)
+		pw.structure(depth+1, n.ChildElements)
+	case *Section:
+		pw.tag(depth, "section", xmlCommonAttrs(&n.Attributes))
+		pw.text(depth+1, "title", n.Title)
+		pw.body(depth+1, n.Body)
+		pw.structure(depth+1, n.ChildElements)
+	case *Decoration:
+		if n.IsEmpty() {
+			return
+		}
+		pw.tag(depth, "decoration", xmlCommonAttrs(&n.Attributes))
+		if len(n.Header) != 0 {
+			pw.tag(depth+1, "header", nil)
+			pw.body(depth+2, n.Header)
+		}
+		if len(n.Footer) != 0 {
+			pw.tag(depth+1, "footer", nil)
+			pw.body(depth+2, n.Footer)
+		}
+	case *DocInfo:
+		if n.IsEmpty() {
+			return
+		}
+		pw.tag(depth, "docinfo", xmlCommonAttrs(&n.Attributes))
+		pw.text(depth+1, "author", n.Author)
+		if len(n.Authors) != 0 {
+			pw.tag(depth+1, "authors", nil)
+			for _, author := range n.Authors {
+				pw.text(depth+2, "author", author)
+			}
+		}
+		for _, field := range n.docInfoText() {
+			pw.text(depth+1, field.Name, field.Text)
+		}
+		for _, field := range n.Fields {
+			pw.node(depth+1, field)
+		}
+	case *DocInfoField:
+		pw.tag(depth, "field", xmlCommonAttrs(&n.Attributes))
+		pw.text(depth+1, "field_name", n.Name)
+		pw.tag(depth+1, "field_body", nil)
+		pw.body(depth+2, n.Body)
+	case *Comment:
+		pw.tag(depth, "comment", append(xmlCommonAttrs(&n.Attributes), xmlAttr{"xml:space", "preserve"}))
+		if n.Text != "" {
+			pw.lines(depth+1, n.Text)
+		}
+	case *LiteralBlock:
+		pw.tag(depth, "literal_block", append(xmlCommonAttrs(&n.Attributes), xmlAttr{"xml:space", "preserve"}))
+		if n.Text != "" {
+			pw.lines(depth+1, n.Text)
+		}
+	case *Raw:
+		pw.tag(depth, "raw", append(
+			xmlCommonAttrs(&n.Attributes),
+			xmlAttr{"format", n.Format},
+			xmlAttr{"xml:space", "preserve"},
+		))
+		if n.Text != "" {
+			pw.lines(depth+1, n.Text)
+		}
+	case *Transition:
+		pw.tag(depth, "transition", xmlCommonAttrs(&n.Attributes))
+	case *Paragraph:
+		if len(n.Text) != 0 {
+			pw.tag(depth, "paragraph", xmlCommonAttrs(&n.Attributes))
+			pw.inline(depth+1, n.Text)
+		}
+	case *BlockQuote:
+		pw.tag(depth, "block_quote", xmlCommonAttrs(&n.Attributes))
+		pw.body(depth+1, n.Quote)
+		pw.text(depth+1, "attribution", n.Attribution)
+	case *BulletList:
+		attrs := xmlCommonAttrs(&n.Attributes)
+		if n.Bullet != "" {
+			attrs = append(attrs, xmlAttr{"bullet", n.Bullet})
+		}
+		pw.tag(depth, "bullet_list", attrs)
+		for _, item := range n.Items {
+			pw.node(depth+1, item)
+		}
+	case *EnumeratedList:
+		attrs := append(
+			xmlCommonAttrs(&n.Attributes),
+			xmlAttr{"enumtype", string(n.EnumType)},
+			xmlAttr{"prefix", n.EnumPrefix},
+			xmlAttr{"suffix", n.EnumSuffix},
+		)
+		if n.FirstIndex != 1 {
+			attrs = append(attrs, xmlAttr{"start", strconv.Itoa(n.FirstIndex)})
+		}
+		pw.tag(depth, "enumerated_list", attrs)
+		for _, item := range n.Items {
+			pw.node(depth+1, item)
+		}
+	case *ListItem:
+		pw.tag(depth, "list_item", xmlCommonAttrs(&n.Attributes))
+		pw.body(depth+1, n.Body)
+	case *Error:
+		level, typ := docutilsLevel(n.Severity)
+		attrs := append(xmlCommonAttrs(&n.Attributes), xmlAttr{"level", strconv.Itoa(level)}, xmlAttr{"type", typ})
+		if n.Pos.Line > 0 {
+			attrs = append(attrs, xmlAttr{"line", strconv.Itoa(n.Pos.Line)})
+		}
+		attrs = append(attrs, xmlSourceAttr(n.Pos)...)
+		pw.tag(depth, "system_message", attrs)
+		pw.text(depth+1, "paragraph", Text{CharData(n.Message)})
+		if n.Skipped != "" {
+			pw.tag(depth+1, "literal_block", []xmlAttr{{"xml:space", "preserve"}})
+			pw.lines(depth+2, n.Skipped)
+		}
+	case Body:
+		pw.body(depth, n)
+	case Structure:
+		pw.structure(depth, n)
+	case Text:
+		pw.inline(depth, n)
+	default:
+		if pw.err == nil {
+			pw.err = fmt.Errorf("cannot write %T as pseudo-XML", node)
+		}
+	}
+}
+
+func (pw *pseudoXMLWriter) body(depth int, body Body) {
+	for _, elem := range body {
+		pw.node(depth, elem)
+	}
+}
+
+func (pw *pseudoXMLWriter) structure(depth int, structure Structure) {
+	for _, elem := range structure {
+		pw.node(depth, elem)
+	}
+}
+
+// text writes an element containing the given text, or nothing at all if
+// the text is empty.
+func (pw *pseudoXMLWriter) text(depth int, name string, text Text) {
+	if len(text) == 0 {
+		return
+	}
+	pw.tag(depth, name, nil)
+	pw.inline(depth+1, text)
+}
+
+func (pw *pseudoXMLWriter) inline(depth int, text Text) {
+	for _, elem := range text {
+		switch n := elem.(type) {
+		case CharData:
+			pw.lines(depth, string(n))
+		case *Error:
+			pw.tag(depth, "problematic", nil)
+			pw.lines(depth+1, n.Message)
+		default:
+			pw.inline(depth, elem.InlineChildNodes())
+		}
+	}
+}
+
+// lines writes each line of the given text at the given depth.
+func (pw *pseudoXMLWriter) lines(depth int, s string) {
+	for _, line := range strings.Split(s, "\n") {
+		pw.line(depth, line)
+	}
+}
+
+// tag writes a start tag, with its attributes in lexical order. There are
+// no end tags in pseudo-XML; the indentation of the following lines shows
+// the extent of each element.
+func (pw *pseudoXMLWriter) tag(depth int, name string, attrs []xmlAttr) {
+	attrs = append([]xmlAttr(nil), attrs...)
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Name < attrs[j].Name
+	})
+
+	var buf strings.Builder
+	buf.WriteString("<" + name)
+	for _, attr := range attrs {
+		buf.WriteString(" " + attr.Name + `="` + xmlEscape(attr.Value) + `"`)
+	}
+	buf.WriteString(">")
+	pw.line(depth, buf.String())
+}
+
+func (pw *pseudoXMLWriter) line(depth int, s string) {
+	if pw.err != nil {
+		return
+	}
+	_, pw.err = pw.w.WriteString(strings.Repeat("    ", depth) + s + "\n")
+}
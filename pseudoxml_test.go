@@ -0,0 +1,32 @@
+package rst
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWritePseudoXML(t *testing.T) {
+	src := "para & <text>\n\n(3) item\n\nbefore::\n\n    literal\n"
+	fragment := ParseFragmentString(src, "test.rst")
+
+	var buf bytes.Buffer
+	if err := WritePseudoXML(&buf, fragment); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<document source="test.rst">
+    <paragraph>
+        para & <text>
+    <enumerated_list enumtype="arabic" prefix="(" start="3" suffix=")">
+        <list_item>
+            <paragraph>
+                item
+    <paragraph>
+        before:
+    <literal_block xml:space="preserve">
+        literal
+`
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package rst
+
+// Find returns all of the nodes of type T within the tree rooted at the
+// given node, including the node itself, in source order.
+//
+// T may be a concrete element type such as *Paragraph, or an interface
+// type such as BodyElement or InlineElement, in which case every node that
+// implements the interface is included. Note that some block elements also
+// implement InlineElement, because they have an InlineChildNodes method to
+// give access to their text, so to find only inline elements the root
+// should be a Text. The root may be any value accepted by Walk.
+func Find[T interface{}](root interface{}) []T {
+	var ret []T
+	Walk(root, func(node interface{}) bool {
+		if n, ok := node.(T); ok {
+			ret = append(ret, n)
+		}
+		return true
+	})
+	return ret
+}
+
+// FindFunc returns all of the nodes within the tree rooted at the given
+// node, including the node itself, for which the given function returns
+// true, in source order. The root may be any value accepted by Walk.
+func FindFunc(root interface{}, fn func(node interface{}) bool) []interface{} {
+	var ret []interface{}
+	Walk(root, func(node interface{}) bool {
+		if fn(node) {
+			ret = append(ret, node)
+		}
+		return true
+	})
+	return ret
+}
+
+// SectionByTitle returns the first section within the tree rooted at the
+// given node whose title matches the given one, or false if there is no
+// such section. The root may be any value accepted by Walk.
+//
+// Titles are compared as plain text after normalizing them in the same way
+// as reference names, so that differences of case and whitespace are
+// ignored.
+func SectionByTitle(root interface{}, title string) (*Section, bool) {
+	want := normalizeName(title)
+	for _, section := range Find[*Section](root) {
+		if normalizeName(section.Title.String()) == want {
+			return section, true
+		}
+	}
+	return nil, false
+}
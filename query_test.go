@@ -0,0 +1,84 @@
+package rst
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	doc := cloneTestTree()
+
+	var paras []string
+	for _, para := range Find[*Paragraph](doc) {
+		paras = append(paras, para.Text.String())
+	}
+	wantParas := []string{
+		"header", "footer", "value", "with ", "quoted", "bullet", "enumerated", "section body",
+	}
+	if !reflect.DeepEqual(paras, wantParas) {
+		t.Errorf("wrong paragraphs\ngot:  %q\nwant: %q", paras, wantParas)
+	}
+
+	// An interface type matches every node that implements it, whatever
+	// its concrete type and wherever it appears in the tree.
+	var lists []string
+	for _, elem := range Find[BodyElement](doc.Body) {
+		switch elem.(type) {
+		case *BulletList, *EnumeratedList:
+			lists = append(lists, reflect.TypeOf(elem).String())
+		}
+	}
+	if want := []string{"*rst.BulletList", "*rst.EnumeratedList"}; !reflect.DeepEqual(lists, want) {
+		t.Errorf("wrong lists\ngot:  %q\nwant: %q", lists, want)
+	}
+	para := doc.Body[0].(*Paragraph)
+	if got := Find[InlineElement](para.Text); !reflect.DeepEqual(Text(got), para.Text) {
+		t.Errorf("wrong inline elements\ngot:  %#v\nwant: %#v", got, para.Text)
+	}
+	// A paragraph is itself an InlineElement, since it has the necessary
+	// method, and so is included if it is within the tree.
+	if got := Find[InlineElement](para); len(got) != 3 || got[0] != InlineElement(para) {
+		t.Errorf("wrong inline elements for paragraph %#v", got)
+	}
+
+	// The root itself is included.
+	if got := Find[*Document](doc); len(got) != 1 || got[0] != doc {
+		t.Errorf("wrong result for root %#v", got)
+	}
+
+	if got := Find[*Section](Body{}); got != nil {
+		t.Errorf("wrong result for empty tree %#v", got)
+	}
+}
+
+func TestFindFunc(t *testing.T) {
+	doc := cloneTestTree()
+
+	got := FindFunc(doc, func(node interface{}) bool {
+		positioned, ok := node.(interface{ Position() Position })
+		return ok && positioned.Position().Line == 6
+	})
+	want := []interface{}{doc.Body[4], doc.Body[5]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestSectionByTitle(t *testing.T) {
+	doc := cloneTestTree()
+	section := doc.ChildElements[0].(*Section)
+
+	got, ok := SectionByTitle(doc, "  SUBSECTION ")
+	if !ok || got != section.ChildElements[0] {
+		t.Errorf("wrong result for subsection %#v, %t", got, ok)
+	}
+
+	// Only the tree rooted at the given node is searched.
+	if _, ok := SectionByTitle(section.ChildElements[0], "Section"); ok {
+		t.Errorf("found section outside of the given tree")
+	}
+
+	if got, ok := SectionByTitle(doc, "Missing"); ok || got != nil {
+		t.Errorf("wrong result for missing section %#v, %t", got, ok)
+	}
+}
@@ -0,0 +1,53 @@
+package rst
+
+import (
+	"strings"
+)
+
+// Raw is a body element containing content that is already in some output
+// format, such as a fragment of HTML or LaTeX, to be passed through to the
+// output unchanged.
+//
+// The parser never produces Raw elements, since the raw directive is not
+// yet supported. They exist so that programs that build documents can
+// include content that cannot be expressed in the document model.
+//
+// Each writer includes the content of a Raw element only if Format names
+// the writer's output format, and otherwise omits it entirely; content is
+// never converted between formats. The XML and pseudo-XML writers, which
+// represent the tree itself, include every Raw element regardless of its
+// format.
+//
+// The HTML writer, TemplateRenderer, additionally consults its RawPolicy,
+// under which the content is by default escaped rather than included, since
+// a tree containing Raw elements might have been built from untrusted input.
+type Raw struct {
+	bodyElementImpl
+	Attributes
+
+	// Format is a space-separated list of the names of the output formats
+	// the content is intended for, such as "html" or "latex". Names are
+	// compared case-insensitively.
+	Format string
+
+	// Text is the content itself, which is not escaped in any way when it
+	// is included in the output.
+	Text string
+
+	Pos Position
+}
+
+func (r *Raw) Position() Position {
+	return r.Pos
+}
+
+// HasFormat returns true if the given format name is among those listed in
+// r.Format.
+func (r *Raw) HasFormat(name string) bool {
+	for _, format := range strings.Fields(r.Format) {
+		if strings.EqualFold(format, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package rst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRawHasFormat(t *testing.T) {
+	raw := &Raw{Format: "html  LaTeX"}
+	for _, name := range []string{"html", "HTML", "latex"} {
+		if !raw.HasFormat(name) {
+			t.Errorf("HasFormat(%q) is false", name)
+		}
+	}
+	for _, name := range []string{"", "text", "htm"} {
+		if raw.HasFormat(name) {
+			t.Errorf("HasFormat(%q) is true", name)
+		}
+	}
+}
+
+func TestRawWriters(t *testing.T) {
+	fragment := &Fragment{
+		Body: Body{
+			&Paragraph{Text: Text{CharData("before")}},
+			&Raw{Format: "html", Text: `<video src="a.mp4"></video>`},
+			&Raw{Format: "latex", Text: `\newpage`},
+			&Raw{Format: "text", Text: "plain\ntext"},
+			&Paragraph{Text: Text{CharData("after")}},
+		},
+	}
+
+	tests := []struct {
+		Format string
+		Want   string
+	}{
+		{
+			"html",
+			"<p>before</p>\n" +
+				"<div class=\"system-message\">\n" +
+				"<p class=\"system-message-title\">System Message: WARNING</p>\n" +
+				"<p>raw HTML is not allowed; showing it as literal text</p>\n" +
+				"</div>\n" +
+				"<pre class=\"literal-block\">&lt;video src=&#34;a.mp4&#34;&gt;&lt;/video&gt;\n</pre>\n" +
+				"<p>after</p>\n",
+		},
+		{
+			"markdown",
+			"before\n\n<video src=\"a.mp4\"></video>\n\nafter\n",
+		},
+		{
+			"text",
+			"before\n\nplain\ntext\n\nafter\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Format, func(t *testing.T) {
+			r, ok := LookupRenderer(test.Format)
+			if !ok {
+				t.Fatalf("no %s renderer", test.Format)
+			}
+			var buf bytes.Buffer
+			if err := r.Render(&buf, fragment); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.Want {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+
+	t.Run("xml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteXML(&buf, fragment.Body[2]); err != nil {
+			t.Fatal(err)
+		}
+		want := `<raw format="latex" xml:space="preserve">\newpage</raw>` + "\n"
+		if got := buf.String(); got != want {
+			t.Errorf("wrong output\ngot:  %s\nwant: %s", got, want)
+		}
+	})
+
+	policies := []struct {
+		Name   string
+		Policy RawPolicy
+		Filter func(raw *Raw) string
+		Want   string
+	}{
+		{
+			"allow all",
+			RawAllowAll,
+			nil,
+			"<p>before</p>\n<video src=\"a.mp4\"></video>\n<p>after</p>\n",
+		},
+		{
+			"allow listed",
+			RawAllowListed,
+			func(raw *Raw) string {
+				return strings.Replace(raw.Text, "video", "audio", -1)
+			},
+			"<p>before</p>\n<audio src=\"a.mp4\"></audio>\n<p>after</p>\n",
+		},
+		{
+			"allow listed stripping",
+			RawAllowListed,
+			func(raw *Raw) string {
+				return ""
+			},
+			"<p>before</p>\n<p>after</p>\n",
+		},
+		{
+			"omit",
+			RawOmit,
+			nil,
+			"<p>before</p>\n<p>after</p>\n",
+		},
+	}
+	for _, test := range policies {
+		t.Run(test.Name, func(t *testing.T) {
+			r, err := NewTemplateRenderer("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.RawPolicy = test.Policy
+			r.RawFilter = test.Filter
+			var buf bytes.Buffer
+			if err := r.Render(&buf, fragment); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.Want {
+				t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+		})
+	}
+
+	// Allowing listed content without a filter is the same as disallowing
+	// it.
+	r, err := NewTemplateRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.RawPolicy = RawAllowListed
+	var buf bytes.Buffer
+	if err := r.Render(&buf, fragment); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Contains(got, "<video") {
+		t.Errorf("raw content was included without a filter\n%s", got)
+	}
+}
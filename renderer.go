@@ -0,0 +1,107 @@
+package rst
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Renderer is implemented by types that can write a tree in some output
+// format.
+//
+// The node given to Render is usually a *Fragment or a *Document, but
+// renderers should accept any value accepted by Walk where that makes
+// sense for the format.
+type Renderer interface {
+	Render(w io.Writer, node interface{}) error
+}
+
+// RendererFunc is an adapter that allows an ordinary function to be used
+// as a Renderer.
+type RendererFunc func(w io.Writer, node interface{}) error
+
+// Render calls f(w, node).
+func (f RendererFunc) Render(w io.Writer, node interface{}) error {
+	return f(w, node)
+}
+
+// WithMessagePolicy returns a renderer that applies the given policy to
+// each tree before passing it to r, so that the Error elements in the
+// output are placed as the policy requires.
+func WithMessagePolicy(r Renderer, policy MessagePolicy) Renderer {
+	return RendererFunc(func(w io.Writer, node interface{}) error {
+		return r.Render(w, policy.Apply(node))
+	})
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = make(map[string]Renderer)
+)
+
+// RegisterRenderer makes a renderer available by the given format name, so
+// that applications can select an output format dynamically using
+// LookupRenderer.
+//
+// The formats built into this package are registered as "xml",
+// "pseudoxml", "text", "markdown", "html" and "fjson", using their default
+// options.
+// Third-party packages typically call RegisterRenderer from an init
+// function, so that importing the package is enough to make the format
+// available.
+//
+// RegisterRenderer panics if the renderer is nil or if a renderer is
+// already registered with the same name.
+func RegisterRenderer(format string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	if r == nil {
+		panic("rst: RegisterRenderer with nil renderer")
+	}
+	if _, exists := renderers[format]; exists {
+		panic(fmt.Sprintf("rst: RegisterRenderer called twice for format %q", format))
+	}
+	renderers[format] = r
+}
+
+// LookupRenderer returns the renderer registered with the given format name,
+// or false if there is none.
+func LookupRenderer(format string) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	r, ok := renderers[format]
+	return r, ok
+}
+
+// RendererFormats returns the names of all of the registered formats, in
+// lexical order.
+func RendererFormats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func init() {
+	RegisterRenderer("xml", RendererFunc(WriteXML))
+	RegisterRenderer("pseudoxml", RendererFunc(WritePseudoXML))
+	RegisterRenderer("markdown", RendererFunc(WriteMarkdown))
+	RegisterRenderer("text", RendererFunc(func(w io.Writer, node interface{}) error {
+		return WritePlainText(w, node, PlainTextOptions{})
+	}))
+
+	html, err := NewTemplateRenderer("")
+	if err != nil {
+		panic(err)
+	}
+	RegisterRenderer("html", html)
+	RegisterRenderer("fjson", RendererFunc(WriteSphinxJSON))
+}
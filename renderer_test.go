@@ -0,0 +1,78 @@
+package rst
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+)
+
+var testRendererRuns int
+
+func TestRendererRegistry(t *testing.T) {
+	fragment := ParseFragmentString("hello", "test.rst")
+
+	for _, format := range []string{"xml", "markdown", "text"} {
+		r, ok := LookupRenderer(format)
+		if !ok {
+			t.Errorf("no built-in renderer for %q", format)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := r.Render(&buf, fragment); err != nil {
+			t.Errorf("%s renderer failed: %s", format, err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("hello")) {
+			t.Errorf("%s renderer output does not contain the text:\n%s", format, buf.String())
+		}
+	}
+
+	if _, ok := LookupRenderer("no-such-format"); ok {
+		t.Errorf("found renderer for unregistered format")
+	}
+
+	// Register concurrently, so that running the tests with -race will
+	// check the locking.
+	// Each run uses new names, since renderers cannot be unregistered.
+	testRendererRuns++
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			format := fmt.Sprintf("test-%d-%d", testRendererRuns, i)
+			RegisterRenderer(format, RendererFunc(func(w io.Writer, node interface{}) error {
+				_, err := io.WriteString(w, format)
+				return err
+			}))
+			LookupRenderer(format)
+			RendererFormats()
+		}(i)
+	}
+	wg.Wait()
+
+	got := RendererFormats()
+	if !sort.StringsAreSorted(got) {
+		t.Errorf("formats are not sorted: %q", got)
+	}
+	registered := make(map[string]bool)
+	for _, format := range got {
+		registered[format] = true
+	}
+	for i := 0; i < 4; i++ {
+		if format := fmt.Sprintf("test-%d-%d", testRendererRuns, i); !registered[format] {
+			t.Errorf("%q is missing from formats %q", format, got)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("no panic for duplicate registration")
+			}
+		}()
+		RegisterRenderer("xml", RendererFunc(WriteXML))
+	}()
+}
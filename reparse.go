@@ -0,0 +1,212 @@
+package rst
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// An Edit describes a change to the source of a fragment: the OldLen bytes
+// starting at the byte offset StartOffset are replaced with NewText.
+type Edit struct {
+	StartOffset int
+	OldLen      int
+	NewText     string
+}
+
+// Reparse returns the fragment that results from applying the given edit to
+// the source of old, which must have been parsed with Options.KeepSource
+// set, reparsing only as much of the source as the edit could affect. This
+// is considerably faster than parsing the whole of a large source again
+// after a small edit, as an editor might after each keystroke.
+//
+// At most one Options value may be given, and it should be the same as was
+// used to parse old, except that KeepSource is always implied. The result
+// is then the same as parsing the edited source in full, as
+// ParseFragmentString would.
+//
+// Old is not modified, but the result shares with it the elements that
+// precede the edit, so modifying one tree may affect the other.
+func Reparse(old *Fragment, edit Edit, opts ...Options) (*Fragment, error) {
+	p := &Parser{Options: optionsArg(opts)}
+	return p.Reparse(old, edit)
+}
+
+// Reparse is the Parser equivalent of the package-level function of the
+// same name.
+func (pp *Parser) Reparse(old *Fragment, edit Edit) (*Fragment, error) {
+	if old.Source == nil {
+		return nil, fmt.Errorf("fragment has no source; parse it with Options.KeepSource")
+	}
+	oldSrc := old.Source
+	oldText := oldSrc.Text()
+	start, end := edit.StartOffset, edit.StartOffset+edit.OldLen
+	if start < 0 || edit.OldLen < 0 || end > len(oldText) {
+		return nil, fmt.Errorf("edit of bytes %d to %d is outside of the source", start, end)
+	}
+	newText := oldText[:start] + edit.NewText + oldText[end:]
+
+	opts := pp.Options
+	opts.KeepSource = true
+	full := func() (*Fragment, error) {
+		return (&Parser{Options: opts}).ParseFragmentString(newText, oldSrc.Filename), nil
+	}
+
+	// Limits on the document as a whole can't be enforced on only part
+	// of it, and the parser never produces structure for a fragment
+	// unless something unusual happened, so in those cases we just parse
+	// the whole of the new source.
+	limits := opts.Limits
+	if limits.MaxElements > 0 || limits.MaxErrors > 0 || limits.MaxTokens > 0 || len(old.ChildElements) > 0 {
+		return full()
+	}
+
+	// The region to reparse runs from the last boundary that precedes
+	// the edit to the first that follows it, where a boundary is the
+	// start of a top-level element that nothing before it can affect.
+	// The blank line before each boundary must also be untouched.
+	editFirst, editLast := oldSrc.lineOf(start), oldSrc.lineOf(end)
+	first, last := 0, len(old.Body)
+	for i := range old.Body {
+		if !reparseBoundary(oldSrc, old.Body, i) {
+			continue
+		}
+		line := old.Body[i].(Node).Position().Line
+		if line < editFirst {
+			first = i
+		} else if line > editLast+1 {
+			last = i
+			break
+		}
+	}
+
+	regionStart := 1
+	if first > 0 {
+		regionStart = old.Body[first].(Node).Position().Line
+	}
+	delta := strings.Count(edit.NewText, "\n") - strings.Count(oldText[start:end], "\n")
+	newSrc := newSource(oldSrc.Filename, newText)
+	regionText := newText[newSrc.lineStart(regionStart):]
+	if last < len(old.Body) {
+		regionEnd := old.Body[last].(Node).Position().Line + delta
+		regionText = newText[newSrc.lineStart(regionStart):newSrc.lineStart(regionEnd)]
+	}
+
+	regionOpts := opts
+	regionOpts.KeepSource = false
+	p := newStringParser(regionText, oldSrc.Filename, regionOpts)
+	p.skipLines(regionStart - 1)
+	region := p.ParseFragment()
+	if p.failed || len(region.ChildElements) > 0 {
+		// The scanner gave up partway, and so the full parse would not
+		// include anything after this region either.
+		return full()
+	}
+	if first > 0 && len(region.Body) > 0 {
+		if _, ok := region.Body[0].(*BlockQuote); ok {
+			// The region begins at the left margin, so a block quote there
+			// must be the result of a LATE_INDENT token wrapping what came
+			// before it, which in the full parse would include the elements
+			// before the region too.
+			return full()
+		}
+	}
+	if n := len(region.Body); n > 0 && last < len(old.Body) {
+		if _, ok := region.Body[n-1].(*Error); ok {
+			// An error at the end of the region may be one that, in the
+			// full parse, would skip over the element that follows.
+			return full()
+		}
+	}
+
+	body := make(Body, 0, first+len(region.Body)+len(old.Body)-last)
+	body = append(body, old.Body[:first]...)
+	body = append(body, region.Body...)
+	for _, elem := range old.Body[last:] {
+		if delta != 0 && mentionsPosition(elem, oldSrc.Filename) {
+			return full()
+		}
+		elem = Clone(elem).(BodyElement)
+		shiftLines(elem, delta)
+		body = append(body, elem)
+	}
+	if len(body) == 0 {
+		body = nil
+	}
+
+	return &Fragment{
+		Body:       body,
+		Pos:        old.Pos,
+		SourceName: oldSrc.Filename,
+		Source:     newSrc,
+	}, nil
+}
+
+// reparseBoundary returns true if the top-level element body[i] begins a
+// part of the source that can be parsed separately from what precedes it.
+//
+// That is so if the element's first line begins at the left margin after a
+// blank line, where the scanner has no indentation or literal block to carry
+// over and every block before it has ended. The source line is checked
+// rather than the element's position, which for a block quote does not
+// reflect its indentation. Lists are excluded because a list continues
+// across blank lines, and so adjacent lists might merge after an edit, as
+// are errors, whose extent is uncertain.
+func reparseBoundary(src *Source, body Body, i int) bool {
+	if i == 0 {
+		return false
+	}
+	pos := body[i].(Node).Position()
+	line := src.Line(pos.Line)
+	if line == "" || line[0] == ' ' || line[0] == '\t' || strings.TrimSpace(src.Line(pos.Line-1)) != "" {
+		return false
+	}
+	for _, elem := range body[i-1 : i+1] {
+		switch elem.(type) {
+		case *BulletList, *EnumeratedList, *Error:
+			return false
+		}
+	}
+	return true
+}
+
+// shiftLines adds the given number of lines to every position within the
+// tree rooted at the given node, modifying it in place.
+func shiftLines(node interface{}, lines int) {
+	if lines == 0 {
+		return
+	}
+	posType := reflect.TypeOf(Position{})
+	Walk(node, func(node interface{}) bool {
+		v := reflect.ValueOf(node)
+		if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return true
+		}
+		v = v.Elem()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if field.Type() != posType || !field.CanSet() {
+				continue
+			}
+			if pos := field.Addr().Interface().(*Position); pos.Line > 0 {
+				pos.Line += lines
+			}
+		}
+		return true
+	})
+}
+
+// mentionsPosition returns true if the message of any error within the tree
+// rooted at the given node refers to a position in the named file, such as
+// the line that a warning about indentation compares with. Such a message
+// would no longer be accurate once the tree is moved by shiftLines.
+func mentionsPosition(node interface{}, filename string) bool {
+	found := false
+	Walk(node, func(node interface{}) bool {
+		if err, ok := node.(*Error); ok && strings.Contains(err.Message, filename+":") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
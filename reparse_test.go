@@ -0,0 +1,96 @@
+package rst
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReparse(t *testing.T) {
+	old := ParseFragmentString("one\n\ntwo\n\nthree\n\nfour\n\n* five\n", "test.rst", Options{KeepSource: true})
+	got, err := Reparse(old, Edit{StartOffset: 10, OldLen: 5, NewText: "3\nand a half\n\nmore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ParseFragmentString("one\n\ntwo\n\n3\nand a half\n\nmore\n\nfour\n\n* five\n", "test.rst", Options{KeepSource: true})
+	if diff := Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+	if got, want := got.Source.Text(), want.Source.Text(); got != want {
+		t.Errorf("wrong source %q; want %q", got, want)
+	}
+
+	// The elements before the edit are shared, while those after it are
+	// copied so that the old tree keeps its positions.
+	if got.Body[0] != old.Body[0] {
+		t.Errorf("paragraph before the edit was not shared")
+	}
+	if got, want := old.Body[3].(*Paragraph).Pos.Line, 7; got != want {
+		t.Errorf("old tree modified: paragraph after the edit is now on line %d; want %d", got, want)
+	}
+
+	if _, err := Reparse(ParseFragmentString("a\n", "test.rst"), Edit{}); err == nil {
+		t.Errorf("no error for fragment without source")
+	}
+	if _, err := Reparse(old, Edit{StartOffset: 10, OldLen: 100}); err == nil {
+		t.Errorf("no error for edit outside of source")
+	}
+}
+
+// TestReparseRandom checks that reparsing after each of a series of random
+// edits gives the same result as parsing the edited source in full.
+func TestReparseRandom(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "golden", "*.rst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Insertions are chosen from fragments of markup that are likely to
+	// change how the surrounding source is parsed.
+	insertions := []string{
+		"x", " ", "  ", "\t", "\n", "\n\n", "\n    ", "::", "::\n\n", "* ", "- ",
+		"1. ", "A. ", "i) ", ".. ", "-- ", "text\n",
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Base(path)
+		opts := Options{KeepSource: true}
+		frag := ParseFragmentString(string(src), name, opts)
+
+		for i := 0; i < 200; i++ {
+			text := frag.Source.Text()
+			start := rng.Intn(len(text) + 1)
+			oldLen := 0
+			if rng.Intn(2) == 0 {
+				oldLen = rng.Intn(len(text)-start+1) % 12
+			}
+			edit := Edit{
+				StartOffset: start,
+				OldLen:      oldLen,
+				NewText:     insertions[rng.Intn(len(insertions))],
+			}
+
+			got, err := Reparse(frag, edit, opts)
+			if err != nil {
+				t.Fatalf("%s: edit %d: %s", name, i, err)
+			}
+			newText := text[:start] + edit.NewText + text[start+oldLen:]
+			want := ParseFragmentString(newText, name, opts)
+			if diff := Diff(want, got); diff != "" {
+				t.Fatalf("%s: edit %d %#v of\n%s\nwrong result\n%s", name, i, edit, indentLines(text), diff)
+			}
+			frag = got
+		}
+	}
+}
+
+func indentLines(s string) string {
+	return "    " + strings.Replace(s, "\n", "\n    ", -1)
+}
@@ -0,0 +1,298 @@
+package rst
+
+import (
+	"fmt"
+)
+
+// RewriteFunc is the signature of the callback used by Rewrite.
+//
+// The function returns the value that should take the place of the given
+// node, and whether Rewrite should then descend into the children of that
+// replacement. Returning the node itself leaves it in place.
+//
+// The replacement may be nil to delete the node, or a slice (Body, Text,
+// Structure or []interface{}) to replace it with zero or more other nodes.
+type RewriteFunc func(node interface{}) (replacement interface{}, recurse bool)
+
+// Rewrite traverses the tree rooted at the given node in source order, giving
+// fn an opportunity to replace each node it encounters, and returns the
+// replacement for the root node itself.
+//
+// Rewrite modifies the tree in place: each element whose children are
+// visited has its child sequences replaced with newly-allocated sequences
+// containing the results of fn. Any other references to the original
+// sequences are therefore unaffected, but the elements themselves are
+// shared between the old and new trees.
+//
+// If node is a Body, Text or Structure then the result is a sequence of the
+// same type. Otherwise the result is whatever fn returned for the root node,
+// as long as recurse was true for it. If recurse was false for the root then
+// the result is still fn's replacement, but its children are not visited.
+//
+// Rewrite panics if fn returns a replacement that is not valid in the context
+// where the original node appeared, such as an inline element in place of a
+// body element.
+func Rewrite(node interface{}, fn RewriteFunc) interface{} {
+	switch n := node.(type) {
+	case Structure:
+		return rewriteStructure(n, fn)
+	case Body:
+		return rewriteBody(n, fn)
+	case Text:
+		return rewriteText(n, fn)
+	}
+
+	replacement, recurse := fn(node)
+	if recurse {
+		for _, item := range flattenReplacement(replacement) {
+			rewriteChildren(item, fn)
+		}
+	}
+	return replacement
+}
+
+// rewriteOne calls fn for the given node, recursively rewrites the children
+// of the replacement if requested, and then returns the replacement
+// flattened into a slice.
+func rewriteOne(node interface{}, fn RewriteFunc) []interface{} {
+	replacement, recurse := fn(node)
+	items := flattenReplacement(replacement)
+	if recurse {
+		for _, item := range items {
+			rewriteChildren(item, fn)
+		}
+	}
+	return items
+}
+
+func flattenReplacement(replacement interface{}) []interface{} {
+	switch r := replacement.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return r
+	case Body:
+		items := make([]interface{}, len(r))
+		for i, elem := range r {
+			items[i] = elem
+		}
+		return items
+	case Text:
+		items := make([]interface{}, len(r))
+		for i, elem := range r {
+			items[i] = elem
+		}
+		return items
+	case Structure:
+		items := make([]interface{}, len(r))
+		for i, elem := range r {
+			items[i] = elem
+		}
+		return items
+	default:
+		return []interface{}{r}
+	}
+}
+
+// rewriteChildren replaces the child sequences of the given node with
+// rewritten versions.
+func rewriteChildren(node interface{}, fn RewriteFunc) {
+	switch n := node.(type) {
+	case *Fragment:
+		n.Body = rewriteBody(n.Body, fn)
+		n.ChildElements = rewriteStructure(n.ChildElements, fn)
+	case *Document:
+		n.Title = rewriteText(n.Title, fn)
+		n.Subtitle = rewriteText(n.Subtitle, fn)
+		n.Decoration = rewriteDecoration(n.Decoration, fn)
+		n.DocInfo = rewriteDocInfo(n.DocInfo, fn)
+		n.Body = rewriteBody(n.Body, fn)
+		n.ChildElements = rewriteStructure(n.ChildElements, fn)
+	case *Decoration:
+		n.Header = rewriteBody(n.Header, fn)
+		n.Footer = rewriteBody(n.Footer, fn)
+	case *DocInfo:
+		n.Author = rewriteText(n.Author, fn)
+		if n.Authors != nil {
+			authors := make([]Text, 0, len(n.Authors))
+			for _, author := range n.Authors {
+				if author = rewriteText(author, fn); author != nil {
+					authors = append(authors, author)
+				}
+			}
+			n.Authors = authors
+		}
+		n.Organization = rewriteText(n.Organization, fn)
+		n.Address = rewriteText(n.Address, fn)
+		n.Contact = rewriteText(n.Contact, fn)
+		n.Version = rewriteText(n.Version, fn)
+		n.Revision = rewriteText(n.Revision, fn)
+		n.Status = rewriteText(n.Status, fn)
+		n.Date = rewriteText(n.Date, fn)
+		n.Copyright = rewriteText(n.Copyright, fn)
+		n.Fields = rewriteDocInfoFields(n.Fields, fn)
+	case *DocInfoField:
+		n.Name = rewriteText(n.Name, fn)
+		n.Body = rewriteBody(n.Body, fn)
+	case *Section:
+		n.Title = rewriteText(n.Title, fn)
+		n.Body = rewriteBody(n.Body, fn)
+		n.ChildElements = rewriteStructure(n.ChildElements, fn)
+	case *Paragraph:
+		n.Text = rewriteText(n.Text, fn)
+	case *BlockQuote:
+		n.Quote = rewriteBody(n.Quote, fn)
+		n.Attribution = rewriteText(n.Attribution, fn)
+	case *BulletList:
+		n.Items = rewriteItems(n.Items, fn)
+	case *EnumeratedList:
+		n.Items = rewriteItems(n.Items, fn)
+	case *ListItem:
+		n.Body = rewriteBody(n.Body, fn)
+	}
+}
+
+func rewriteBody(body Body, fn RewriteFunc) Body {
+	if body == nil {
+		return nil
+	}
+	ret := make(Body, 0, len(body))
+	for _, elem := range body {
+		for _, item := range rewriteOne(elem, fn) {
+			newElem, ok := item.(BodyElement)
+			if !ok {
+				panic(fmt.Sprintf("rewrite replaced body element with %T", item))
+			}
+			ret = append(ret, newElem)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
+
+func rewriteStructure(structure Structure, fn RewriteFunc) Structure {
+	if structure == nil {
+		return nil
+	}
+	ret := make(Structure, 0, len(structure))
+	for _, elem := range structure {
+		for _, item := range rewriteOne(elem, fn) {
+			newElem, ok := item.(StructureElement)
+			if !ok {
+				panic(fmt.Sprintf("rewrite replaced structure element with %T", item))
+			}
+			ret = append(ret, newElem)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
+
+func rewriteText(text Text, fn RewriteFunc) Text {
+	if text == nil {
+		return nil
+	}
+	ret := make(Text, 0, len(text))
+	for _, elem := range text {
+		for _, item := range rewriteOne(elem, fn) {
+			newElem, ok := item.(InlineElement)
+			if !ok {
+				panic(fmt.Sprintf("rewrite replaced inline element with %T", item))
+			}
+			ret = append(ret, newElem)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
+
+// rewriteOptional rewrites a node that is held in a field of its own rather
+// than in a sequence, and so can only be replaced by a single node or
+// removed. The result is nil if the node was removed.
+func rewriteOptional(node interface{}, fn RewriteFunc) interface{} {
+	items := rewriteOne(node, fn)
+	switch len(items) {
+	case 0:
+		return nil
+	case 1:
+		return items[0]
+	default:
+		panic(fmt.Sprintf("rewrite replaced %T with more than one node", node))
+	}
+}
+
+func rewriteDecoration(deco *Decoration, fn RewriteFunc) *Decoration {
+	if deco == nil {
+		return nil
+	}
+	item := rewriteOptional(deco, fn)
+	if item == nil {
+		return nil
+	}
+	newDeco, ok := item.(*Decoration)
+	if !ok {
+		panic(fmt.Sprintf("rewrite replaced decoration with %T", item))
+	}
+	return newDeco
+}
+
+func rewriteDocInfo(info *DocInfo, fn RewriteFunc) *DocInfo {
+	if info == nil {
+		return nil
+	}
+	item := rewriteOptional(info, fn)
+	if item == nil {
+		return nil
+	}
+	newInfo, ok := item.(*DocInfo)
+	if !ok {
+		panic(fmt.Sprintf("rewrite replaced docinfo with %T", item))
+	}
+	return newInfo
+}
+
+func rewriteDocInfoFields(fields []*DocInfoField, fn RewriteFunc) []*DocInfoField {
+	if fields == nil {
+		return nil
+	}
+	ret := make([]*DocInfoField, 0, len(fields))
+	for _, elem := range fields {
+		for _, item := range rewriteOne(elem, fn) {
+			newField, ok := item.(*DocInfoField)
+			if !ok {
+				panic(fmt.Sprintf("rewrite replaced docinfo field with %T", item))
+			}
+			ret = append(ret, newField)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
+
+func rewriteItems(items []*ListItem, fn RewriteFunc) []*ListItem {
+	if items == nil {
+		return nil
+	}
+	ret := make([]*ListItem, 0, len(items))
+	for _, elem := range items {
+		for _, item := range rewriteOne(elem, fn) {
+			newItem, ok := item.(*ListItem)
+			if !ok {
+				panic(fmt.Sprintf("rewrite replaced list item with %T", item))
+			}
+			ret = append(ret, newItem)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
@@ -0,0 +1,90 @@
+package rst
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	fragment := ParseFragmentString("a\n\n* b\n\n  c\n\n* d", testParserFilename)
+	item := fragment.Body[1].(*BulletList).Items[0]
+	origItemBody := item.Body
+
+	got := Rewrite(fragment, func(node interface{}) (interface{}, bool) {
+		switch n := node.(type) {
+		case *Paragraph:
+			if reflect.DeepEqual(n.Text, Text{CharData("c")}) {
+				// Delete
+				return nil, false
+			}
+			if reflect.DeepEqual(n.Text, Text{CharData("d")}) {
+				// Expand to two paragraphs
+				return Body{
+					n,
+					&Paragraph{Text: Text{CharData("e")}, Pos: n.Pos},
+				}, true
+			}
+		case CharData:
+			return CharData(strings.ToUpper(string(n))), true
+		}
+		return node, true
+	})
+
+	if got != fragment {
+		t.Fatalf("Rewrite returned %#v; want the original fragment", got)
+	}
+
+	var chars []string
+	Walk(fragment, func(node interface{}) bool {
+		if n, ok := node.(CharData); ok {
+			chars = append(chars, string(n))
+		}
+		return true
+	})
+	wantChars := []string{"A", "B", "D", "E"}
+	if !reflect.DeepEqual(chars, wantChars) {
+		t.Errorf("wrong CharData after rewrite\ngot:  %#v\nwant: %#v", chars, wantChars)
+	}
+
+	// The list item itself is modified in place, but its original body
+	// sequence is not.
+	if got, want := len(item.Body), 1; got != want {
+		t.Errorf("list item has %d elements after rewrite; want %d", got, want)
+	}
+	if got, want := len(origItemBody), 2; got != want {
+		t.Errorf("original list item body has %d elements; want %d", got, want)
+	}
+}
+
+func TestRewriteSequence(t *testing.T) {
+	body := Body{
+		&Paragraph{Text: Text{CharData("a")}},
+		&Error{Message: "oops"},
+		&Paragraph{Text: Text{CharData("b")}},
+	}
+
+	got := Rewrite(body, func(node interface{}) (interface{}, bool) {
+		if _, isErr := node.(*Error); isErr {
+			return nil, false
+		}
+		return node, false
+	})
+
+	want := Body{body[0], body[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestRewriteInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Rewrite did not panic")
+		}
+	}()
+
+	Rewrite(Body{&Paragraph{}}, func(node interface{}) (interface{}, bool) {
+		return CharData("not a body element"), false
+	})
+}
@@ -2,6 +2,8 @@ package rst
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"io"
 	"strings"
 )
@@ -33,10 +35,28 @@ const (
 
 	EOF
 	ERROR
+
+	// EXPLICIT_MARKUP is a line beginning with the explicit markup start
+	// "..", followed by whitespace or the end of the line, which begins a
+	// comment, directive, footnote or other explicit markup construct.
+	// Its data is the text after the marker, and its position is that of
+	// the marker.
+	//
+	// The lines that follow, up to the first that is not indented beyond
+	// the marker, are the construct's body. The scanner treats the marker
+	// as establishing a lazy indent for them, as described for LazyIndent,
+	// so an EXPLICIT_MARKUP token is always followed eventually by a
+	// DEDENT that ends the body, with no INDENT to match it. Unless the
+	// marker is alone on its line, blank lines may come between it and
+	// the body.
+	//
+	// A line that continues a paragraph is not explicit markup even if it
+	// begins with the marker, and is instead reported as LINE.
+	EXPLICIT_MARKUP
 )
 
 type Scanner struct {
-	lineScanner *bufio.Scanner
+	lineScanner lineReader
 
 	filename string
 	line     int
@@ -49,18 +69,87 @@ type Scanner struct {
 	literal    bool
 	lazyIndent bool
 
+	// lazyOverBlanks is set along with lazyIndent when blank lines may
+	// come before the line that establishes the lazy indent, as they may
+	// for the body of an explicit markup construct.
+	lazyOverBlanks bool
+
+	// afterText is set if the last line scanned was a LINE, and so a
+	// following line at the same level continues the same paragraph
+	// rather than beginning a new block.
+	afterText bool
+
 	peek *Token
 
+	// peekPushedBack is set if peek is a token that was pushed back by
+	// PushBackSuffix, which onToken has therefore already seen in its
+	// original form.
+	peekPushedBack bool
+
+	// onToken, if non-nil, is called for each token as it is read.
+	onToken func(*Token)
+
+	// readIndents and readLiteral record the indent stack and literal
+	// state as they were before the current peeked token was produced,
+	// which is to say as of the last token returned by Read. readIndents
+	// is reused for each token, to avoid allocation.
+	readIndents []int
+	readLiteral bool
+
 	pushBack *Token
 
 	nextIndent int
 	nextToken  *Token
+
+	// The raw leading whitespace of the most recent non-blank line and
+	// its position, used to detect inconsistent mixing of tabs and spaces
+	// between consecutive lines. prevPrefixValid is false at the start of
+	// the input and after each blank line.
+	prevPrefix      string
+	prevPrefixPos   Position
+	prevPrefixValid bool
+
+	warnings []*Error
+
+	// lines retains the raw text of the lines from that of the last token
+	// returned by Read onwards, so that the parser can examine the source
+	// of the tokens it is working on. linesBase is the number of earlier
+	// lines that have been released, so lines[0] is line linesBase+1.
+	lines     []string
+	linesBase int
+
+	// ahead is set if the line after the last one in lines has already
+	// been read from lineScanner by lineAfter, in which case aheadLine
+	// is its text, or aheadOK is false if there was no such line.
+	ahead     bool
+	aheadOK   bool
+	aheadLine string
+
+	limits Limits
+	tokens int
+
+	// failed is set once the scanner encounters an error, after which it
+	// will return only this token.
+	failed *Token
+
+	// ctx, if non-nil, is checked before each line is scanned so that
+	// scanning can be abandoned when it is cancelled.
+	ctx context.Context
 }
 
 func NewScanner(r io.Reader, filename string) *Scanner {
 	lineScanner := bufio.NewScanner(r)
 	lineScanner.Split(splitRSTLines)
+	return newScanner(lineScanner, filename)
+}
 
+// newStringScanner is like NewScanner but takes its source from a string,
+// from which the data of each token is sliced without copying.
+func newStringScanner(src, filename string) *Scanner {
+	return newScanner(&stringLines{src: src}, filename)
+}
+
+func newScanner(lineScanner lineReader, filename string) *Scanner {
 	// Our indent stack has one permanent member at column 0, and then
 	// grows as necessary. We'll start at capacity 10 so we can parse
 	// shallow documents without more allocation.
@@ -85,6 +174,10 @@ func NewScanner(r io.Reader, filename string) *Scanner {
 func (s *Scanner) Read() *Token {
 	tok := s.Peek()
 	s.peek = nil
+	s.releaseLines(tok.Position.Line)
+	if s.onToken != nil && !s.peekPushedBack {
+		s.onToken(tok)
+	}
 	return tok
 }
 
@@ -93,11 +186,62 @@ func (s *Scanner) Read() *Token {
 // PushIndent or LazyIndent, or else they will panic.
 func (s *Scanner) Peek() *Token {
 	if s.peek == nil {
+		s.readIndents = append(s.readIndents[:0], s.indents...)
+		s.readLiteral = s.literal
+		pushBack := s.pushBack
 		s.peek = s.next()
+		s.peekPushedBack = pushBack != nil && s.peek == pushBack
 	}
 	return s.peek
 }
 
+// IndentStack returns the widths, in columns, of the active indentation
+// levels, starting with the permanent level of zero at the bottom of the
+// stack. The result is a copy that the caller may modify.
+//
+// The scanner produces tokens ahead of the caller, and the INDENT, DEDENT
+// and LATE_INDENT tokens among them change the stack. The result reflects
+// the stack as of the last token returned by Read, along with any levels
+// pushed by PushIndent since, regardless of whether a later token has been
+// peeked. For example, after reading an INDENT token the stack includes
+// the new level, and after reading the DEDENT that ends it the stack no
+// longer does.
+func (s *Scanner) IndentStack() []int {
+	indents := s.indents
+	if s.peek != nil {
+		indents = s.readIndents
+	}
+	return append([]int(nil), indents...)
+}
+
+// CurrentIndent returns the width, in columns, of the current indentation
+// level, as of the last token returned by Read in the same way as for
+// IndentStack. Lines at this level begin at column CurrentIndent()+1.
+func (s *Scanner) CurrentIndent() int {
+	if s.peek != nil {
+		return s.readIndents[len(s.readIndents)-1]
+	}
+	return s.currentIndent()
+}
+
+// InLiteral returns true if the scanner is within a literal block, and so
+// reports lines indented beyond the current level as LITERAL tokens rather
+// than as INDENT and LINE tokens. A literal block begins after a line that
+// ends with the marker "::", and ends at the next non-blank line that is
+// not indented beyond the current level.
+// As with IndentStack, the result is as of the last token returned by
+// Read.
+//
+// The scanner decides this when it scans the line containing the marker,
+// which may be before the LINE token for that line is returned if
+// synthetic tokens must be produced first.
+func (s *Scanner) InLiteral() bool {
+	if s.peek != nil {
+		return s.readLiteral
+	}
+	return s.literal
+}
+
 // SkipBlanks seeks forward through the token stream until the next token
 // is something other than a BLANK.
 //
@@ -109,6 +253,46 @@ func (s *Scanner) SkipBlanks() {
 	}
 }
 
+// SetOnToken registers a function to be called with each token as it is
+// read, including the synthetic indentation tokens. Peeking at a token does
+// not call the function; only reading it does.
+//
+// When a token is split using PushBackSuffix, the function is not called
+// again for the remainder that is pushed back, so that each line of the
+// source is reported only once.
+func (s *Scanner) SetOnToken(fn func(*Token)) {
+	s.onToken = fn
+}
+
+// SetLimits configures resource limits for the scanner. It must be called
+// before the first token is read.
+func (s *Scanner) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// Err returns the error, if any, that was encountered while reading from the
+// scanner's underlying reader.
+//
+// Errors caused by exceeding the scanner's limits are not reported here,
+// since they relate to the content of the input rather than the process
+// of reading it.
+func (s *Scanner) Err() error {
+	return s.lineScanner.Err()
+}
+
+// TakeWarnings returns any warnings the scanner has generated since the
+// last call, and then forgets them.
+//
+// Warnings describe problems that don't affect the token stream, such as
+// suspicious use of tabs in indentation. Since the scanner works ahead of
+// the parser by up to one line, warnings may be reported slightly before
+// the token they relate to is read.
+func (s *Scanner) TakeWarnings() []*Error {
+	warnings := s.warnings
+	s.warnings = nil
+	return warnings
+}
+
 // Eat consumes the next token, and panics if it is not of the given type.
 //
 // This is used to declare that any other token type indicates a bug in
@@ -120,27 +304,102 @@ func (s *Scanner) Eat(wantType TokenType) {
 	}
 }
 
-// next produces the next token in the token stream, which will either be
-// a real token obtained from s.nextToken or it will be a synthetic token
-// to adjust the indent level to match s.nextIndent.
+// next produces the next token in the token stream, taking care of
+// pushed-back tokens, resource limits and the terminal ERROR state before
+// delegating to produce for new tokens.
 func (s *Scanner) next() *Token {
+	if s.failed != nil {
+		return s.failed
+	}
+
+	// The parser may have pushed an indent level since our last token,
+	// so the depth limit must be checked both before and after we
+	// produce a new token.
+	tooDeep := s.indentTooDeep()
 
 	// If a token has been "pushed back" then it must be dealt with first
 	if s.pushBack != nil {
 		token := s.pushBack
 		s.pushBack = nil
+		if tooDeep {
+			return s.indentDepthError(token.Position)
+		}
+		if token.Type == EXPLICIT_MARKUP {
+			s.startMarkupBody(token)
+		}
 		return token
 	}
 
+	token := s.produce()
+
+	switch token.Type {
+	case ERROR:
+		s.failed = token
+		return token
+	case EOF:
+		return token
+	}
+
+	if tooDeep || s.indentTooDeep() {
+		return s.indentDepthError(token.Position)
+	}
+
+	s.tokens++
+	if s.limits.MaxTokens > 0 && s.tokens > s.limits.MaxTokens {
+		return s.fail(
+			fmt.Sprintf("document exceeds maximum of %d tokens", s.limits.MaxTokens),
+			token.Position,
+		)
+	}
+
+	return token
+}
+
+func (s *Scanner) indentTooDeep() bool {
+	return s.limits.MaxIndentDepth > 0 && len(s.indents)-1 > s.limits.MaxIndentDepth
+}
+
+func (s *Scanner) indentDepthError(pos Position) *Token {
+	return s.fail(
+		fmt.Sprintf("indentation exceeds maximum depth of %d", s.limits.MaxIndentDepth),
+		pos,
+	)
+}
+
+// fail puts the scanner into its terminal error state, returning the
+// ERROR token that it will produce from now on.
+func (s *Scanner) fail(msg string, pos Position) *Token {
+	s.failed = &Token{
+		Type:     ERROR,
+		Data:     msg,
+		Position: pos,
+	}
+	return s.failed
+}
+
+// stop puts the scanner into its terminal error state on behalf of the
+// parser, discarding any token that has been peeked so that the next token
+// read is the ERROR token.
+func (s *Scanner) stop(msg string, pos Position) {
+	s.peek = s.fail(msg, pos)
+	s.peekPushedBack = false
+}
+
+// produce generates a new token, which will either be a real token obtained
+// from s.nextToken or it will be a synthetic token to adjust the indent level
+// to match s.nextIndent.
+func (s *Scanner) produce() *Token {
 	// Make sure our scanning state is synced and up-to-date
 	s.scan()
 
-	if s.lazyIndent {
+	if s.lazyIndent && !(s.lazyOverBlanks && s.nextToken.Type == BLANK && s.nextIndent <= s.currentIndent()) {
 		s.lazyIndent = false
+		s.lazyOverBlanks = false
 
-		// "lazy indent" only applies if the next token is a LINE token
-		// which indents more than current.
-		if s.nextToken.Type != LINE || s.nextIndent <= s.currentIndent() {
+		// "lazy indent" only applies if the next token is a line of
+		// content which indents more than current.
+		contentType := s.nextToken.Type == LINE || s.nextToken.Type == EXPLICIT_MARKUP
+		if !contentType || s.nextIndent <= s.currentIndent() {
 			// Synthetic DEDENT token makes sure we let the parser leave
 			// whatever context it was in that was expecting a lazy indent.
 			return &Token{
@@ -155,7 +414,7 @@ func (s *Scanner) next() *Token {
 		// context the lazy indent applies to, so we'll just record the
 		// new indent to bypass the INDENT token and then emit the
 		// LINE token as normal below.
-		s.PushIndent(s.nextIndent)
+		s.indents = append(s.indents, s.nextIndent)
 	}
 
 	currentIndent := s.currentIndent()
@@ -206,6 +465,9 @@ func (s *Scanner) next() *Token {
 
 		token := s.nextToken
 		s.nextToken = nil // let scan() know we need another token
+		if token.Type == EXPLICIT_MARKUP {
+			s.startMarkupBody(token)
+		}
 		return token
 	}
 }
@@ -222,10 +484,39 @@ func (s *Scanner) scan() {
 			Column:   1,
 			Filename: s.filename,
 		}
-		if s.lineScanner.Scan() {
+		if s.ctx != nil {
+			select {
+			case <-s.ctx.Done():
+				s.nextIndent = s.currentIndent()
+				s.nextToken = &Token{
+					Type:     ERROR,
+					Data:     s.ctx.Err().Error(),
+					Position: position,
+				}
+				return
+			default:
+			}
+		}
+
+		if whole, ok := s.readLine(); ok {
 			s.line++
-			whole := s.lineScanner.Text()
-			data := whole
+			s.lines = append(s.lines, whole)
+
+			// Trailing whitespace is insignificant everywhere except in
+			// literal blocks, whose lines are given to the parser intact.
+			line := strings.TrimRight(whole, "\b\t \f\v")
+
+			if s.limits.MaxLineLength > 0 && len(line) > s.limits.MaxLineLength {
+				s.nextIndent = s.currentIndent()
+				s.nextToken = &Token{
+					Type:     ERROR,
+					Data:     fmt.Sprintf("line exceeds maximum length of %d bytes", s.limits.MaxLineLength),
+					Position: position,
+				}
+				return
+			}
+
+			data := line
 			indent := 0
 			for {
 				if len(data) == 0 {
@@ -243,6 +534,12 @@ func (s *Scanner) scan() {
 				data = data[1:]
 			}
 
+			if len(data) == 0 {
+				s.prevPrefixValid = false
+			} else {
+				s.checkIndentPrefix(line[:len(line)-len(data)], position)
+			}
+
 			if s.literal {
 				// This is a continuation of a literal block unless it
 				// contains non-whitespace characters that are indented
@@ -259,15 +556,39 @@ func (s *Scanner) scan() {
 						// once it has collected all of the consecutive
 						// LITERAL tokens and can see which one has the
 						// shortest prefix, so we'll just give it the whole
-						// line to work with.
+						// line to work with, including any trailing
+						// whitespace.
 						Data: whole,
 
 						Position: position,
 					}
+					s.afterText = false
 					return
 				}
 			}
 
+			// Literal mode lasts only until the next line that isn't
+			// part of the literal block, unless that line introduces
+			// another one.
+			if len(data) > 0 {
+				s.literal = false
+			}
+
+			if rest, ok := explicitMarkupStart(data); ok && !s.continuesText(indent) {
+				// The marker is checked before the literal block marker
+				// so that a directive such as ".. code::" does not begin
+				// a literal block.
+				position.Column = indent + 1
+				s.nextIndent = indent
+				s.nextToken = &Token{
+					Type:     EXPLICIT_MARKUP,
+					Data:     rest,
+					Position: position,
+				}
+				s.afterText = false
+				return
+			}
+
 			if len(data) >= 2 && data[len(data)-2:] == "::" {
 				// Marker of the beginning of literal lines.
 				s.literal = true
@@ -294,6 +615,7 @@ func (s *Scanner) scan() {
 						Data:     data[:0],
 						Position: position,
 					}
+					s.afterText = false
 					return
 				}
 			}
@@ -310,6 +632,7 @@ func (s *Scanner) scan() {
 					Data:     data,
 					Position: position,
 				}
+				s.afterText = false
 				return
 			}
 
@@ -322,6 +645,7 @@ func (s *Scanner) scan() {
 				Data:     data,
 				Position: position,
 			}
+			s.afterText = true
 			return
 
 		} else {
@@ -383,6 +707,39 @@ func (s *Scanner) LazyIndent() {
 		panic("cannot call LazyIndent with an active peek")
 	}
 	s.lazyIndent = true
+	s.lazyOverBlanks = false
+}
+
+// startMarkupBody establishes the lazy indent for the body of the explicit
+// markup construct that the given EXPLICIT_MARKUP token begins.
+func (s *Scanner) startMarkupBody(token *Token) {
+	s.lazyIndent = true
+	s.lazyOverBlanks = token.Data != ""
+}
+
+// continuesText returns true if a non-blank line with the given indent
+// would continue the paragraph on the line before it, including the first
+// line of a lazily-indented body after a line of text.
+func (s *Scanner) continuesText(indent int) bool {
+	if !s.afterText {
+		return false
+	}
+	current := s.currentIndent()
+	return indent == current || (s.lazyIndent && indent > current)
+}
+
+// explicitMarkupStart returns the text after the explicit markup start
+// "..", with surrounding whitespace removed, if the given line data begins
+// with one.
+func explicitMarkupStart(data string) (string, bool) {
+	if !strings.HasPrefix(data, "..") {
+		return "", false
+	}
+	rest := data[2:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
 }
 
 // PushBackSuffix pushes a token back into the scanner with a prefix removed
@@ -427,6 +784,125 @@ func (s *Scanner) PushBackSuffix(token *Token, prefixLen int) {
 	if s.pushBack.Type == LINE && s.pushBack.Data == "" {
 		s.pushBack.Type = BLANK
 	}
+
+	// The remainder begins a new block, such as the body of a list item,
+	// and so may be explicit markup.
+	if s.pushBack.Type == LINE {
+		if rest, ok := explicitMarkupStart(s.pushBack.Data); ok {
+			s.pushBack.Type = EXPLICIT_MARKUP
+			s.pushBack.Data = rest
+			s.afterText = false
+		}
+	}
+}
+
+// checkIndentPrefix compares the raw leading whitespace of a non-blank line
+// with that of the line before it, and generates a warning if the two
+// are inconsistent.
+//
+// Consecutive lines are consistent if one prefix is a prefix of the other,
+// which is always true when only spaces are used. Otherwise tabs and spaces
+// have been mixed in a way that makes the resulting indent depend on the
+// tab width, which is almost never what the author intended.
+func (s *Scanner) checkIndentPrefix(prefix string, pos Position) {
+	if s.prevPrefixValid {
+		prev := s.prevPrefix
+		if !strings.HasPrefix(prefix, prev) && !strings.HasPrefix(prev, prefix) {
+			s.warnings = append(s.warnings, &Error{
+				Message: fmt.Sprintf(
+					"inconsistent use of tabs and spaces in indentation; compare with %s",
+					s.prevPrefixPos,
+				),
+				Pos:      pos,
+				Severity: SeverityWarning,
+			})
+		}
+	}
+
+	s.prevPrefix = prefix
+	s.prevPrefixPos = pos
+	s.prevPrefixValid = true
+}
+
+// readLine returns the text of the next line of the input, or false if
+// there are no more lines.
+func (s *Scanner) readLine() (string, bool) {
+	if s.ahead {
+		s.ahead = false
+		return s.aheadLine, s.aheadOK
+	}
+	if !s.lineScanner.Scan() {
+		return "", false
+	}
+	return s.lineScanner.Text(), true
+}
+
+// lineAfter returns the text of the line following the given one, exactly
+// as it appears in the input, or false if there is no such line. The line
+// is read ahead of time if necessary, for use in decisions that depend on
+// what follows a line, but is not scanned until its turn comes.
+func (s *Scanner) lineAfter(line int) (string, bool) {
+	if text, ok := s.sourceLine(line + 1); ok {
+		return text, true
+	}
+	if line != s.linesBase+len(s.lines) {
+		return "", false
+	}
+	if !s.ahead {
+		s.ahead = true
+		s.aheadLine, s.aheadOK = "", s.lineScanner.Scan()
+		if s.aheadOK {
+			s.aheadLine = s.lineScanner.Text()
+		}
+	}
+	return s.aheadLine, s.aheadOK
+}
+
+// skipLines arranges for the scanner to behave as if its input were preceded
+// by the given number of lines, which it does not scan but which are
+// counted, so that the positions of tokens are correct when the input is
+// part of a larger source.
+func (s *Scanner) skipLines(n int) {
+	s.line += n
+	s.linesBase += n
+}
+
+// SourceLine returns the text of the given line, numbered from 1, exactly
+// as it appeared in the input. The result is empty if the given line has
+// not been read yet, or if it is before the line of the last token returned
+// by Read, since the scanner does not retain the lines it has finished with.
+func (s *Scanner) SourceLine(line int) string {
+	text, _ := s.sourceLine(line)
+	return text
+}
+
+// sourceLine is like SourceLine, but also returns false if the scanner does
+// not have the given line.
+func (s *Scanner) sourceLine(line int) (string, bool) {
+	i := line - s.linesBase - 1
+	if i < 0 || i >= len(s.lines) {
+		return "", false
+	}
+	return s.lines[i], true
+}
+
+// releaseLines discards the retained lines before the given one.
+func (s *Scanner) releaseLines(line int) {
+	n := line - s.linesBase - 1
+	if n <= 0 {
+		return
+	}
+	if n > len(s.lines) {
+		n = len(s.lines)
+	}
+	// The remaining lines are moved to the start of the slice, rather than
+	// slicing it, so that the released strings can be garbage collected.
+	kept := copy(s.lines, s.lines[n:])
+	for i := kept; i < len(s.lines); i++ {
+		s.lines[i] = ""
+	}
+	s.lines = s.lines[:kept]
+	s.linesBase += n
 }
 
 func (s *Scanner) currentIndent() int {
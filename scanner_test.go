@@ -2,11 +2,8 @@ package rst
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
 	"testing"
-
-	"github.com/davecgh/go-spew/spew"
 )
 
 const testScannerFilename = "test.rst"
@@ -495,6 +492,39 @@ func TestScanner(t *testing.T) {
 				},
 			},
 		},
+		{
+			"hello  \n::  \n    world  \n  baz \t\nqux  ",
+			[]*Token{
+				{
+					Type:     LINE,
+					Data:     "hello",
+					Position: Position{Line: 1, Column: 1},
+				},
+				{
+					Type:     BLANK,
+					Position: Position{Line: 2, Column: 1},
+				},
+				{
+					Type:     LITERAL,
+					Data:     "    world  ",
+					Position: Position{Line: 3, Column: 1},
+				},
+				{
+					Type:     LITERAL,
+					Data:     "  baz \t",
+					Position: Position{Line: 4, Column: 1},
+				},
+				{
+					Type:     LINE,
+					Data:     "qux",
+					Position: Position{Line: 5, Column: 1},
+				},
+				{
+					Type:     EOF,
+					Position: Position{Line: 6, Column: 1},
+				},
+			},
+		},
 		{
 			"  ::\n    hello\n  world",
 			[]*Token{
@@ -598,13 +628,170 @@ func TestScanner(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	spewConfig := &spew.ConfigState{
-		Indent:                  "    ",
-		SortKeys:                true,
-		DisablePointerAddresses: true,
-		DisableCapacities:       true,
+		{
+			".. comment",
+			[]*Token{
+				{
+					Type:     EXPLICIT_MARKUP,
+					Data:     "comment",
+					Position: Position{Line: 1, Column: 1},
+				},
+				{
+					Type:     DEDENT,
+					Data:     "",
+					Position: Position{Line: 2, Column: 1},
+				},
+				{
+					Type:     EOF,
+					Position: Position{Line: 2, Column: 1},
+				},
+			},
+		},
+		{
+			".. note:: text\n   more",
+			[]*Token{
+				{
+					Type:     EXPLICIT_MARKUP,
+					Data:     "note:: text",
+					Position: Position{Line: 1, Column: 1},
+				},
+				{
+					Type:     LINE,
+					Data:     "more",
+					Position: Position{Line: 2, Column: 4},
+				},
+				{
+					Type:     DEDENT,
+					Data:     "",
+					Position: Position{Line: 3, Column: 1},
+				},
+				{
+					Type:     EOF,
+					Position: Position{Line: 3, Column: 1},
+				},
+			},
+		},
+		{
+			".. code::\n\n   x = 1",
+			[]*Token{
+				{
+					Type:     EXPLICIT_MARKUP,
+					Data:     "code::",
+					Position: Position{Line: 1, Column: 1},
+				},
+				{
+					Type:     BLANK,
+					Data:     "",
+					Position: Position{Line: 2, Column: 1},
+				},
+				{
+					Type:     LINE,
+					Data:     "x = 1",
+					Position: Position{Line: 3, Column: 4},
+				},
+				{
+					Type:     DEDENT,
+					Data:     "",
+					Position: Position{Line: 4, Column: 1},
+				},
+				{
+					Type:     EOF,
+					Position: Position{Line: 4, Column: 1},
+				},
+			},
+		},
+		{
+			"  .. x\n     body",
+			[]*Token{
+				{
+					Type:     INDENT,
+					Data:     "  ",
+					Position: Position{Line: 1, Column: 1},
+				},
+				{
+					Type:     EXPLICIT_MARKUP,
+					Data:     "x",
+					Position: Position{Line: 1, Column: 3},
+				},
+				{
+					Type:     LINE,
+					Data:     "body",
+					Position: Position{Line: 2, Column: 6},
+				},
+				{
+					Type:     DEDENT,
+					Data:     "",
+					Position: Position{Line: 3, Column: 1},
+				},
+				{
+					Type:     DEDENT,
+					Data:     "",
+					Position: Position{Line: 3, Column: 1},
+				},
+				{
+					Type:     EOF,
+					Position: Position{Line: 3, Column: 1},
+				},
+			},
+		},
+		{
+			"..\n\n   quote",
+			[]*Token{
+				{
+					Type:     EXPLICIT_MARKUP,
+					Data:     "",
+					Position: Position{Line: 1, Column: 1},
+				},
+				{
+					Type:     DEDENT,
+					Data:     "",
+					Position: Position{Line: 2, Column: 1},
+				},
+				{
+					Type:     BLANK,
+					Data:     "",
+					Position: Position{Line: 2, Column: 1},
+				},
+				{
+					Type:     INDENT,
+					Data:     "   ",
+					Position: Position{Line: 3, Column: 1},
+				},
+				{
+					Type:     LINE,
+					Data:     "quote",
+					Position: Position{Line: 3, Column: 4},
+				},
+				{
+					Type:     DEDENT,
+					Data:     "",
+					Position: Position{Line: 4, Column: 1},
+				},
+				{
+					Type:     EOF,
+					Position: Position{Line: 4, Column: 1},
+				},
+			},
+		},
+		{
+			"text\n.. x",
+			[]*Token{
+				{
+					Type:     LINE,
+					Data:     "text",
+					Position: Position{Line: 1, Column: 1},
+				},
+				{
+					Type:     LINE,
+					Data:     ".. x",
+					Position: Position{Line: 2, Column: 1},
+				},
+				{
+					Type:     EOF,
+					Position: Position{Line: 3, Column: 1},
+				},
+			},
+		},
 	}
 
 	for i, test := range tests {
@@ -636,13 +823,249 @@ func TestScanner(t *testing.T) {
 				}
 			}
 
-			if !reflect.DeepEqual(got, test.Want) {
-				t.Errorf(
-					"\nincorrect tokens for %q\ngot:  %s\nwant: %s",
-					test.Input,
-					spewConfig.Sdump(got), spewConfig.Sdump(test.Want),
-				)
+			if diff := Diff(test.Want, got); diff != "" {
+				t.Errorf("incorrect tokens for %q\n%s", test.Input, diff)
+			}
+		})
+	}
+}
+
+func TestScannerWarnings(t *testing.T) {
+	tests := []struct {
+		Input string
+		Want  []*Error
+	}{
+		{
+			"    foo\n    bar",
+			nil,
+		},
+		{
+			"\tfoo\n\t    bar\n\tbaz",
+			nil,
+		},
+		{
+			"    foo\n\n\tbar",
+			nil,
+		},
+		{
+			"    foo\n\tbar",
+			[]*Error{
+				{
+					Message:  "inconsistent use of tabs and spaces in indentation; compare with test.rst:1:1",
+					Pos:      Position{Line: 2, Column: 1, Filename: testScannerFilename},
+					Severity: SeverityWarning,
+				},
+			},
+		},
+		{
+			"\tfoo\n        bar",
+			[]*Error{
+				{
+					Message:  "inconsistent use of tabs and spaces in indentation; compare with test.rst:1:1",
+					Pos:      Position{Line: 2, Column: 1, Filename: testScannerFilename},
+					Severity: SeverityWarning,
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			r := strings.NewReader(test.Input)
+			scanner := NewScanner(r, testScannerFilename)
+			var got []*Error
+			for {
+				token := scanner.Read()
+				got = append(got, scanner.TakeWarnings()...)
+				if token.Type == EOF || token.Type == ERROR {
+					break
+				}
+			}
+
+			if diff := Diff(test.Want, got); diff != "" {
+				t.Errorf("incorrect warnings for %q\n%s", test.Input, diff)
 			}
 		})
 	}
 }
+
+func TestScannerLimits(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Limits Limits
+		Want   *Token
+	}{
+		{
+			"hello\nworld",
+			Limits{MaxLineLength: 5},
+			&Token{
+				Type:     EOF,
+				Position: Position{Line: 3, Column: 1},
+			},
+		},
+		{
+			// Trailing whitespace does not count towards the length.
+			"hello \t \nworld",
+			Limits{MaxLineLength: 5},
+			&Token{
+				Type:     EOF,
+				Position: Position{Line: 3, Column: 1},
+			},
+		},
+		{
+			"hello\nworld!",
+			Limits{MaxLineLength: 5},
+			&Token{
+				Type:     ERROR,
+				Data:     "line exceeds maximum length of 5 bytes",
+				Position: Position{Line: 2, Column: 1},
+			},
+		},
+		{
+			"a\n  b\n    c",
+			Limits{MaxIndentDepth: 2},
+			&Token{
+				Type:     EOF,
+				Position: Position{Line: 4, Column: 1},
+			},
+		},
+		{
+			"a\n  b\n    c\n      d",
+			Limits{MaxIndentDepth: 2},
+			&Token{
+				Type:     ERROR,
+				Data:     "indentation exceeds maximum depth of 2",
+				Position: Position{Line: 4, Column: 1},
+			},
+		},
+		{
+			"a\nb\nc",
+			Limits{MaxTokens: 3},
+			&Token{
+				Type:     EOF,
+				Position: Position{Line: 4, Column: 1},
+			},
+		},
+		{
+			"a\nb\nc\nd",
+			Limits{MaxTokens: 3},
+			&Token{
+				Type:     ERROR,
+				Data:     "document exceeds maximum of 3 tokens",
+				Position: Position{Line: 4, Column: 1},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		test.Want.Position.Filename = testScannerFilename
+
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			r := strings.NewReader(test.Input)
+			scanner := NewScanner(r, testScannerFilename)
+			scanner.SetLimits(test.Limits)
+			var got *Token
+			for {
+				got = scanner.Read()
+				if got.Type == EOF || got.Type == ERROR {
+					break
+				}
+			}
+
+			if diff := Diff(test.Want, got); diff != "" {
+				t.Errorf("incorrect final token for %q\n%s", test.Input, diff)
+			}
+
+			// Once in the error state, the scanner must stay there.
+			if got.Type == ERROR {
+				if again := scanner.Read(); again.Type != ERROR {
+					t.Errorf("got %s after ERROR; want ERROR", again.Type)
+				}
+			}
+		})
+	}
+}
+
+func TestScannerIndentState(t *testing.T) {
+	s := NewScanner(strings.NewReader("a\n  b\n    c\nd::\n\n  e\nf\n"), testScannerFilename)
+
+	type state struct {
+		Type    TokenType
+		Stack   string
+		Current int
+		Literal bool
+	}
+	var got []state
+	for {
+		// Peeking must not affect the state, which describes the last
+		// token read.
+		s.Peek()
+		tok := s.Read()
+		s.Peek()
+		got = append(got, state{
+			Type:    tok.Type,
+			Stack:   fmt.Sprint(s.IndentStack()),
+			Current: s.CurrentIndent(),
+			Literal: s.InLiteral(),
+		})
+		if tok.Type == EOF {
+			break
+		}
+	}
+
+	want := []state{
+		{LINE, "[0]", 0, false},
+		{INDENT, "[0 2]", 2, false},
+		{LINE, "[0 2]", 2, false},
+		{INDENT, "[0 2 4]", 4, false},
+		{LINE, "[0 2 4]", 4, false},
+		// The "d::" line is scanned before the DEDENT tokens that precede
+		// it are produced, so the literal state changes early.
+		{DEDENT, "[0 2]", 2, true},
+		{DEDENT, "[0]", 0, true},
+		{LINE, "[0]", 0, true},
+		{BLANK, "[0]", 0, true},
+		{LITERAL, "[0]", 0, true},
+		// The literal block ends at the first line that isn't indented
+		// beyond the current level.
+		{LINE, "[0]", 0, false},
+		{EOF, "[0]", 0, false},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("wrong states\ngot:  %v\nwant: %v", got, want)
+	}
+
+	s = NewScanner(strings.NewReader("- a\n"), testScannerFilename)
+	s.Read()
+	s.PushIndent(2)
+	if got, want := fmt.Sprint(s.IndentStack()), "[0 2]"; got != want {
+		t.Errorf("wrong stack after PushIndent %s; want %s", got, want)
+	}
+	s.IndentStack()[0] = 99
+	if got := s.IndentStack()[0]; got != 0 {
+		t.Errorf("modifying the result of IndentStack changed the scanner")
+	}
+}
+
+func TestScannerOnToken(t *testing.T) {
+	scanner := NewScanner(strings.NewReader("* a\nb\n"), "test.rst")
+	var seen []string
+	scanner.SetOnToken(func(token *Token) {
+		seen = append(seen, token.Data)
+	})
+
+	scanner.Peek()
+	if len(seen) != 0 {
+		t.Fatalf("peeking reported %q", seen)
+	}
+	first := scanner.Read()
+	scanner.PushIndent(2)
+	scanner.PushBackSuffix(first, 2)
+	if got := scanner.Read(); got.Data != "a" {
+		t.Fatalf("wrong pushed-back token %q", got.Data)
+	}
+	scanner.Read()
+	if got, want := strings.Join(seen, "|"), "* a|"; got != want {
+		t.Errorf("wrong tokens reported %q; want %q", got, want)
+	}
+}
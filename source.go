@@ -0,0 +1,168 @@
+package rst
+
+import (
+	"sort"
+)
+
+// Source is the original text from which a fragment was parsed, retained
+// when Options.KeepSource is set so that the text covered by elements of
+// the tree can be recovered exactly.
+//
+// A Source is not modified once parsing is complete, and so it may be
+// shared freely between trees and goroutines.
+type Source struct {
+	// Filename is the filename that was given to the parser.
+	Filename string
+
+	text string
+
+	// lineStarts is the byte offset of the start of each line, so that
+	// line n (counting from one) begins at lineStarts[n-1].
+	lineStarts []int
+}
+
+func newSource(filename, text string) *Source {
+	s := &Source{
+		Filename:   filename,
+		text:       text,
+		lineStarts: []int{0},
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' && i+1 < len(text) {
+			s.lineStarts = append(s.lineStarts, i+1)
+		}
+	}
+	return s
+}
+
+// Text returns the whole of the source, byte for byte as it was read.
+func (s *Source) Text() string {
+	return s.text
+}
+
+// Lines returns the number of lines in the source.
+func (s *Source) Lines() int {
+	if s.text == "" {
+		return 0
+	}
+	return len(s.lineStarts)
+}
+
+// Line returns the text of the given line, counting from one, including
+// any trailing whitespace but excluding the line terminator. The result is
+// empty if there is no such line.
+func (s *Source) Line(n int) string {
+	start, end, ok := s.lineBounds(n)
+	if !ok {
+		return ""
+	}
+	return s.text[start:end]
+}
+
+// lineBounds returns the byte offsets of the start and end of the given
+// line, excluding its terminator, which may be either "\n" or "\r\n".
+func (s *Source) lineBounds(n int) (start, end int, ok bool) {
+	if n < 1 || n > s.Lines() {
+		return 0, 0, false
+	}
+	start = s.lineStarts[n-1]
+	end = len(s.text)
+	if n < len(s.lineStarts) {
+		end = s.lineStarts[n] - 1
+	}
+	if end > start && end == len(s.text) && s.text[end-1] == '\n' {
+		end--
+	}
+	if end > start && s.text[end-1] == '\r' {
+		end--
+	}
+	return start, end, true
+}
+
+// lineOf returns the number of the line containing the given byte offset,
+// counting from one. An offset at the end of the source is on the last
+// line.
+func (s *Source) lineOf(offset int) int {
+	return sort.Search(len(s.lineStarts), func(i int) bool {
+		return s.lineStarts[i] > offset
+	})
+}
+
+// lineStart returns the byte offset of the start of the given line, or the
+// length of the source if the line is beyond its end.
+func (s *Source) lineStart(n int) int {
+	if n-1 < len(s.lineStarts) {
+		return s.lineStarts[n-1]
+	}
+	return len(s.text)
+}
+
+// Offset returns the byte offset within the source of the given position,
+// or false if the position is not within the source.
+//
+// Columns are interpreted as the scanner produces them: tabs in a line's
+// indentation advance to the next multiple of eight columns, and all other
+// bytes occupy one column each. A position in the column just beyond the
+// end of a line refers to the line's terminator, so that it may be used as
+// the end of a slice.
+func (s *Source) Offset(pos Position) (int, bool) {
+	start, end, ok := s.lineBounds(pos.Line)
+	if !ok || pos.Column < 1 {
+		return 0, false
+	}
+
+	col := 1
+	offset := start
+	indenting := true
+	for col < pos.Column {
+		if offset >= end {
+			return 0, false
+		}
+		switch c := s.text[offset]; {
+		case c == '\t' && indenting:
+			col = ((col-1)/8+1)*8 + 1
+		case c == ' ' && indenting:
+			col++
+		default:
+			indenting = false
+			col++
+		}
+		offset++
+	}
+	if col != pos.Column {
+		// The position lies within the expansion of a tab.
+		return 0, false
+	}
+	return offset, true
+}
+
+// Slice returns the text between the given start position, inclusive, and
+// end position, exclusive, or false if either position is not within the
+// source or if end precedes start.
+func (s *Source) Slice(start, end Position) (string, bool) {
+	startOffset, ok := s.Offset(start)
+	if !ok {
+		return "", false
+	}
+	endOffset, ok := s.Offset(end)
+	if !ok || endOffset < startOffset {
+		return "", false
+	}
+	return s.text[startOffset:endOffset], true
+}
+
+// LineRange returns the text of the given lines, from first to last
+// inclusive, including the line terminators between them but not the
+// one after the last line. It returns false if either line is not within
+// the source or if last precedes first.
+func (s *Source) LineRange(first, last int) (string, bool) {
+	start, _, ok := s.lineBounds(first)
+	if !ok {
+		return "", false
+	}
+	_, end, ok := s.lineBounds(last)
+	if !ok || end < start {
+		return "", false
+	}
+	return s.text[start:end], true
+}
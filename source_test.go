@@ -0,0 +1,116 @@
+package rst
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeepSource(t *testing.T) {
+	const src = "* one\r\n  more \r\n* two\r\n\r\nPara::\r\n\r\n    code\r\n\t  x\r\n"
+
+	if got := ParseFragmentString(src, "a.rst").Source; got != nil {
+		t.Errorf("source retained by default")
+	}
+
+	frag := ParseFragmentString(src, "a.rst", Options{KeepSource: true})
+	source := frag.Source
+	if source == nil {
+		t.Fatalf("source not retained")
+	}
+	if got, want := source.Text(), src; got != want {
+		t.Errorf("wrong text\ngot:  %q\nwant: %q", got, want)
+	}
+	if got, want := source.Filename, "a.rst"; got != want {
+		t.Errorf("wrong filename %q; want %q", got, want)
+	}
+	if got, want := source.Lines(), 8; got != want {
+		t.Errorf("wrong line count %d; want %d", got, want)
+	}
+	if got, want := source.Line(2), "  more "; got != want {
+		t.Errorf("wrong line 2 %q; want %q", got, want)
+	}
+	if got := source.Line(9); got != "" {
+		t.Errorf("line beyond the end is %q", got)
+	}
+
+	// A list item's text runs from its own position to that of the
+	// following item.
+	items := frag.Body[0].(*BulletList).Items
+	got, ok := source.Slice(items[0].Pos, items[1].Pos)
+	if want := "* one\r\n  more \r\n"; !ok || got != want {
+		t.Errorf("wrong text for first item %q, %t; want %q", got, ok, want)
+	}
+
+	// The paragraph's text, given its end as the column just beyond the
+	// end of its line.
+	para := frag.Body[1].(*Paragraph)
+	got, ok = source.Slice(para.Pos, Position{Line: 5, Column: 7})
+	if want := "Para::"; !ok || got != want {
+		t.Errorf("wrong text for paragraph %q, %t; want %q", got, ok, want)
+	}
+
+	// The literal block that follows it keeps its original indentation,
+	// including the tab that the scanner expands.
+	got, ok = source.LineRange(7, 8)
+	if want := "    code\r\n\t  x"; !ok || got != want {
+		t.Errorf("wrong text for literal block %q, %t; want %q", got, ok, want)
+	}
+	got, ok = source.Slice(Position{Line: 8, Column: 11}, Position{Line: 8, Column: 12})
+	if want := "x"; !ok || got != want {
+		t.Errorf("wrong text after tab %q, %t; want %q", got, ok, want)
+	}
+}
+
+func TestSourceOffset(t *testing.T) {
+	source := newSource("", "ab\n\tc\nlast\na\tb")
+
+	tests := []struct {
+		Pos    Position
+		Offset int
+		OK     bool
+	}{
+		{Position{Line: 1, Column: 1}, 0, true},
+		{Position{Line: 1, Column: 3}, 2, true},
+		{Position{Line: 1, Column: 4}, 0, false},
+		{Position{Line: 2, Column: 1}, 3, true},
+		{Position{Line: 2, Column: 5}, 0, false},
+		{Position{Line: 2, Column: 9}, 4, true},
+		{Position{Line: 2, Column: 10}, 5, true},
+		{Position{Line: 3, Column: 5}, 10, true},
+		{Position{Line: 4, Column: 3}, 13, true},
+		{Position{Line: 5, Column: 1}, 0, false},
+		{Position{Line: 0, Column: 1}, 0, false},
+		{Position{Line: 1, Column: 0}, 0, false},
+	}
+	for _, test := range tests {
+		got, ok := source.Offset(test.Pos)
+		if got != test.Offset || ok != test.OK {
+			t.Errorf("Offset(%d:%d) = %d, %t; want %d, %t", test.Pos.Line, test.Pos.Column, got, ok, test.Offset, test.OK)
+		}
+	}
+
+	if _, ok := source.Slice(Position{Line: 2, Column: 1}, Position{Line: 1, Column: 1}); ok {
+		t.Errorf("Slice succeeded with end before start")
+	}
+	if got, _ := source.LineRange(1, 3); !strings.HasSuffix(got, "\nlast") {
+		t.Errorf("wrong line range %q", got)
+	}
+}
+
+func TestKeepSourceReader(t *testing.T) {
+	const src = "* one\n* two\n"
+	frag := ParseFragment(strings.NewReader(src), "a.rst", Options{KeepSource: true})
+	if frag.Source == nil || frag.Source.Text() != src {
+		t.Fatalf("wrong source %#v", frag.Source)
+	}
+
+	buf := []byte(src)
+	frag = ParseFragmentBytes(buf, "a.rst", Options{KeepSource: true})
+	copy(buf, "+ ONE")
+	if got := frag.Source.Text(); got != src {
+		t.Errorf("source changed with the given slice: %q", got)
+	}
+	if got := frag.Body[0].(*BulletList).Items[0].Body[0].(*Paragraph).Text[0]; got != CharData("one") {
+		t.Errorf("text changed with the given slice: %q", got)
+	}
+}
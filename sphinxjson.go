@@ -0,0 +1,158 @@
+package rst
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// sphinxJSONPage is the subset of the per-page object written by the Sphinx
+// JSON builder that can be produced from a single document.
+type sphinxJSONPage struct {
+	Title      string            `json:"title"`
+	Body       string            `json:"body"`
+	TOC        string            `json:"toc"`
+	DisplayTOC bool              `json:"display_toc"`
+	Meta       map[string]string `json:"meta"`
+	SourceName string            `json:"sourcename"`
+}
+
+// WriteSphinxJSON writes the given *Document or *Fragment to w as a JSON
+// object in the shape of a page written by the Sphinx JSON ("fjson")
+// builder, so that it can be consumed by front ends designed for Sphinx.
+//
+// The object has the following properties:
+//
+//	title        the document title as HTML, or empty for a fragment
+//	body         the content of the document as HTML, without the title
+//	toc          a local table of contents as nested HTML lists, linking
+//	             to the anchor ids of the sections in body
+//	display_toc  true if the table of contents has more than one entry
+//	meta         the document's bibliographic fields as plain text, keyed
+//	             by their normalized names
+//	sourcename   the name of the file that the document was parsed from,
+//	             or empty if it is not known
+//
+// The HTML is produced by the default templates of TemplateRenderer, and
+// the table of contents uses the same ids as ExtractIndex.
+//
+// Sphinx also includes properties describing the page's place in a larger
+// project, such as its parents and neighbors, which cannot be determined
+// from a single document and so are not included.
+func WriteSphinxJSON(w io.Writer, node interface{}) error {
+	r, err := NewTemplateRenderer("")
+	if err != nil {
+		return err
+	}
+	render := func(node interface{}) (string, error) {
+		var buf bytes.Buffer
+		err := r.Render(&buf, node)
+		return buf.String(), err
+	}
+
+	page := sphinxJSONPage{
+		Meta: map[string]string{},
+	}
+
+	var title Text
+	var content *Fragment
+	switch n := node.(type) {
+	case *Document:
+		sphinxMeta(page.Meta, n.DocInfo)
+		page.SourceName = n.SourceName
+		title = n.Title
+		content = &Fragment{Body: n.Body, ChildElements: n.ChildElements, Pos: n.Pos}
+	case *Fragment:
+		page.SourceName = n.SourceName
+		content = n
+	default:
+		return fmt.Errorf("cannot write %T as Sphinx JSON", node)
+	}
+
+	if page.Title, err = render(title); err != nil {
+		return err
+	}
+	if page.Body, err = render(content); err != nil {
+		return err
+	}
+
+	// ExtractIndex only gives a depth-zero entry for content before the
+	// first section, which Sphinx represents as a link to the top of the
+	// page, titled with the document title.
+	var entries []IndexEntry
+	for _, entry := range ExtractIndex(node) {
+		if entry.Depth == 0 && len(entry.Title) == 0 {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	var tocBuf bytes.Buffer
+	if _, err := sphinxTOC(&tocBuf, entries, 0, render); err != nil {
+		return err
+	}
+	page.TOC = tocBuf.String()
+	page.DisplayTOC = len(entries) > 1
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(page)
+}
+
+// sphinxMeta adds the fields of the given DocInfo to meta, in the same way
+// as Sphinx records a document's metadata.
+func sphinxMeta(meta map[string]string, info *DocInfo) {
+	if info.IsEmpty() {
+		return
+	}
+	if len(info.Author) != 0 {
+		meta["author"] = info.Author.String()
+	}
+	if len(info.Authors) != 0 {
+		authors := make([]string, len(info.Authors))
+		for i, author := range info.Authors {
+			authors[i] = author.String()
+		}
+		meta["authors"] = strings.Join(authors, ", ")
+	}
+	for _, field := range info.docInfoText() {
+		if len(field.Text) != 0 {
+			meta[field.Name] = field.Text.String()
+		}
+	}
+	for _, field := range info.Fields {
+		meta[normalizeName(field.Name.String())] = PlainText(field.Body)
+	}
+}
+
+// sphinxTOC writes a nested HTML list for the leading entries of the given
+// slice that are at least as deep as the first, and returns how many entries
+// it consumed.
+func sphinxTOC(buf *bytes.Buffer, entries []IndexEntry, start int, render func(interface{}) (string, error)) (int, error) {
+	if start >= len(entries) {
+		return start, nil
+	}
+	depth := entries[start].Depth
+
+	buf.WriteString("<ul>\n")
+	i := start
+	for i < len(entries) && entries[i].Depth >= depth {
+		entry := entries[i]
+		title, err := render(entry.Title)
+		if err != nil {
+			return i, err
+		}
+		fmt.Fprintf(buf, `<li><a class="reference internal" href="#%s">%s</a>`, html.EscapeString(entry.ID), title)
+		i++
+		if i < len(entries) && entries[i].Depth > depth {
+			if i, err = sphinxTOC(buf, entries, i, render); err != nil {
+				return i, err
+			}
+		}
+		buf.WriteString("</li>\n")
+	}
+	buf.WriteString("</ul>\n")
+	return i, nil
+}
@@ -0,0 +1,133 @@
+package rst
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteSphinxJSON(t *testing.T) {
+	doc := templateTestDocument()
+	doc.SourceName = "guide.rst"
+	var buf bytes.Buffer
+	if err := WriteSphinxJSON(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %s\n%s", err, buf.String())
+	}
+
+	want := map[string]interface{}{
+		"title": "Guide",
+		"body": `<p>Fish &amp; &lt;chips&gt;</p>
+<div class="section" id="getting-started">
+<h1>Getting Started</h1>
+<ul class="simple">
+<li><p>one</p>
+</li>
+<li><p>two</p>
+</li>
+</ul>
+<blockquote>
+<p>quoted</p>
+<p class="attribution">&mdash;someone</p>
+</blockquote>
+<pre class="literal-block">if a &lt; b {
+    return
+}
+</pre>
+<div class="section" id="details">
+<h2>Details</h2>
+<ol class="loweralpha simple" start="2">
+<li><p>b</p>
+</li>
+</ol>
+</div>
+</div>
+<hr class="docutils" />
+<div class="system-message">
+<p class="system-message-title">System Message: WARNING (test.rst:9:1)</p>
+<p>something odd</p>
+</div>
+`,
+		"toc": `<ul>
+<li><a class="reference internal" href="#">Guide</a><ul>
+<li><a class="reference internal" href="#getting-started">Getting Started</a><ul>
+<li><a class="reference internal" href="#details">Details</a></li>
+</ul>
+</li>
+</ul>
+</li>
+</ul>
+`,
+		"display_toc": true,
+		"meta":        map[string]interface{}{},
+		"sourcename":  "guide.rst",
+	}
+	for key, wantValue := range want {
+		if wantStr, ok := wantValue.(string); ok {
+			if gotStr, _ := got[key].(string); gotStr != wantStr {
+				t.Errorf("wrong %s\n%s", key, diffLines(wantStr, gotStr))
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got[key], wantValue) {
+			t.Errorf("wrong %s %#v; want %#v", key, got[key], wantValue)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("wrong number of properties %d; want %d", len(got), len(want))
+	}
+
+	// HTML must not be escaped as JSON unicode escapes, as Sphinx does not.
+	if bytes.Contains(buf.Bytes(), []byte(`\u003c`)) {
+		t.Errorf("HTML was escaped in the JSON output:\n%s", buf.String())
+	}
+}
+
+func TestWriteSphinxJSONFragment(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSphinxJSON(&buf, ParseFragmentString("hello", "test.rst")); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"title":"","body":"<p>hello</p>\n","toc":"","display_toc":false,"meta":{},"sourcename":"test.rst"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\ngot:  %s\nwant: %s", got, want)
+	}
+
+	if err := WriteSphinxJSON(&buf, Body{}); err == nil {
+		t.Errorf("no error for unsupported node type")
+	}
+}
+
+func TestWriteSphinxJSONMeta(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSphinxJSON(&buf, docInfoTestDocument()); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Body string            `json:"body"`
+		Meta map[string]string `json:"meta"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"authors":     "Ann, Bob",
+		"version":     "1.0",
+		"date":        "2020-01-01",
+		"reviewed by": "Cat",
+		"audience":    "everyone",
+	}
+	if !reflect.DeepEqual(got.Meta, want) {
+		t.Errorf("wrong meta\ngot:  %#v\nwant: %#v", got.Meta, want)
+	}
+	if strings.Contains(got.Body, "docinfo") {
+		t.Errorf("body includes the docinfo\n%s", got.Body)
+	}
+}
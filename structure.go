@@ -5,6 +5,8 @@ package rst
 //
 // For a valid RST document, a Structure sequence will be a list of sections,
 // with each sequential pair of sections optionally separated by one transition.
+//
+// An empty Structure is always represented as nil by the parser.
 type Structure []StructureElement
 
 // StructureElement implementers can participate in the structural model of
@@ -17,10 +19,11 @@ type StructureElement interface {
 	// Returns a Structure sequence that might be empty or nil.
 	StructureChildElements() Structure
 
-	Position() Position
+	Node
 }
 
 type Section struct {
+	Attributes
 	Title         Text
 	Body          Body
 	ChildElements Structure
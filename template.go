@@ -0,0 +1,464 @@
+package rst
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// TemplateRenderer is a Renderer that writes HTML using a set of
+// html/template templates, one for each element type, so that callers can
+// take full control of the markup produced for each element.
+//
+// Each template is executed with the element itself as its data, and is
+// named after the corresponding docutils element:
+//
+//	fragment, document, header, footer, docinfo, section, heading,
+//	transition, paragraph, block_quote, bullet_list, enumerated_list,
+//	list_item, comment, literal_block, raw, system_message, problematic,
+//	text
+//
+// The "heading" template is executed by "section" with the section's Title,
+// so that the heading can be replaced without replacing the whole section.
+//
+// The "raw" template is used only for Raw elements whose format includes
+// "html", and only if RawPolicy allows it. Its data is the content of the
+// element as template.HTML, so that it is not escaped. Under the default
+// policy such elements are instead rendered using "system_message", for a
+// warning, followed by "literal_block", for their escaped content.
+//
+// The "header" and "footer" templates are executed with the Body of the
+// document's header or footer, and only when it is not empty. Likewise,
+// "docinfo" is executed with the document's DocInfo only when it has at
+// least one field.
+// The "problematic" template is used for Error elements that appear within
+// Text, and "system_message" for all others. The "text" template is used
+// for each CharData, with consecutive CharData separated by newlines.
+//
+// In addition to the standard template functions, templates may call:
+//
+//	render NODE      renders a node, a sequence such as Body or Text, or
+//	                 a slice of list items, using these same templates
+//	level            the nesting level of the section being rendered,
+//	                 starting at 1 for top-level sections
+//	anchor TEXT      a unique anchor id for the given title, as generated
+//	                 by the Anchors field
+//	severity ERROR   "INFO", "WARNING" or "ERROR", for a system message
+//
+// The default templates, given in DefaultHTMLTemplates, produce HTML in
+// the style of the docutils HTML writer and serve as examples for writing
+// replacements.
+type TemplateRenderer struct {
+	// RawPolicy selects what is done with Raw elements containing HTML.
+	// By default their content is escaped, so that it appears as text.
+	RawPolicy RawPolicy
+
+	// RawFilter is called for each Raw element containing HTML when
+	// RawPolicy is RawAllowListed, and returns the HTML to include in its
+	// place, or an empty string to omit it. It may return the element's
+	// content unchanged, rewrite it, or strip the parts it does not allow.
+	RawFilter func(raw *Raw) string
+
+	// Messages selects how Error elements are presented. By default,
+	// warnings and errors are rendered in place, and info messages are
+	// omitted.
+	Messages MessagePolicy
+
+	// Anchors selects how the "anchor" template function generates ids.
+	// Only its Slug and Duplicates settings are used: each rendering
+	// starts with no ids generated, so ids are unique within each rendered
+	// document. By default, ids are generated in the same way as docutils.
+	Anchors Anchors
+
+	base *template.Template
+}
+
+// RawPolicy is the policy of a TemplateRenderer for Raw elements.
+type RawPolicy int
+
+const (
+	// RawDisallow renders the content of Raw elements whose format
+	// includes "html" as an escaped literal block, along with a warning,
+	// so that no markup from them reaches the output. This is the default,
+	// so that a tree from an untrusted source is safe to render.
+	RawDisallow RawPolicy = iota
+
+	// RawAllowListed passes the content of Raw elements whose format
+	// includes "html" to RawFilter, and includes the HTML it returns.
+	// Without a RawFilter, it behaves as RawDisallow.
+	RawAllowListed
+
+	// RawAllowAll includes the content of Raw elements whose format
+	// includes "html" in the output unchanged. This is appropriate only
+	// when the tree comes from a trusted source.
+	RawAllowAll
+
+	// RawOmit omits all Raw elements.
+	RawOmit
+)
+
+// NewTemplateRenderer returns a TemplateRenderer that uses the default
+// templates, except for any that are redefined in the given template
+// source using "define" actions, such as:
+//
+//	{{define "bullet_list"}}<ul class="list">{{render .Items}}</ul>{{end}}
+//
+// The source may also define helper templates of its own. It is an error
+// for the source to contain anything other than template definitions and
+// whitespace.
+func NewTemplateRenderer(overrides string) (*TemplateRenderer, error) {
+	base, err := template.New("rst").Funcs(templateFuncs(nil)).Parse(DefaultHTMLTemplates)
+	if err != nil {
+		// The default templates are fixed, so this is a bug.
+		panic(fmt.Sprintf("invalid default templates: %s", err))
+	}
+
+	if overrides != "" {
+		base, err = base.New("overrides").Parse(overrides)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(templateText(base.Lookup("overrides"))) != "" {
+			return nil, fmt.Errorf("template source must contain only template definitions")
+		}
+	}
+
+	return &TemplateRenderer{base: base}, nil
+}
+
+func templateText(t *template.Template) string {
+	if t == nil || t.Tree == nil || t.Tree.Root == nil {
+		return ""
+	}
+	return t.Tree.Root.String()
+}
+
+// Render writes the given node to w as HTML.
+func (r *TemplateRenderer) Render(w io.Writer, node interface{}) error {
+	// Each rendering has its own state, so the functions that depend on it
+	// are bound to a fresh copy of the templates.
+	tr := &templateRendering{
+		anchors:   Anchors{Slug: r.Anchors.Slug, Duplicates: r.Anchors.Duplicates},
+		rawPolicy: r.RawPolicy,
+		rawFilter: r.RawFilter,
+	}
+	t, err := r.base.Clone()
+	if err != nil {
+		return err
+	}
+	tr.tmpl = t.Funcs(templateFuncs(tr))
+
+	html, err := tr.render(r.Messages.Apply(node))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, string(html))
+	return err
+}
+
+// templateRendering holds the state of a single call to
+// TemplateRenderer.Render.
+type templateRendering struct {
+	tmpl      *template.Template
+	level     int
+	anchors   Anchors
+	rawPolicy RawPolicy
+	rawFilter func(raw *Raw) string
+}
+
+func templateFuncs(tr *templateRendering) template.FuncMap {
+	if tr == nil {
+		// Placeholders so that the templates can be parsed before any
+		// rendering state exists.
+		tr = &templateRendering{}
+	}
+	return template.FuncMap{
+		"render": tr.render,
+		"level": func() int {
+			return tr.level
+		},
+		"anchor": func(title Text) string {
+			return tr.anchors.ID(title)
+		},
+		"severity": func(err *Error) string {
+			_, typ := docutilsLevel(err.Severity)
+			return typ
+		},
+	}
+}
+
+func (tr *templateRendering) render(node interface{}) (template.HTML, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch n := node.(type) {
+	case *Fragment:
+		err = tr.execute(&buf, "fragment", n)
+	case *Document:
+		err = tr.execute(&buf, "document", n)
+	case *Section:
+		tr.level++
+		err = tr.execute(&buf, "section", n)
+		tr.level--
+	case *Comment:
+		err = tr.execute(&buf, "comment", n)
+	case *LiteralBlock:
+		err = tr.execute(&buf, "literal_block", n)
+	case *Raw:
+		if n.HasFormat("html") {
+			err = tr.raw(&buf, n)
+		}
+	case *Transition:
+		err = tr.execute(&buf, "transition", n)
+	case *Paragraph:
+		err = tr.execute(&buf, "paragraph", n)
+	case *BlockQuote:
+		err = tr.execute(&buf, "block_quote", n)
+	case *BulletList:
+		err = tr.execute(&buf, "bullet_list", n)
+	case *EnumeratedList:
+		err = tr.execute(&buf, "enumerated_list", n)
+	case *ListItem:
+		err = tr.execute(&buf, "list_item", n)
+	case *Error:
+		err = tr.execute(&buf, "system_message", n)
+	case []*ListItem:
+		for _, item := range n {
+			err = tr.renderInto(&buf, item)
+			if err != nil {
+				break
+			}
+		}
+	case Body:
+		for _, elem := range n {
+			err = tr.renderInto(&buf, elem)
+			if err != nil {
+				break
+			}
+		}
+	case Structure:
+		for _, elem := range n {
+			err = tr.renderInto(&buf, elem)
+			if err != nil {
+				break
+			}
+		}
+	case Text:
+		err = tr.text(&buf, n)
+	default:
+		err = fmt.Errorf("cannot render %T with templates", node)
+	}
+
+	return template.HTML(buf.String()), err
+}
+
+// raw renders a Raw element containing HTML as the policy requires.
+func (tr *templateRendering) raw(buf *bytes.Buffer, raw *Raw) error {
+	switch {
+	case tr.rawPolicy == RawOmit:
+		return nil
+	case tr.rawPolicy == RawAllowAll:
+		return tr.execute(buf, "raw", template.HTML(raw.Text))
+	case tr.rawPolicy == RawAllowListed && tr.rawFilter != nil:
+		if html := tr.rawFilter(raw); html != "" {
+			return tr.execute(buf, "raw", template.HTML(html))
+		}
+		return nil
+	}
+
+	warning := &Error{
+		Message:  "raw HTML is not allowed; showing it as literal text",
+		Pos:      raw.Pos,
+		Severity: SeverityWarning,
+	}
+	if err := tr.execute(buf, "system_message", warning); err != nil {
+		return err
+	}
+	return tr.execute(buf, "literal_block", &LiteralBlock{Text: raw.Text, Pos: raw.Pos})
+}
+
+func (tr *templateRendering) renderInto(buf *bytes.Buffer, node interface{}) error {
+	html, err := tr.render(node)
+	buf.WriteString(string(html))
+	return err
+}
+
+func (tr *templateRendering) text(buf *bytes.Buffer, text Text) error {
+	for i, elem := range text {
+		var err error
+		switch n := elem.(type) {
+		case CharData:
+			// The parser produces a separate CharData for each source
+			// line, so consecutive ones are separated by line breaks.
+			if i > 0 {
+				if _, ok := text[i-1].(CharData); ok {
+					buf.WriteString("\n")
+				}
+			}
+			err = tr.execute(buf, "text", string(n))
+		case *Error:
+			err = tr.execute(buf, "problematic", n)
+		default:
+			err = tr.text(buf, elem.InlineChildNodes())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tr *templateRendering) execute(buf *bytes.Buffer, name string, data interface{}) error {
+	return tr.tmpl.ExecuteTemplate(buf, name, data)
+}
+
+// DefaultHTMLTemplates is the source of the templates that a
+// TemplateRenderer uses for any element whose template is not overridden.
+const DefaultHTMLTemplates = `
+{{- define "fragment" -}}
+{{render .Body}}{{render .ChildElements}}
+{{- end -}}
+
+{{- define "document" -}}
+{{with .Decoration}}{{with .Header}}{{template "header" .}}{{end}}{{end -}}
+<div class="document">
+{{with .Title}}<h1 class="title">{{render .}}</h1>
+{{end -}}
+{{with .Subtitle}}<h2 class="subtitle">{{render .}}</h2>
+{{end -}}
+{{with .DocInfo}}{{if not .IsEmpty}}{{template "docinfo" .}}{{end}}{{end -}}
+{{render .Body}}{{render .ChildElements}}</div>
+{{with .Decoration}}{{with .Footer}}{{template "footer" .}}{{end}}{{end -}}
+{{end -}}
+
+{{- define "header" -}}
+<div class="header">
+{{render .}}<hr class="header" />
+</div>
+{{end -}}
+
+{{- define "footer" -}}
+<div class="footer">
+<hr class="footer" />
+{{render .}}</div>
+{{end -}}
+
+{{- define "docinfo" -}}
+<dl class="docinfo simple">
+{{with .Author}}<dt class="author">Author</dt>
+<dd class="author">{{render .}}</dd>
+{{end -}}
+{{with .Authors}}<dt class="authors">Authors</dt>
+<dd class="authors">{{range $i, $author := .}}{{if $i}}<br />
+{{end}}{{render $author}}{{end}}</dd>
+{{end -}}
+{{with .Organization}}<dt class="organization">Organization</dt>
+<dd class="organization">{{render .}}</dd>
+{{end -}}
+{{with .Address}}<dt class="address">Address</dt>
+<dd class="address">{{render .}}</dd>
+{{end -}}
+{{with .Contact}}<dt class="contact">Contact</dt>
+<dd class="contact">{{render .}}</dd>
+{{end -}}
+{{with .Version}}<dt class="version">Version</dt>
+<dd class="version">{{render .}}</dd>
+{{end -}}
+{{with .Revision}}<dt class="revision">Revision</dt>
+<dd class="revision">{{render .}}</dd>
+{{end -}}
+{{with .Status}}<dt class="status">Status</dt>
+<dd class="status">{{render .}}</dd>
+{{end -}}
+{{with .Date}}<dt class="date">Date</dt>
+<dd class="date">{{render .}}</dd>
+{{end -}}
+{{with .Copyright}}<dt class="copyright">Copyright</dt>
+<dd class="copyright">{{render .}}</dd>
+{{end -}}
+{{range .Fields}}<dt class="field">{{render .Name}}</dt>
+<dd class="field">{{render .Body}}</dd>
+{{end -}}
+</dl>
+{{end -}}
+
+{{- define "comment" -}}
+{{- /* Comments are not rendered by default. */ -}}
+{{- end -}}
+
+{{- define "literal_block" -}}
+<pre class="literal-block">{{.Text}}
+</pre>
+{{end -}}
+
+{{- define "raw" -}}
+{{.}}
+{{end -}}
+
+{{- define "section" -}}
+<div class="section" id="{{anchor .Title}}">
+{{template "heading" .Title}}
+{{render .Body}}{{render .ChildElements}}</div>
+{{end -}}
+
+{{- define "heading" -}}
+{{- $level := level -}}
+{{- if eq $level 1}}<h1>{{render .}}</h1>
+{{- else if eq $level 2}}<h2>{{render .}}</h2>
+{{- else if eq $level 3}}<h3>{{render .}}</h3>
+{{- else if eq $level 4}}<h4>{{render .}}</h4>
+{{- else if eq $level 5}}<h5>{{render .}}</h5>
+{{- else}}<h6>{{render .}}</h6>
+{{- end -}}
+{{- end -}}
+
+{{- define "transition" -}}
+<hr class="docutils" />
+{{end -}}
+
+{{- define "paragraph" -}}
+<p>{{render .Text}}</p>
+{{end -}}
+
+{{- define "block_quote" -}}
+<blockquote>
+{{render .Quote}}
+{{- with .Attribution}}<p class="attribution">&mdash;{{render .}}</p>
+{{end -}}
+</blockquote>
+{{end -}}
+
+{{- define "bullet_list" -}}
+<ul class="simple">
+{{render .Items}}</ul>
+{{end -}}
+
+{{- define "enumerated_list" -}}
+<ol class="{{.EnumType}} simple"{{if ne .FirstIndex 1}} start="{{.FirstIndex}}"{{end}}>
+{{render .Items}}</ol>
+{{end -}}
+
+{{- define "list_item" -}}
+<li>{{render .Body}}</li>
+{{end -}}
+
+{{- define "system_message" -}}
+<div class="system-message">
+<p class="system-message-title">System Message: {{severity .}}{{if .Pos.Line}} ({{.Pos}}){{end}}</p>
+<p>{{.Message}}</p>
+{{- with .Skipped}}
+<pre class="literal-block">{{.}}</pre>
+{{- end}}
+</div>
+{{end -}}
+
+{{- define "problematic" -}}
+<span class="problematic">{{.Message}}</span>
+{{- end -}}
+
+{{- define "text" -}}
+{{.}}
+{{- end -}}
+`
@@ -0,0 +1,246 @@
+package rst
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func templateTestDocument() *Document {
+	para := func(s string) *Paragraph {
+		return &Paragraph{Text: Text{CharData(s)}}
+	}
+	return &Document{
+		Title: Text{CharData("Guide")},
+		Body:  Body{para("Fish & <chips>")},
+		ChildElements: Structure{
+			&Section{
+				Title: Text{CharData("Getting Started")},
+				Body: Body{
+					&BulletList{
+						Bullet: "-",
+						Items: []*ListItem{
+							{Body: Body{para("one")}},
+							{Body: Body{para("two")}},
+						},
+					},
+					&BlockQuote{
+						Quote:       Body{para("quoted")},
+						Attribution: Text{CharData("someone")},
+					},
+					&LiteralBlock{Text: "if a < b {\n    return\n}"},
+				},
+				ChildElements: Structure{
+					&Section{
+						Title: Text{CharData("Details")},
+						Body: Body{
+							&EnumeratedList{
+								EnumType:   EnumLowerAlpha,
+								EnumSuffix: ".",
+								FirstIndex: 2,
+								Items:      []*ListItem{{Body: Body{para("b")}}},
+							},
+						},
+					},
+				},
+			},
+			&Transition{},
+			&Error{
+				Message:  "something odd",
+				Pos:      Position{Line: 9, Column: 1, Filename: "test.rst"},
+				Severity: SeverityWarning,
+			},
+		},
+	}
+}
+
+const templateTestDefaultHTML = `<div class="document">
+<h1 class="title">Guide</h1>
+<p>Fish &amp; &lt;chips&gt;</p>
+<div class="section" id="getting-started">
+<h1>Getting Started</h1>
+<ul class="simple">
+<li><p>one</p>
+</li>
+<li><p>two</p>
+</li>
+</ul>
+<blockquote>
+<p>quoted</p>
+<p class="attribution">&mdash;someone</p>
+</blockquote>
+<pre class="literal-block">if a &lt; b {
+    return
+}
+</pre>
+<div class="section" id="details">
+<h2>Details</h2>
+<ol class="loweralpha simple" start="2">
+<li><p>b</p>
+</li>
+</ol>
+</div>
+</div>
+<hr class="docutils" />
+<div class="system-message">
+<p class="system-message-title">System Message: WARNING (test.rst:9:1)</p>
+<p>something odd</p>
+</div>
+</div>
+`
+
+func TestTemplateRendererDefault(t *testing.T) {
+	r, ok := LookupRenderer("html")
+	if !ok {
+		t.Fatal("no html renderer registered")
+	}
+
+	// Render twice, to make sure that no state carries over between
+	// renderings.
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		if err := r.Render(&buf, templateTestDocument()); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != templateTestDefaultHTML {
+			t.Errorf("wrong output\n%s", diffLines(templateTestDefaultHTML, got))
+		}
+	}
+}
+
+func TestTemplateRendererOverride(t *testing.T) {
+	r, err := NewTemplateRenderer(`
+{{define "bullet_list"}}<ul class="custom">{{range .Items}}<li>{{render .Body}}</li>{{end}}</ul>
+{{end}}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, templateTestDocument()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Replace(
+		templateTestDefaultHTML,
+		"<ul class=\"simple\">\n<li><p>one</p>\n</li>\n<li><p>two</p>\n</li>\n</ul>\n",
+		"<ul class=\"custom\"><li><p>one</p>\n</li><li><p>two</p>\n</li></ul>\n",
+		1,
+	)
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\n%s", diffLines(want, got))
+	}
+}
+
+func TestTemplateRendererAnchors(t *testing.T) {
+	r, err := NewTemplateRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Anchors = Anchors{Slug: GitHubSlug, Duplicates: GitHubDuplicates}
+	doc := &Document{
+		ChildElements: Structure{
+			&Section{Title: Text{CharData("Usage")}},
+			&Section{Title: Text{CharData("Usage")}},
+		},
+	}
+
+	// Render twice, to make sure that the ids generated by one rendering
+	// do not affect the next.
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		if err := r.Render(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.String()
+		for _, want := range []string{`id="usage"`, `id="usage-1"`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("output does not contain %s\n%s", want, got)
+			}
+		}
+	}
+}
+
+func TestTemplateRendererSystemMessagePosition(t *testing.T) {
+	r, ok := LookupRenderer("html")
+	if !ok {
+		t.Fatal("no html renderer registered")
+	}
+
+	// An Error constructed in code may have no position, which is then
+	// left out rather than written as ":0:0".
+	var buf bytes.Buffer
+	if err := r.Render(&buf, Body{&Error{Message: "oops"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "<p class=\"system-message-title\">System Message: ERROR</p>\n"; !strings.Contains(got, want) {
+		t.Errorf("output does not contain %s\n%s", want, got)
+	}
+}
+
+func TestTemplateRendererErrors(t *testing.T) {
+	if _, err := NewTemplateRenderer(`{{define "paragraph"}}{{.Nope}`); err == nil {
+		t.Errorf("no error for invalid template")
+	}
+	if _, err := NewTemplateRenderer(`<p>not in a definition</p>`); err == nil {
+		t.Errorf("no error for content outside of a definition")
+	}
+
+	r, err := NewTemplateRenderer(`{{define "paragraph"}}{{.Nope}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, Body{&Paragraph{}}); err == nil {
+		t.Errorf("no error for template referring to a nonexistent field")
+	}
+	if err := r.Render(&buf, 42); err == nil {
+		t.Errorf("no error for unsupported node type")
+	}
+}
+
+func TestTemplateRendererEscaping(t *testing.T) {
+	// There are no reference or image elements, and Raw elements are
+	// escaped by default, so all input-derived content reaches the output
+	// through templates that escape it. This checks that markup in every
+	// text-bearing position of the parsed tree comes out escaped.
+	const evil = `<script>alert("x")</script>`
+	inputs := []string{
+		evil,
+		"- " + evil,
+		"1. " + evil,
+		"para\n\n   " + evil + "\n\n   -- " + evil,
+		evil + "\n=====",
+		"  a\n b\n" + evil,
+	}
+
+	r, ok := LookupRenderer("html")
+	if !ok {
+		t.Fatal("no html renderer registered")
+	}
+	for _, input := range inputs {
+		fragment := ParseFragmentString(input, "<evil>.rst")
+		var buf bytes.Buffer
+		if err := r.Render(&buf, fragment); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(buf.String(), "<script") || strings.Contains(buf.String(), "<evil") {
+			t.Errorf("unescaped markup in output for %q:\n%s", input, buf.String())
+		}
+	}
+
+	// Programs that build trees might copy untrusted input into Raw
+	// elements, so those are escaped too unless the renderer is configured
+	// otherwise.
+	raw := &Raw{Format: "html", Text: evil}
+	for _, node := range []interface{}{raw, Body{raw}, &Fragment{Body: Body{raw}}} {
+		var buf bytes.Buffer
+		if err := r.Render(&buf, node); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(buf.String(), "<script") {
+			t.Errorf("unescaped raw content in output for %T:\n%s", node, buf.String())
+		}
+	}
+}
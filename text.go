@@ -1,7 +1,13 @@
 package rst
 
+import (
+	"strings"
+)
+
 // Text represents inline markup, which is a mixture of plain text nodes
 // and inline markup elements.
+//
+// An empty Text is always represented as nil by the parser.
 type Text []InlineElement
 
 // InlineElement implementation.
@@ -13,6 +19,35 @@ func (t Text) InlineChildNodes() Text {
 	return t
 }
 
+// String returns the human-readable text of the receiver, with any inline
+// markup removed.
+//
+// Consecutive CharData nodes are separated by a single space, since the
+// parser produces a separate CharData for each source line. The text of
+// other inline elements is included by recursing into their child nodes,
+// except for Error elements, which have no text of their own.
+func (t Text) String() string {
+	var buf strings.Builder
+	t.writeString(&buf)
+	return buf.String()
+}
+
+func (t Text) writeString(buf *strings.Builder) {
+	prevCharData := false
+	for _, elem := range t {
+		if s, ok := elem.(CharData); ok {
+			if prevCharData {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(string(s))
+			prevCharData = true
+			continue
+		}
+		prevCharData = false
+		elem.InlineChildNodes().writeString(buf)
+	}
+}
+
 type InlineElement interface {
 	InlineChildNodes() Text
 }
@@ -4,9 +4,9 @@ package rst
 
 import "fmt"
 
-const _TokenType_name = "INVALIDLINEBLANKLITERALINDENTDEDENTLATE_INDENTEOFERROR"
+const _TokenType_name = "INVALIDLINEBLANKLITERALINDENTDEDENTLATE_INDENTEOFERROREXPLICIT_MARKUP"
 
-var _TokenType_index = [...]uint8{0, 7, 11, 16, 23, 29, 35, 46, 49, 54}
+var _TokenType_index = [...]uint8{0, 7, 11, 16, 23, 29, 35, 46, 49, 54, 69}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
@@ -0,0 +1,108 @@
+package rst
+
+import (
+	"sort"
+)
+
+// A Transform is a step of processing that is applied to a whole document
+// after it has been parsed, for work that can only be done once all of the
+// document is known, such as numbering sections or resolving references.
+//
+// Each transform has a priority that decides when it runs relative to the
+// others, so that transforms written separately can be combined without
+// depending on the order in which they are given. See the Priority
+// constants for the canonical order.
+type Transform interface {
+	// Name identifies the transform, for use in messages.
+	Name() string
+
+	// Priority decides the order in which transforms run, lowest first.
+	Priority() int
+
+	// Apply modifies the given document in place, returning any problems
+	// it finds.
+	Apply(doc *Document) []*Error
+}
+
+// The priorities of the standard stages of processing a document, in the
+// order in which they run. A transform that implements one of these stages
+// should use its priority, and others may use any value in between to run
+// at a defined point relative to them.
+//
+// The values are those of the equivalent docutils transforms, so that
+// transforms ported from docutils can keep their priorities.
+const (
+	// PrioritySubstitutions is for expanding substitution references,
+	// which must come before references are resolved because a
+	// substitution may contain references.
+	PrioritySubstitutions = 220
+
+	// PriorityFootnotes is for numbering footnotes and matching them with
+	// the references to them.
+	PriorityFootnotes = 620
+
+	// PriorityReferences is for resolving references to their targets.
+	PriorityReferences = 660
+
+	// PrioritySectionNumbers is for numbering sections, as the "sectnum"
+	// directive requests.
+	PrioritySectionNumbers = 710
+
+	// PriorityContents is for generating tables of contents, which must
+	// come after section numbering so that the numbers are included.
+	PriorityContents = 720
+
+	// PriorityStripComments is for removing comments, as done by
+	// StripCommentsTransform.
+	PriorityStripComments = 740
+
+	// PriorityMessages is for placing system messages, as done by
+	// MessagePolicy.Transform. It comes last so that the messages from all
+	// of the other transforms are included.
+	PriorityMessages = 860
+)
+
+// ApplyTransforms applies the given transforms to the document in order of
+// their priorities, modifying it in place, and returns all of the problems
+// that they report. Transforms of equal priority run in the order given.
+func (d *Document) ApplyTransforms(ts ...Transform) []*Error {
+	sorted := make([]Transform, len(ts))
+	copy(sorted, ts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority() < sorted[j].Priority()
+	})
+
+	var errs []*Error
+	for _, t := range sorted {
+		errs = append(errs, t.Apply(d)...)
+	}
+	return errs
+}
+
+// NewTransform returns a Transform with the given name and priority that
+// calls the given function to apply it.
+func NewTransform(name string, priority int, apply func(doc *Document) []*Error) Transform {
+	return &funcTransform{
+		name:     name,
+		priority: priority,
+		apply:    apply,
+	}
+}
+
+type funcTransform struct {
+	name     string
+	priority int
+	apply    func(doc *Document) []*Error
+}
+
+func (t *funcTransform) Name() string {
+	return t.name
+}
+
+func (t *funcTransform) Priority() int {
+	return t.priority
+}
+
+func (t *funcTransform) Apply(doc *Document) []*Error {
+	return t.apply(doc)
+}
@@ -0,0 +1,88 @@
+package rst
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyTransforms(t *testing.T) {
+	var ran []string
+	record := func(name string, priority int) Transform {
+		return NewTransform(name, priority, func(doc *Document) []*Error {
+			ran = append(ran, name)
+			return []*Error{{Message: name}}
+		})
+	}
+
+	doc := &Document{}
+	errs := doc.ApplyTransforms(
+		record("late", 900),
+		record("early", 100),
+		record("middle a", 500),
+		record("middle b", 500),
+	)
+
+	want := []string{"early", "middle a", "middle b", "late"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("wrong order\ngot:  %q\nwant: %q", ran, want)
+	}
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Message)
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("wrong errors\ngot:  %q\nwant: %q", messages, want)
+	}
+}
+
+func TestApplyTransformsBuiltin(t *testing.T) {
+	doc := &Document{
+		Body: Body{
+			&Comment{Text: "comment"},
+			&Paragraph{Text: Text{CharData("text")}},
+		},
+	}
+
+	// Transforms on either side of the standard ones see the document as
+	// it is at that point in the pipeline.
+	countComments := func(doc *Document) int {
+		count := 0
+		Walk(doc, func(node interface{}) bool {
+			if _, ok := node.(*Comment); ok {
+				count++
+			}
+			return true
+		})
+		return count
+	}
+	var before, after int
+	errs := doc.ApplyTransforms(
+		MessagePolicy{Placement: MessagesAtEnd}.Transform(),
+		NewTransform("after", PriorityStripComments+1, func(doc *Document) []*Error {
+			after = countComments(doc)
+			doc.Body = append(Body{&Error{Message: "added"}}, doc.Body...)
+			return nil
+		}),
+		StripCommentsTransform,
+		NewTransform("before", PriorityStripComments-1, func(doc *Document) []*Error {
+			before = countComments(doc)
+			return nil
+		}),
+	)
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors %#v", errs)
+	}
+	if before != 1 || after != 0 {
+		t.Errorf("wrong comment counts %d before and %d after stripping; want 1 and 0", before, after)
+	}
+
+	// The message added by the transform before the messages stage was
+	// moved to the end along with any others.
+	want := &Document{
+		Body:          Body{&Paragraph{Text: Text{CharData("text")}}},
+		ChildElements: Structure{&Error{Message: "added"}},
+	}
+	if diff := Diff(want, doc); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
@@ -9,6 +9,7 @@ package rst
 // of a section.
 type Transition struct {
 	bodyElementImpl
+	Attributes
 	Pos Position
 }
 
@@ -0,0 +1,244 @@
+package rst
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Validate checks that the given document meets the structural rules that
+// the parser maintains, but that a tree constructed or rewritten by other
+// code might violate, returning an Error for each violation in source order
+// or nil if there are none. Specifically:
+//
+//   - Each element of a Structure must be a section, a transition or an
+//     Error. Other body elements must come before the sections, in Body.
+//   - Neither the document nor a section may begin or end with a transition.
+//   - Each section must have a non-empty title.
+//   - No Body, Structure or Text, nor the items of a list, may contain a nil
+//     element.
+//   - Each ID must be used only once in the whole document.
+//
+// List items can be placed only in the Items of a list, since ListItem does
+// not implement BodyElement, so the only violation that Validate can find
+// for them is a nil item.
+//
+// Each Error has the position of the offending element. Elements without
+// a position, including nil elements, are instead identified by including
+// their path from the document in the message, in the same form as Diff
+// uses, such as "Body[2].Items[0]".
+//
+// Validate is intended to protect renderers from trees that might otherwise
+// cause them to fail, and as a cheap assertion to make in the tests of code
+// that transforms documents.
+func Validate(doc *Document) []*Error {
+	v := &validator{
+		ids: make(map[string]string),
+	}
+	v.node(doc, "")
+	return v.errs
+}
+
+type validator struct {
+	errs []*Error
+
+	// ids maps each ID found so far to a description of the element that
+	// first used it.
+	ids map[string]string
+}
+
+func (v *validator) report(pos Position, path string, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if pos == (Position{}) {
+		msg = describePath(path) + ": " + msg
+	}
+	v.errs = append(v.errs, &Error{
+		Message: msg,
+		Pos:     pos,
+	})
+}
+
+// describePath returns the path of a node for use in a message. Paths are
+// built with a leading "." that is removed here, as Diff does, and the path
+// of the document itself is empty.
+func describePath(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return strings.TrimPrefix(path, ".")
+}
+
+func (v *validator) node(node interface{}, path string) {
+	pos := Position{}
+	if n, ok := node.(Node); ok {
+		pos = n.Position()
+	}
+	if attrs := NodeAttributes(node); attrs != nil {
+		v.attributes(attrs, pos, path)
+	}
+
+	switch n := node.(type) {
+	case *Document:
+		v.text(n.Title, path+".Title")
+		v.text(n.Subtitle, path+".Subtitle")
+		if n.Decoration != nil {
+			v.node(n.Decoration, path+".Decoration")
+		}
+		if n.DocInfo != nil {
+			v.node(n.DocInfo, path+".DocInfo")
+		}
+		v.transitions(n.Body, n.ChildElements, path, "document")
+		v.body(n.Body, path+".Body")
+		v.structure(n.ChildElements, path+".ChildElements")
+	case *Decoration:
+		v.body(n.Header, path+".Header")
+		v.body(n.Footer, path+".Footer")
+	case *DocInfo:
+		v.text(n.Author, path+".Author")
+		for i, author := range n.Authors {
+			v.text(author, fmt.Sprintf("%s.Authors[%d]", path, i))
+		}
+		v.text(n.Organization, path+".Organization")
+		v.text(n.Address, path+".Address")
+		v.text(n.Contact, path+".Contact")
+		v.text(n.Version, path+".Version")
+		v.text(n.Revision, path+".Revision")
+		v.text(n.Status, path+".Status")
+		v.text(n.Date, path+".Date")
+		v.text(n.Copyright, path+".Copyright")
+		for i, field := range n.Fields {
+			fieldPath := fmt.Sprintf("%s.Fields[%d]", path, i)
+			if field == nil {
+				v.report(Position{}, fieldPath, "nil field")
+				continue
+			}
+			v.node(field, fieldPath)
+		}
+	case *DocInfoField:
+		v.text(n.Name, path+".Name")
+		v.body(n.Body, path+".Body")
+	case *Section:
+		if len(n.Title) == 0 {
+			v.report(pos, path, "section has no title")
+		}
+		v.text(n.Title, path+".Title")
+		v.transitions(n.Body, n.ChildElements, path, "section")
+		v.body(n.Body, path+".Body")
+		v.structure(n.ChildElements, path+".ChildElements")
+	case *Paragraph:
+		v.text(n.Text, path+".Text")
+	case *BlockQuote:
+		v.body(n.Quote, path+".Quote")
+		v.text(n.Attribution, path+".Attribution")
+	case *BulletList:
+		v.items(n.Items, path)
+	case *EnumeratedList:
+		v.items(n.Items, path)
+	case *ListItem:
+		v.body(n.Body, path+".Body")
+	case InlineElement:
+		v.text(n.InlineChildNodes(), path)
+	}
+}
+
+func (v *validator) attributes(attrs *Attributes, pos Position, path string) {
+	for _, id := range attrs.IDs {
+		if first, exists := v.ids[id]; exists {
+			v.report(pos, path, "duplicate ID %q, first used by %s", id, first)
+			continue
+		}
+		if pos != (Position{}) {
+			v.ids[id] = "the element at " + pos.String()
+		} else {
+			v.ids[id] = describePath(path)
+		}
+	}
+}
+
+// transitions checks that the element that begins or ends the content of
+// a document or section, whose body and subsections are given, is not a
+// transition.
+func (v *validator) transitions(body Body, structure Structure, path, what string) {
+	var first, last interface{}
+	var firstPath, lastPath string
+	switch {
+	case len(body) > 0:
+		first, firstPath = body[0], path+".Body[0]"
+	case len(structure) > 0:
+		first, firstPath = structure[0], path+".ChildElements[0]"
+	}
+	switch {
+	case len(structure) > 0:
+		last, lastPath = structure[len(structure)-1], fmt.Sprintf("%s.ChildElements[%d]", path, len(structure)-1)
+	case len(body) > 0:
+		last, lastPath = body[len(body)-1], fmt.Sprintf("%s.Body[%d]", path, len(body)-1)
+	}
+
+	if t, ok := first.(*Transition); ok && t != nil {
+		v.report(t.Pos, firstPath, "%s begins with a transition", what)
+	}
+	if t, ok := last.(*Transition); ok && t != nil && lastPath != firstPath {
+		v.report(t.Pos, lastPath, "%s ends with a transition", what)
+	}
+}
+
+func (v *validator) body(body Body, path string) {
+	for i, elem := range body {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if isNilNode(elem) {
+			v.report(Position{}, elemPath, "nil element")
+			continue
+		}
+		v.node(elem, elemPath)
+	}
+}
+
+func (v *validator) structure(structure Structure, path string) {
+	for i, elem := range structure {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if isNilNode(elem) {
+			v.report(Position{}, elemPath, "nil element")
+			continue
+		}
+		switch elem.(type) {
+		case *Section, *Transition, *Error:
+		default:
+			if _, ok := elem.(BodyElement); ok {
+				v.report(elem.Position(), elemPath, "body element %T must come before the sections", elem)
+			}
+		}
+		v.node(elem, elemPath)
+	}
+}
+
+func (v *validator) text(text Text, path string) {
+	for i, elem := range text {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if isNilNode(elem) {
+			v.report(Position{}, elemPath, "nil element")
+			continue
+		}
+		v.node(elem, elemPath)
+	}
+}
+
+func (v *validator) items(items []*ListItem, path string) {
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s.Items[%d]", path, i)
+		if item == nil {
+			v.report(Position{}, itemPath, "nil list item")
+			continue
+		}
+		v.node(item, itemPath)
+	}
+}
+
+// isNilNode returns true if the given value is nil, either as an interface
+// or as a pointer that an interface holds.
+func isNilNode(node interface{}) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
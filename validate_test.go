@@ -0,0 +1,239 @@
+package rst
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// structuralParagraph is a body element that also claims to be a structural
+// element, which is the only way that a body element can be placed among
+// sections.
+type structuralParagraph struct {
+	*Paragraph
+}
+
+func (p structuralParagraph) StructureChildElements() Structure {
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	pos := func(line int) Position {
+		return Position{Line: line, Column: 1, Filename: "test.rst"}
+	}
+	para := func(line int) *Paragraph {
+		return &Paragraph{Text: Text{CharData("text")}, Pos: pos(line)}
+	}
+	section := func(line int, body Body, children Structure) *Section {
+		return &Section{Title: Text{CharData("Title")}, Body: body, ChildElements: children, Pos: pos(line)}
+	}
+	withID := func(id string, attrs *Attributes) {
+		attrs.IDs = append(attrs.IDs, id)
+	}
+
+	tests := []struct {
+		Name string
+		Doc  *Document
+		Want []string
+	}{
+		{
+			"empty",
+			&Document{},
+			nil,
+		},
+		{
+			"transitions between body elements and sections",
+			&Document{
+				Body: Body{para(1), &Transition{Pos: pos(2)}, para(3), &Transition{Pos: pos(4)}},
+				ChildElements: Structure{
+					section(5, Body{para(6), &Transition{Pos: pos(7)}, para(8)}, nil),
+					&Transition{Pos: pos(9)},
+					section(10, nil, nil),
+				},
+			},
+			nil,
+		},
+		{
+			"document begins with a transition",
+			&Document{
+				Body: Body{&Transition{Pos: pos(1)}, para(2)},
+			},
+			[]string{"test.rst:1:1: document begins with a transition"},
+		},
+		{
+			"document ends with a transition",
+			&Document{
+				Body:          Body{para(1)},
+				ChildElements: Structure{section(2, nil, nil), &Transition{Pos: pos(3)}},
+			},
+			[]string{"test.rst:3:1: document ends with a transition"},
+		},
+		{
+			"section begins and ends with transitions",
+			&Document{
+				ChildElements: Structure{
+					section(1, Body{&Transition{Pos: pos(2)}, para(3), &Transition{Pos: pos(4)}}, nil),
+				},
+			},
+			[]string{
+				"test.rst:2:1: section begins with a transition",
+				"test.rst:4:1: section ends with a transition",
+			},
+		},
+		{
+			"section consisting of a transition",
+			&Document{
+				ChildElements: Structure{
+					section(1, nil, Structure{&Transition{Pos: pos(2)}}),
+				},
+			},
+			[]string{"test.rst:2:1: section begins with a transition"},
+		},
+		{
+			"errors among sections",
+			&Document{
+				ChildElements: Structure{section(1, nil, nil), &Error{Message: "problem", Pos: pos(2)}},
+			},
+			nil,
+		},
+		{
+			"body element among sections",
+			&Document{
+				ChildElements: Structure{section(1, nil, nil), structuralParagraph{para(2)}},
+			},
+			[]string{"test.rst:2:1: body element rst.structuralParagraph must come before the sections"},
+		},
+		{
+			"section without a title",
+			&Document{
+				ChildElements: Structure{
+					section(1, nil, Structure{&Section{Pos: pos(2)}}),
+				},
+			},
+			[]string{"test.rst:2:1: section has no title"},
+		},
+		{
+			"section without a title or position",
+			&Document{
+				ChildElements: Structure{section(1, nil, nil), &Section{}},
+			},
+			[]string{":0:0: ChildElements[1]: section has no title"},
+		},
+		{
+			"nil elements",
+			&Document{
+				Title: Text{CharData("Title"), nil},
+				Body: Body{
+					nil,
+					(*Paragraph)(nil),
+					&BlockQuote{Quote: Body{para(3)}, Attribution: Text{nil}, Pos: pos(3)},
+				},
+				ChildElements: Structure{
+					section(4, Body{para(5), nil}, nil),
+					nil,
+				},
+			},
+			[]string{
+				":0:0: Title[1]: nil element",
+				":0:0: Body[0]: nil element",
+				":0:0: Body[1]: nil element",
+				":0:0: Body[2].Attribution[0]: nil element",
+				":0:0: ChildElements[0].Body[1]: nil element",
+				":0:0: ChildElements[1]: nil element",
+			},
+		},
+		{
+			"nil list items",
+			&Document{
+				Body: Body{
+					&BulletList{Bullet: "*", Items: []*ListItem{{Body: Body{para(1)}, Pos: pos(1)}, nil}, Pos: pos(1)},
+					&EnumeratedList{Items: []*ListItem{nil}, Pos: pos(3)},
+				},
+			},
+			[]string{
+				":0:0: Body[0].Items[1]: nil list item",
+				":0:0: Body[1].Items[0]: nil list item",
+			},
+		},
+		{
+			"nil docinfo field",
+			&Document{
+				DocInfo: &DocInfo{Fields: []*DocInfoField{nil}, Pos: pos(1)},
+			},
+			[]string{":0:0: DocInfo.Fields[0]: nil field"},
+		},
+		{
+			"distinct IDs",
+			func() *Document {
+				doc := &Document{
+					Body:          Body{para(1), para(2)},
+					ChildElements: Structure{section(3, nil, nil)},
+				}
+				withID("a", &doc.Body[0].(*Paragraph).Attributes)
+				withID("b", &doc.Body[1].(*Paragraph).Attributes)
+				withID("c", &doc.ChildElements[0].(*Section).Attributes)
+				return doc
+			}(),
+			nil,
+		},
+		{
+			"duplicate IDs",
+			func() *Document {
+				doc := &Document{
+					Body: Body{
+						para(1),
+						&BulletList{Items: []*ListItem{{}}, Pos: pos(2)},
+					},
+					ChildElements: Structure{section(3, nil, nil)},
+				}
+				withID("a", &doc.Body[0].(*Paragraph).Attributes)
+				withID("a", &doc.ChildElements[0].(*Section).Attributes)
+				withID("b", &doc.Body[1].(*BulletList).Items[0].Attributes)
+				withID("b", &doc.ChildElements[0].(*Section).Attributes)
+				withID("b", &doc.ChildElements[0].(*Section).Attributes)
+				return doc
+			}(),
+			[]string{
+				`test.rst:3:1: duplicate ID "a", first used by the element at test.rst:1:1`,
+				`test.rst:3:1: duplicate ID "b", first used by Body[1].Items[0]`,
+				`test.rst:3:1: duplicate ID "b", first used by Body[1].Items[0]`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(test.Doc) {
+				got = append(got, fmt.Sprintf("%s: %s", err.Pos, err.Message))
+			}
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong errors\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+// TestValidateCompleteTree checks that a tree using every kind of element
+// is valid once its IDs are made unique.
+func TestValidateCompleteTree(t *testing.T) {
+	doc := cloneTestTree()
+	n := 0
+	Walk(doc, func(node interface{}) bool {
+		if attrs := NodeAttributes(node); attrs != nil {
+			n++
+			attrs.IDs = []string{fmt.Sprintf("id%d", n)}
+		}
+		return true
+	})
+	if errs := Validate(doc); errs != nil {
+		for _, err := range errs {
+			t.Errorf("%s: %s", err.Pos, err.Message)
+		}
+	}
+
+	// Without unique IDs, every element but the first is a duplicate.
+	if got, want := len(Validate(cloneTestTree())), n-1; got != want {
+		t.Errorf("wrong number of errors for duplicate IDs %d; want %d", got, want)
+	}
+}
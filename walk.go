@@ -0,0 +1,125 @@
+package rst
+
+// Walk traverses the tree rooted at the given node in source order, calling
+// fn for each node it encounters. If fn returns false then the children of
+// that node are not visited.
+//
+// The node may be any element defined in this package, or one of the
+// sequence types Structure, Body or Text. Sequences are not themselves
+// passed to fn; instead, each of their elements is visited in turn.
+func Walk(node interface{}, fn func(node interface{}) bool) {
+	switch n := node.(type) {
+	case Structure:
+		for _, elem := range n {
+			Walk(elem, fn)
+		}
+	case Body:
+		for _, elem := range n {
+			Walk(elem, fn)
+		}
+	case Text:
+		for _, elem := range n {
+			Walk(elem, fn)
+		}
+	case nil:
+		// Nothing to visit
+	default:
+		if !fn(node) {
+			return
+		}
+		for _, child := range Children(node) {
+			Walk(child, fn)
+		}
+	}
+}
+
+// Children returns the direct children of the given node, in source order.
+//
+// Children that are grouped in sequences such as Body or Text are flattened,
+// so that for example the children of a Section are the elements of its
+// title, followed by the elements of its body, followed by its subsections.
+// If node is itself a sequence then its children are its elements.
+//
+// Nodes that have no children, and values that are not nodes at all, produce
+// an empty result.
+func Children(node interface{}) []interface{} {
+	var children []interface{}
+	appendBody := func(body Body) {
+		for _, elem := range body {
+			children = append(children, elem)
+		}
+	}
+	appendStructure := func(structure Structure) {
+		for _, elem := range structure {
+			children = append(children, elem)
+		}
+	}
+	appendText := func(text Text) {
+		for _, elem := range text {
+			children = append(children, elem)
+		}
+	}
+
+	switch n := node.(type) {
+	case *Fragment:
+		appendBody(n.Body)
+		appendStructure(n.ChildElements)
+	case *Document:
+		appendText(n.Title)
+		appendText(n.Subtitle)
+		if n.Decoration != nil {
+			children = append(children, n.Decoration)
+		}
+		if n.DocInfo != nil {
+			children = append(children, n.DocInfo)
+		}
+		appendBody(n.Body)
+		appendStructure(n.ChildElements)
+	case *Decoration:
+		appendBody(n.Header)
+		appendBody(n.Footer)
+	case *DocInfo:
+		appendText(n.Author)
+		for _, author := range n.Authors {
+			appendText(author)
+		}
+		for _, field := range n.docInfoText() {
+			appendText(field.Text)
+		}
+		for _, field := range n.Fields {
+			children = append(children, field)
+		}
+	case *DocInfoField:
+		appendText(n.Name)
+		appendBody(n.Body)
+	case *Section:
+		appendText(n.Title)
+		appendBody(n.Body)
+		appendStructure(n.ChildElements)
+	case Structure:
+		appendStructure(n)
+	case Body:
+		appendBody(n)
+	case Text:
+		appendText(n)
+	case *Paragraph:
+		appendText(n.Text)
+	case *BlockQuote:
+		appendBody(n.Quote)
+		appendText(n.Attribution)
+	case *BulletList:
+		for _, item := range n.Items {
+			children = append(children, item)
+		}
+	case *EnumeratedList:
+		for _, item := range n.Items {
+			children = append(children, item)
+		}
+	case *ListItem:
+		appendBody(n.Body)
+	case InlineElement:
+		appendText(n.InlineChildNodes())
+	}
+
+	return children
+}
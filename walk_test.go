@@ -0,0 +1,77 @@
+package rst
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	fragment := ParseFragmentString(
+		"intro\n\n* a\n\n  b\n\n* c\n\n1. d\n2. e\n\n    quote\n\n    -- attribution",
+		testParserFilename,
+	)
+
+	paragraphs := 0
+	var chars []string
+	Walk(fragment, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *Paragraph:
+			paragraphs++
+		case CharData:
+			chars = append(chars, string(n))
+		}
+		return true
+	})
+
+	if got, want := paragraphs, 7; got != want {
+		t.Errorf("found %d paragraphs; want %d", got, want)
+	}
+
+	wantChars := []string{"intro", "a", "b", "c", "d", "e", "quote", "attribution"}
+	if !reflect.DeepEqual(chars, wantChars) {
+		t.Errorf("wrong CharData\ngot:  %#v\nwant: %#v", chars, wantChars)
+	}
+}
+
+func TestWalkSkipChildren(t *testing.T) {
+	fragment := ParseFragmentString("intro\n\n* a\n\n  b\n\n* c", testParserFilename)
+
+	var chars []string
+	Walk(fragment, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *BulletList:
+			return false
+		case CharData:
+			chars = append(chars, string(n))
+		}
+		return true
+	})
+
+	wantChars := []string{"intro"}
+	if !reflect.DeepEqual(chars, wantChars) {
+		t.Errorf("wrong CharData\ngot:  %#v\nwant: %#v", chars, wantChars)
+	}
+}
+
+func TestChildren(t *testing.T) {
+	para := &Paragraph{
+		Text: Text{
+			CharData("a"),
+			CharData("b"),
+		},
+	}
+	quote := &BlockQuote{
+		Quote:       Body{para},
+		Attribution: Text{CharData("c")},
+	}
+
+	got := Children(quote)
+	want := []interface{}{para, CharData("c")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong children\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	if got := Children(CharData("a")); len(got) != 0 {
+		t.Errorf("CharData has children %#v; want none", got)
+	}
+}
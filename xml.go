@@ -0,0 +1,395 @@
+package rst
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteXML writes the given node to w as XML conforming to the Docutils
+// Generic DTD, in the same shape as the docutils "xml" writer produces.
+//
+// If node is a *Fragment or a *Document then the output is a complete XML
+// document with a "document" root element. Any other node is written as
+// a single XML element with no declaration, which is useful for testing
+// and for embedding the result in some larger XML document.
+//
+// Each element other than those with mixed content is written on its own
+// line, indented to show its nesting, as with the docutils writer's
+// --newlines and --indents options.
+func WriteXML(w io.Writer, node interface{}) error {
+	xw := &xmlWriter{w: bufio.NewWriter(w)}
+
+	switch n := node.(type) {
+	case *Fragment:
+		xw.header()
+		xw.open(0, "document", xmlSourceAttr(n.Pos))
+		xw.body(1, n.Body)
+		xw.structure(1, n.ChildElements)
+		xw.close(0, "document")
+	case *Document:
+		xw.header()
+		xw.open(0, "document", append(xmlCommonAttrs(&n.Attributes), xmlSourceAttr(n.Pos)...))
+		xw.text(1, "title", nil, n.Title)
+		xw.text(1, "subtitle", nil, n.Subtitle)
+		xw.node(1, n.Decoration)
+		xw.node(1, n.DocInfo)
+		xw.body(1, n.Body)
+		xw.structure(1, n.ChildElements)
+		xw.close(0, "document")
+	default:
+		xw.node(0, node)
+	}
+
+	if xw.err != nil {
+		return xw.err
+	}
+	return xw.w.Flush()
+}
+
+// xmlWriter writes docutils XML, retaining the first error encountered so
+// that callers need not check after each write.
+type xmlWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// xmlAttr is a single XML attribute. Attributes are given as a slice rather
+// than a map so that they are always written in a consistent order.
+type xmlAttr struct {
+	Name, Value string
+}
+
+// xmlCommonAttrs returns the XML attributes representing the given generic
+// attributes, in the order docutils uses: ids, names and classes, followed by
+// any extra attributes in lexical order.
+//
+// As in docutils, the values in each list are separated by spaces, and any
+// spaces or backslashes within the values are escaped with backslashes.
+func xmlCommonAttrs(a *Attributes) []xmlAttr {
+	if a.IsEmpty() {
+		return nil
+	}
+
+	var attrs []xmlAttr
+	list := func(name string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		escaped := make([]string, len(values))
+		for i, value := range values {
+			value = strings.Replace(value, `\`, `\\`, -1)
+			escaped[i] = strings.Replace(value, " ", `\ `, -1)
+		}
+		attrs = append(attrs, xmlAttr{name, strings.Join(escaped, " ")})
+	}
+	list("ids", a.IDs)
+	list("names", a.Names)
+	list("classes", a.Classes)
+
+	names := make([]string, 0, len(a.Extra))
+	for name := range a.Extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attrs = append(attrs, xmlAttr{name, a.Extra[name]})
+	}
+	return attrs
+}
+
+func xmlSourceAttr(pos Position) []xmlAttr {
+	if pos.Filename == "" {
+		return nil
+	}
+	return []xmlAttr{{"source", pos.Filename}}
+}
+
+func (xw *xmlWriter) header() {
+	xw.write(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	xw.write(`<!DOCTYPE document PUBLIC "+//IDN docutils.sourceforge.net//DTD Docutils Generic//EN//XML" "http://docutils.sourceforge.net/docs/ref/docutils.dtd">` + "\n")
+}
+
+func (xw *xmlWriter) node(depth int, node interface{}) {
+	switch n := node.(type) {
+	case *Section:
+		xw.open(depth, "section", xmlCommonAttrs(&n.Attributes))
+		xw.text(depth+1, "title", nil, n.Title)
+		xw.body(depth+1, n.Body)
+		xw.structure(depth+1, n.ChildElements)
+		xw.close(depth, "section")
+	case *Decoration:
+		if n.IsEmpty() {
+			return
+		}
+		xw.open(depth, "decoration", xmlCommonAttrs(&n.Attributes))
+		if len(n.Header) != 0 {
+			xw.open(depth+1, "header", nil)
+			xw.body(depth+2, n.Header)
+			xw.close(depth+1, "header")
+		}
+		if len(n.Footer) != 0 {
+			xw.open(depth+1, "footer", nil)
+			xw.body(depth+2, n.Footer)
+			xw.close(depth+1, "footer")
+		}
+		xw.close(depth, "decoration")
+	case *DocInfo:
+		if n.IsEmpty() {
+			return
+		}
+		xw.open(depth, "docinfo", xmlCommonAttrs(&n.Attributes))
+		xw.text(depth+1, "author", nil, n.Author)
+		if len(n.Authors) != 0 {
+			xw.open(depth+1, "authors", nil)
+			for _, author := range n.Authors {
+				xw.text(depth+2, "author", nil, author)
+			}
+			xw.close(depth+1, "authors")
+		}
+		for _, field := range n.docInfoText() {
+			xw.text(depth+1, field.Name, nil, field.Text)
+		}
+		for _, field := range n.Fields {
+			xw.node(depth+1, field)
+		}
+		xw.close(depth, "docinfo")
+	case *DocInfoField:
+		xw.open(depth, "field", xmlCommonAttrs(&n.Attributes))
+		xw.text(depth+1, "field_name", nil, n.Name)
+		if len(n.Body) == 0 {
+			xw.empty(depth+1, "field_body", nil)
+		} else {
+			xw.open(depth+1, "field_body", nil)
+			xw.body(depth+2, n.Body)
+			xw.close(depth+1, "field_body")
+		}
+		xw.close(depth, "field")
+	case *Comment:
+		attrs := append(xmlCommonAttrs(&n.Attributes), xmlAttr{"xml:space", "preserve"})
+		if n.Text == "" {
+			xw.empty(depth, "comment", attrs)
+			return
+		}
+		xw.text(depth, "comment", attrs, Text{CharData(n.Text)})
+	case *LiteralBlock:
+		attrs := append(xmlCommonAttrs(&n.Attributes), xmlAttr{"xml:space", "preserve"})
+		if n.Text == "" {
+			xw.empty(depth, "literal_block", attrs)
+			return
+		}
+		xw.text(depth, "literal_block", attrs, Text{CharData(n.Text)})
+	case *Raw:
+		attrs := append(
+			xmlCommonAttrs(&n.Attributes),
+			xmlAttr{"format", n.Format},
+			xmlAttr{"xml:space", "preserve"},
+		)
+		if n.Text == "" {
+			xw.empty(depth, "raw", attrs)
+			return
+		}
+		xw.text(depth, "raw", attrs, Text{CharData(n.Text)})
+	case *Transition:
+		xw.empty(depth, "transition", xmlCommonAttrs(&n.Attributes))
+	case *Paragraph:
+		xw.text(depth, "paragraph", xmlCommonAttrs(&n.Attributes), n.Text)
+	case *BlockQuote:
+		xw.open(depth, "block_quote", xmlCommonAttrs(&n.Attributes))
+		xw.body(depth+1, n.Quote)
+		xw.text(depth+1, "attribution", nil, n.Attribution)
+		xw.close(depth, "block_quote")
+	case *BulletList:
+		attrs := xmlCommonAttrs(&n.Attributes)
+		if n.Bullet != "" {
+			attrs = append(attrs, xmlAttr{"bullet", n.Bullet})
+		}
+		xw.open(depth, "bullet_list", attrs)
+		for _, item := range n.Items {
+			xw.node(depth+1, item)
+		}
+		xw.close(depth, "bullet_list")
+	case *EnumeratedList:
+		attrs := append(
+			xmlCommonAttrs(&n.Attributes),
+			xmlAttr{"enumtype", string(n.EnumType)},
+			xmlAttr{"prefix", n.EnumPrefix},
+			xmlAttr{"suffix", n.EnumSuffix},
+		)
+		if n.FirstIndex != 1 {
+			attrs = append(attrs, xmlAttr{"start", strconv.Itoa(n.FirstIndex)})
+		}
+		xw.open(depth, "enumerated_list", attrs)
+		for _, item := range n.Items {
+			xw.node(depth+1, item)
+		}
+		xw.close(depth, "enumerated_list")
+	case *ListItem:
+		attrs := xmlCommonAttrs(&n.Attributes)
+		if len(n.Body) == 0 {
+			xw.empty(depth, "list_item", attrs)
+			return
+		}
+		xw.open(depth, "list_item", attrs)
+		xw.body(depth+1, n.Body)
+		xw.close(depth, "list_item")
+	case *Error:
+		xw.systemMessage(depth, n)
+	case Body:
+		xw.body(depth, n)
+	case Structure:
+		xw.structure(depth, n)
+	case Text:
+		xw.text(depth, "paragraph", nil, n)
+	default:
+		if xw.err == nil {
+			xw.err = fmt.Errorf("cannot write %T as XML", node)
+		}
+	}
+}
+
+func (xw *xmlWriter) body(depth int, body Body) {
+	for _, elem := range body {
+		xw.node(depth, elem)
+	}
+}
+
+func (xw *xmlWriter) structure(depth int, structure Structure) {
+	for _, elem := range structure {
+		xw.node(depth, elem)
+	}
+}
+
+// text writes an element with mixed content, such as a paragraph. Nothing
+// is written if the text is empty, since all of the elements we use this
+// for are optional in the places where the text might be empty.
+func (xw *xmlWriter) text(depth int, name string, attrs []xmlAttr, text Text) {
+	if len(text) == 0 {
+		return
+	}
+	xw.indent(depth)
+	xw.startTag(name, attrs)
+	xw.inline(text)
+	xw.write("</" + name + ">\n")
+}
+
+func (xw *xmlWriter) inline(text Text) {
+	for i, elem := range text {
+		switch n := elem.(type) {
+		case CharData:
+			// The parser produces a separate CharData for each source
+			// line, so consecutive ones are separated by line breaks.
+			if i > 0 {
+				if _, ok := text[i-1].(CharData); ok {
+					xw.write("\n")
+				}
+			}
+			xw.escape(string(n))
+		case *Error:
+			xw.startTag("problematic", nil)
+			xw.escape(n.Message)
+			xw.write("</problematic>")
+		default:
+			xw.inline(elem.InlineChildNodes())
+		}
+	}
+}
+
+func (xw *xmlWriter) systemMessage(depth int, err *Error) {
+	level, typ := docutilsLevel(err.Severity)
+	attrs := append(xmlCommonAttrs(&err.Attributes), xmlAttr{"level", strconv.Itoa(level)})
+	if err.Pos.Line > 0 {
+		attrs = append(attrs, xmlAttr{"line", strconv.Itoa(err.Pos.Line)})
+	}
+	attrs = append(attrs, xmlSourceAttr(err.Pos)...)
+	attrs = append(attrs, xmlAttr{"type", typ})
+
+	xw.open(depth, "system_message", attrs)
+	xw.text(depth+1, "paragraph", nil, Text{CharData(err.Message)})
+	if err.Skipped != "" {
+		xw.indent(depth + 1)
+		xw.startTag("literal_block", []xmlAttr{{"xml:space", "preserve"}})
+		xw.escape(err.Skipped)
+		xw.write("</literal_block>\n")
+	}
+	xw.close(depth, "system_message")
+}
+
+func (xw *xmlWriter) open(depth int, name string, attrs []xmlAttr) {
+	xw.indent(depth)
+	xw.startTag(name, attrs)
+	xw.write("\n")
+}
+
+func (xw *xmlWriter) close(depth int, name string) {
+	xw.indent(depth)
+	xw.write("</" + name + ">\n")
+}
+
+func (xw *xmlWriter) empty(depth int, name string, attrs []xmlAttr) {
+	xw.indent(depth)
+	xw.write("<" + name)
+	xw.attrs(attrs)
+	xw.write("/>\n")
+}
+
+func (xw *xmlWriter) startTag(name string, attrs []xmlAttr) {
+	xw.write("<" + name)
+	xw.attrs(attrs)
+	xw.write(">")
+}
+
+func (xw *xmlWriter) attrs(attrs []xmlAttr) {
+	for _, attr := range attrs {
+		xw.write(" " + attr.Name + `="`)
+		xw.escape(attr.Value)
+		xw.write(`"`)
+	}
+}
+
+func (xw *xmlWriter) indent(depth int) {
+	xw.write(strings.Repeat("    ", depth))
+}
+
+// escape writes s with the XML special characters escaped, as described
+// for xmlEscape.
+func (xw *xmlWriter) escape(s string) {
+	xw.write(xmlEscape(s))
+}
+
+// xmlEscape returns s with the XML special characters escaped. Unlike
+// xml.EscapeText this leaves newlines and tabs as they are, so that
+// multi-line text stays readable. Characters that cannot appear in XML
+// at all are replaced with U+FFFD.
+func xmlEscape(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			buf.WriteString("&amp;")
+		case r == '<':
+			buf.WriteString("&lt;")
+		case r == '>':
+			buf.WriteString("&gt;")
+		case r == '"':
+			buf.WriteString("&quot;")
+		case r == '\n' || r == '\t':
+			buf.WriteRune(r)
+		case r < 0x20, r == 0xFFFE, r == 0xFFFF:
+			buf.WriteRune('\uFFFD')
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+func (xw *xmlWriter) write(s string) {
+	if xw.err != nil {
+		return
+	}
+	_, xw.err = xw.w.WriteString(s)
+}
@@ -0,0 +1,95 @@
+package rst
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteXML(t *testing.T) {
+	src := "Hello <world> & friends\nsecond line\n\n" +
+		"* bullet\n\n" +
+		"2. enumerated\n\n" +
+		"after\n\n" +
+		"    quote\n\n" +
+		"    -- someone\n"
+	fragment := ParseFragmentString(src, "test.rst")
+
+	var buf bytes.Buffer
+	if err := WriteXML(&buf, fragment); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE document PUBLIC "+//IDN docutils.sourceforge.net//DTD Docutils Generic//EN//XML" "http://docutils.sourceforge.net/docs/ref/docutils.dtd">
+<document source="test.rst">
+    <paragraph>Hello &lt;world&gt; &amp; friends
+second line</paragraph>
+    <bullet_list bullet="*">
+        <list_item>
+            <paragraph>bullet</paragraph>
+        </list_item>
+    </bullet_list>
+    <enumerated_list enumtype="arabic" prefix="" suffix="." start="2">
+        <list_item>
+            <paragraph>enumerated</paragraph>
+        </list_item>
+    </enumerated_list>
+    <paragraph>after</paragraph>
+    <block_quote>
+        <paragraph>quote</paragraph>
+        <attribution>someone</attribution>
+    </block_quote>
+</document>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteXMLElement(t *testing.T) {
+	tests := []struct {
+		Node interface{}
+		Want string
+	}{
+		{
+			&Section{
+				Title: Text{CharData("Title")},
+				Body:  Body{&Paragraph{Text: Text{CharData("body")}}},
+				ChildElements: Structure{
+					&Transition{},
+				},
+			},
+			"<section>\n    <title>Title</title>\n    <paragraph>body</paragraph>\n    <transition/>\n</section>\n",
+		},
+		{
+			&Error{
+				Message:  `bad "thing"`,
+				Pos:      Position{Line: 3, Column: 1, Filename: "test.rst"},
+				Severity: SeverityWarning,
+				Skipped:  "a\nb",
+			},
+			`<system_message level="2" line="3" source="test.rst" type="WARNING">` + "\n" +
+				`    <paragraph>bad &quot;thing&quot;</paragraph>` + "\n" +
+				`    <literal_block xml:space="preserve">a` + "\n" + `b</literal_block>` + "\n" +
+				`</system_message>` + "\n",
+		},
+		{
+			&Paragraph{Text: Text{CharData("nul\x00")}},
+			"<paragraph>nul�</paragraph>\n",
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WriteXML(&buf, test.Node); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != test.Want {
+			t.Errorf("wrong output for %T\ngot:\n%s\nwant:\n%s", test.Node, got, test.Want)
+		}
+	}
+
+	if err := WriteXML(&bytes.Buffer{}, 5); err == nil {
+		t.Errorf("no error for unsupported value")
+	}
+}